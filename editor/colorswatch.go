@@ -0,0 +1,298 @@
+package editor
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+)
+
+// colorLiteral is a color literal found in the text of a row.
+// start and end are the byte offsets of the literal in the text.
+type colorLiteral struct {
+	start int
+	end   int
+	color *RGBA
+}
+
+var (
+	hexColorRegexp  = regexp.MustCompile(`#(?:[0-9a-fA-F]{6}|[0-9a-fA-F]{3})\b`)
+	rgbColorRegexp  = regexp.MustCompile(`\brgba?\(\s*(\d{1,3}%?)\s*[,\s]\s*(\d{1,3}%?)\s*[,\s]\s*(\d{1,3}%?)\s*(?:[,/]\s*[\d.]+%?\s*)?\)`)
+	hslColorRegexp  = regexp.MustCompile(`\bhsla?\(\s*(\d{1,3}(?:\.\d+)?)(?:deg)?\s*[,\s]\s*(\d{1,3}(?:\.\d+)?)%\s*[,\s]\s*(\d{1,3}(?:\.\d+)?)%\s*(?:[,/]\s*[\d.]+%?\s*)?\)`)
+	nameColorRegexp = regexp.MustCompile(`\b[a-zA-Z]+\b`)
+)
+
+// findColorLiterals returns the color literals in the text: #rrggbb, #rgb,
+// rgb(), rgba(), hsl() and hsla(), and the CSS color names if names is true.
+func findColorLiterals(text string, names bool) []colorLiteral {
+	var literals []colorLiteral
+
+	for _, loc := range hexColorRegexp.FindAllStringIndex(text, -1) {
+		// Skip such as "&#123;" and the anchors of the URL.
+		if loc[0] > 0 && (text[loc[0]-1] == '&' || isWordByte(text[loc[0]-1])) {
+			continue
+		}
+		hex := text[loc[0]:loc[1]]
+		// hexToRGBA reads each digit of #rgb as is, so expand it to #rrggbb.
+		if len(hex) == 4 {
+			hex = string([]byte{'#', hex[1], hex[1], hex[2], hex[2], hex[3], hex[3]})
+		}
+		color := hexToRGBA(hex)
+		if color == nil {
+			continue
+		}
+		literals = append(literals, colorLiteral{loc[0], loc[1], color})
+	}
+
+	for _, loc := range rgbColorRegexp.FindAllStringSubmatchIndex(text, -1) {
+		var rgb [3]int
+		for i := range rgb {
+			rgb[i] = parseColorComponent(text[loc[2+i*2]:loc[3+i*2]])
+		}
+		literals = append(literals, colorLiteral{loc[0], loc[1], newRGBA(rgb[0], rgb[1], rgb[2], 1)})
+	}
+
+	for _, loc := range hslColorRegexp.FindAllStringSubmatchIndex(text, -1) {
+		h, _ := strconv.ParseFloat(text[loc[2]:loc[3]], 64)
+		s, _ := strconv.ParseFloat(text[loc[4]:loc[5]], 64)
+		l, _ := strconv.ParseFloat(text[loc[6]:loc[7]], 64)
+		literals = append(literals, colorLiteral{loc[0], loc[1], hslToRGBA(h, s/100, l/100)})
+	}
+
+	if names {
+		for _, loc := range nameColorRegexp.FindAllStringIndex(text, -1) {
+			hex, ok := cssColorNames[strings.ToLower(text[loc[0]:loc[1]])]
+			if !ok {
+				continue
+			}
+			literals = append(literals, colorLiteral{loc[0], loc[1], hexToRGBA(hex)})
+		}
+	}
+
+	return literals
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || ('0' <= b && b <= '9') || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
+
+// parseColorComponent parses a component of rgb(), either 0-255 or a percentage.
+func parseColorComponent(s string) int {
+	if strings.HasSuffix(s, "%") {
+		v, _ := strconv.Atoi(strings.TrimSuffix(s, "%"))
+		return clampColorComponent(int(math.Round(float64(v) * 255 / 100)))
+	}
+	v, _ := strconv.Atoi(s)
+
+	return clampColorComponent(v)
+}
+
+func clampColorComponent(v int) int {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+
+	return v
+}
+
+// hslToRGBA converts the hue in degrees, and the saturation and
+// the lightness from 0 to 1 into RGBA.
+func hslToRGBA(h, s, l float64) *RGBA {
+	h = math.Mod(h, 360) / 360
+	s = math.Min(math.Max(s, 0), 1)
+	l = math.Min(math.Max(l, 0), 1)
+
+	var q float64
+	if l < 0.5 {
+		q = l * (1 + s)
+	} else {
+		q = l + s - l*s
+	}
+	p := 2*l - q
+	hueToRGB := func(t float64) float64 {
+		if t < 0 {
+			t++
+		}
+		if t > 1 {
+			t--
+		}
+		switch {
+		case t < 1.0/6:
+			return p + (q-p)*6*t
+		case t < 1.0/2:
+			return q
+		case t < 2.0/3:
+			return p + (q-p)*(2.0/3-t)*6
+		}
+		return p
+	}
+
+	return newRGBA(
+		int(math.Round(hueToRGB(h+1.0/3)*255)),
+		int(math.Round(hueToRGB(h)*255)),
+		int(math.Round(hueToRGB(h-1.0/3)*255)),
+		1,
+	)
+}
+
+// contrastColor returns black or white, whichever is readable on the color.
+func contrastColor(color *RGBA) *RGBA {
+	luminance := 0.299*float64(color.R) + 0.587*float64(color.G) + 0.114*float64(color.B)
+	if luminance > 150 {
+		return newRGBA(0, 0, 0, 1)
+	}
+
+	return newRGBA(255, 255, 255, 1)
+}
+
+// colorSwatchSvg returns the icon of the color for the popupmenu.
+func colorSwatchSvg(color *RGBA) string {
+	return fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 16 16"><rect x="2" y="2" width="12" height="12" rx="2" fill="%s" stroke="%s" stroke-width="1"/></svg>`,
+		color.Hex(),
+		warpColor(color, 40).Hex(),
+	)
+}
+
+// rowText returns the text of the row and the column of each byte of it.
+func (w *Window) rowText(y int) (string, []int) {
+	var b strings.Builder
+	var cols []int
+	for x, cell := range w.content[y] {
+		char := " "
+		if cell != nil {
+			char = cell.char
+		}
+		b.WriteString(char)
+		for i := 0; i < len(char); i++ {
+			cols = append(cols, x)
+		}
+	}
+
+	return b.String(), cols
+}
+
+// colorLiterals returns the color literals in the row,
+// which are cached by the text of the row.
+func (w *Window) colorLiterals(text string) []colorLiteral {
+	if w.colorLiteralCache == nil || len(w.colorLiteralCache) > 1000 {
+		w.colorLiteralCache = make(map[string][]colorLiteral)
+	}
+	literals, ok := w.colorLiteralCache[text]
+	if !ok {
+		literals = findColorLiterals(text, editor.config.Editor.ColorSwatchNames)
+		w.colorLiteralCache[text] = literals
+	}
+
+	return literals
+}
+
+// drawColorSwatches draws a swatch before the color literals in the window,
+// or tints the background of the literals with the ColorSwatchStyle "background".
+func (w *Window) drawColorSwatches(p *gui.QPainter) {
+	if w.grid == 1 || w.isMsgGrid || w.isPopupmenu || w.s.name == "minimap" {
+		return
+	}
+
+	font := w.getFont()
+
+	// Set smooth scroll offset
+	var verScrollPixels int
+	if w.lastScrollphase != core.Qt__NoScrollPhase {
+		verScrollPixels = w.scrollPixels2
+	}
+	if editor.config.Editor.LineToScroll == 1 {
+		verScrollPixels += w.scrollPixels[1]
+	}
+	horScrollPixels := w.horizontalScrollPixels()
+
+	isBackground := editor.config.Editor.ColorSwatchStyle == "background"
+	for y := w.viewportMargins[0]; y < w.rows-w.viewportMargins[1] && y < len(w.content); y++ {
+		text, cols := w.rowText(y)
+		for _, literal := range w.colorLiterals(text) {
+			startCol := cols[literal.start]
+			endCol := cols[literal.end-1] + 1
+			X := float64(startCol)*font.cellwidth + float64(horScrollPixels)
+			Y := float64(y*font.lineHeight + verScrollPixels)
+
+			if isBackground {
+				p.FillRect4(
+					core.NewQRectF4(X, Y, float64(endCol-startCol)*font.cellwidth, float64(font.lineHeight)),
+					literal.color.QColor(),
+				)
+				p.SetFont(font.qfont)
+				p.SetPen2(contrastColor(literal.color).QColor())
+				p.DrawText3(int(X), y*font.lineHeight+verScrollPixels+font.baselineOffset, text[literal.start:literal.end])
+				continue
+			}
+
+			// The swatch is drawn in the blank cell before the literal,
+			// or under the literal if there is no room.
+			size := math.Min(font.cellwidth, float64(font.lineHeight)) * 0.7
+			if startCol > 0 && w.content[y][startCol-1] != nil && w.content[y][startCol-1].char == " " {
+				p.FillRect4(
+					core.NewQRectF4(
+						X-font.cellwidth+(font.cellwidth-size)/2,
+						Y+(float64(font.lineHeight)-size)/2,
+						size,
+						size,
+					),
+					literal.color.QColor(),
+				)
+				continue
+			}
+			p.FillRect4(
+				core.NewQRectF4(X, Y+float64(font.lineHeight)-2, float64(endCol-startCol)*font.cellwidth, 2),
+				literal.color.QColor(),
+			)
+		}
+	}
+}
+
+// cssColorNames are the named colors of CSS.
+var cssColorNames = map[string]string{
+	"aliceblue": "#f0f8ff", "antiquewhite": "#faebd7", "aqua": "#00ffff", "aquamarine": "#7fffd4",
+	"azure": "#f0ffff", "beige": "#f5f5dc", "bisque": "#ffe4c4", "black": "#000000",
+	"blanchedalmond": "#ffebcd", "blue": "#0000ff", "blueviolet": "#8a2be2", "brown": "#a52a2a",
+	"burlywood": "#deb887", "cadetblue": "#5f9ea0", "chartreuse": "#7fff00", "chocolate": "#d2691e",
+	"coral": "#ff7f50", "cornflowerblue": "#6495ed", "cornsilk": "#fff8dc", "crimson": "#dc143c",
+	"cyan": "#00ffff", "darkblue": "#00008b", "darkcyan": "#008b8b", "darkgoldenrod": "#b8860b",
+	"darkgray": "#a9a9a9", "darkgreen": "#006400", "darkgrey": "#a9a9a9", "darkkhaki": "#bdb76b",
+	"darkmagenta": "#8b008b", "darkolivegreen": "#556b2f", "darkorange": "#ff8c00", "darkorchid": "#9932cc",
+	"darkred": "#8b0000", "darksalmon": "#e9967a", "darkseagreen": "#8fbc8f", "darkslateblue": "#483d8b",
+	"darkslategray": "#2f4f4f", "darkslategrey": "#2f4f4f", "darkturquoise": "#00ced1", "darkviolet": "#9400d3",
+	"deeppink": "#ff1493", "deepskyblue": "#00bfff", "dimgray": "#696969", "dimgrey": "#696969",
+	"dodgerblue": "#1e90ff", "firebrick": "#b22222", "floralwhite": "#fffaf0", "forestgreen": "#228b22",
+	"fuchsia": "#ff00ff", "gainsboro": "#dcdcdc", "ghostwhite": "#f8f8ff", "gold": "#ffd700",
+	"goldenrod": "#daa520", "gray": "#808080", "green": "#008000", "greenyellow": "#adff2f",
+	"grey": "#808080", "honeydew": "#f0fff0", "hotpink": "#ff69b4", "indianred": "#cd5c5c",
+	"indigo": "#4b0082", "ivory": "#fffff0", "khaki": "#f0e68c", "lavender": "#e6e6fa",
+	"lavenderblush": "#fff0f5", "lawngreen": "#7cfc00", "lemonchiffon": "#fffacd", "lightblue": "#add8e6",
+	"lightcoral": "#f08080", "lightcyan": "#e0ffff", "lightgoldenrodyellow": "#fafad2", "lightgray": "#d3d3d3",
+	"lightgreen": "#90ee90", "lightgrey": "#d3d3d3", "lightpink": "#ffb6c1", "lightsalmon": "#ffa07a",
+	"lightseagreen": "#20b2aa", "lightskyblue": "#87cefa", "lightslategray": "#778899", "lightslategrey": "#778899",
+	"lightsteelblue": "#b0c4de", "lightyellow": "#ffffe0", "lime": "#00ff00", "limegreen": "#32cd32",
+	"linen": "#faf0e6", "magenta": "#ff00ff", "maroon": "#800000", "mediumaquamarine": "#66cdaa",
+	"mediumblue": "#0000cd", "mediumorchid": "#ba55d3", "mediumpurple": "#9370db", "mediumseagreen": "#3cb371",
+	"mediumslateblue": "#7b68ee", "mediumspringgreen": "#00fa9a", "mediumturquoise": "#48d1cc", "mediumvioletred": "#c71585",
+	"midnightblue": "#191970", "mintcream": "#f5fffa", "mistyrose": "#ffe4e1", "moccasin": "#ffe4b5",
+	"navajowhite": "#ffdead", "navy": "#000080", "oldlace": "#fdf5e6", "olive": "#808000",
+	"olivedrab": "#6b8e23", "orange": "#ffa500", "orangered": "#ff4500", "orchid": "#da70d6",
+	"palegoldenrod": "#eee8aa", "palegreen": "#98fb98", "paleturquoise": "#afeeee", "palevioletred": "#db7093",
+	"papayawhip": "#ffefd5", "peachpuff": "#ffdab9", "peru": "#cd853f", "pink": "#ffc0cb",
+	"plum": "#dda0dd", "powderblue": "#b0e0e6", "purple": "#800080", "rebeccapurple": "#663399",
+	"red": "#ff0000", "rosybrown": "#bc8f8f", "royalblue": "#4169e1", "saddlebrown": "#8b4513",
+	"salmon": "#fa8072", "sandybrown": "#f4a460", "seagreen": "#2e8b57", "seashell": "#fff5ee",
+	"sienna": "#a0522d", "silver": "#c0c0c0", "skyblue": "#87ceeb", "slateblue": "#6a5acd",
+	"slategray": "#708090", "slategrey": "#708090", "snow": "#fffafa", "springgreen": "#00ff7f",
+	"steelblue": "#4682b4", "tan": "#d2b48c", "teal": "#008080", "thistle": "#d8bfd8",
+	"tomato": "#ff6347", "turquoise": "#40e0d0", "violet": "#ee82ee", "wheat": "#f5deb3",
+	"white": "#ffffff", "whitesmoke": "#f5f5f5", "yellow": "#ffff00", "yellowgreen": "#9acd32",
+}
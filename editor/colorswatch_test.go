@@ -0,0 +1,116 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestFindColorLiterals(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		names bool
+		want  []colorLiteral
+	}{
+		{
+			"findColorLiterals() hex colors",
+			`fg = "#ff8000", bg = "#0af"`,
+			false,
+			[]colorLiteral{
+				{6, 13, &RGBA{255, 128, 0, 1}},
+				{22, 26, &RGBA{0, 170, 255, 1}},
+			},
+		},
+		{
+			"findColorLiterals() skips the hex not being a color",
+			`a#fff &#123; #12345 #1234567`,
+			false,
+			nil,
+		},
+		{
+			"findColorLiterals() rgb and rgba",
+			`color: rgb(255, 0, 0); background: rgba(0 100% 0 / 0.5);`,
+			false,
+			[]colorLiteral{
+				{7, 21, &RGBA{255, 0, 0, 1}},
+				{35, 55, &RGBA{0, 255, 0, 1}},
+			},
+		},
+		{
+			"findColorLiterals() hsl",
+			`hsl(240, 100%, 50%)`,
+			false,
+			[]colorLiteral{
+				{0, 19, &RGBA{0, 0, 255, 1}},
+			},
+		},
+		{
+			"findColorLiterals() ignores the names by default",
+			`local color = "Red"`,
+			false,
+			nil,
+		},
+		{
+			"findColorLiterals() names",
+			`local color = "Red" -- not reddish`,
+			true,
+			[]colorLiteral{
+				{15, 18, &RGBA{255, 0, 0, 1}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findColorLiterals(tt.text, tt.names)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d literals, want %d: %v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if got[i].start != tt.want[i].start || got[i].end != tt.want[i].end {
+					t.Errorf("literal %d: got [%d, %d), want [%d, %d)", i, got[i].start, got[i].end, tt.want[i].start, tt.want[i].end)
+				}
+				if !got[i].color.equals(tt.want[i].color) {
+					t.Errorf("literal %d: got color %v, want %v", i, got[i].color, tt.want[i].color)
+				}
+			}
+		})
+	}
+}
+
+func TestHslToRGBA(t *testing.T) {
+	tests := []struct {
+		name    string
+		h, s, l float64
+		want    *RGBA
+	}{
+		{"hslToRGBA() red", 0, 1, 0.5, &RGBA{255, 0, 0, 1}},
+		{"hslToRGBA() green", 120, 1, 0.5, &RGBA{0, 255, 0, 1}},
+		{"hslToRGBA() gray", 200, 0, 0.5, &RGBA{128, 128, 128, 1}},
+		{"hslToRGBA() wraps the hue", 360, 1, 0.5, &RGBA{255, 0, 0, 1}},
+		{"hslToRGBA() white", 0, 1, 1, &RGBA{255, 255, 255, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hslToRGBA(tt.h, tt.s, tt.l); !got.equals(tt.want) {
+				t.Errorf("hslToRGBA() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContrastColor(t *testing.T) {
+	tests := []struct {
+		name  string
+		color *RGBA
+		want  *RGBA
+	}{
+		{"contrastColor() on a light color", &RGBA{255, 255, 0, 1}, &RGBA{0, 0, 0, 1}},
+		{"contrastColor() on a dark color", &RGBA{0, 0, 128, 1}, &RGBA{255, 255, 255, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := contrastColor(tt.color); !got.equals(tt.want) {
+				t.Errorf("contrastColor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,545 @@
+package editor
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+	"github.com/akiyosi/qt/widgets"
+)
+
+// The kinds of the entries of the command palette.
+const (
+	commandGoneovim = "goneovim"
+	commandUser     = "command"
+	commandMapping  = "mapping"
+)
+
+// goneovimAction describes a command of goneovim in the command palette.
+// state returns the current state of the toggle, or "" for the others.
+type goneovimAction struct {
+	desc  string
+	state func(ws *Workspace) string
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+
+	return "off"
+}
+
+// goneovimActions are the commands of goneovim shown in the command palette.
+// The commands which are not defined, such as the workspace commands for
+// --server, are not shown.
+var goneovimActions = map[string]goneovimAction{
+	"GonvimSidebarShow": {desc: "Show the sidebar"},
+	"GonvimSidebarToggle": {desc: "Toggle the sidebar", state: func(ws *Workspace) string {
+		return onOff(editor.side != nil && editor.side.isShown)
+	}},
+	"GonvimVersion": {desc: "Show the version"},
+	"GonvimMiniMap": {desc: "Toggle the minimap", state: func(ws *Workspace) string {
+		if ws.minimap == nil {
+			return ""
+		}
+		ws.minimap.mu.Lock()
+		defer ws.minimap.mu.Unlock()
+		return onOff(ws.minimap.visible)
+	}},
+	"GonvimWorkspaceNew":        {desc: "Create a new workspace"},
+	"GonvimWorkspaceNext":       {desc: "Switch to the next workspace"},
+	"GonvimWorkspacePrevious":   {desc: "Switch to the previous workspace"},
+	"GonvimWorkspaceSwitch":     {desc: "Switch to the workspace of the number"},
+	"GonvimWorkspaceClose":      {desc: "Close the workspace"},
+	"GonvimWorkspaceRename":     {desc: "Rename the workspace"},
+	"GonvimWorkspaceMoveUp":     {desc: "Move the workspace up in the sidebar"},
+	"GonvimWorkspaceMoveDown":   {desc: "Move the workspace down in the sidebar"},
+	"GonvimWorkspaceDuplicate":  {desc: "Duplicate the workspace"},
+	"GonvimWorkspaceDetach":     {desc: "Detach the workspace into its own window"},
+	"GonvimWorkspaceAttachBack": {desc: "Attach the detached workspace back"},
+	"GonvimStartPage":           {desc: "Show the start page"},
+	"GonvimSessionSave":         {desc: "Save the session"},
+	"GonvimSessionLoad":         {desc: "Load the session"},
+	"GonvimFind":                {desc: "Find files"},
+	"GonvimGrep":                {desc: "Search the files"},
+	"GonvimCommandPalette":      {desc: "Show the command palette"},
+	"GonvimGridFont":            {desc: "Set the font of the window"},
+	"GonvimLetterSpacing":       {desc: "Set the letter spacing"},
+	"GonvimZoomIn":              {desc: "Zoom in"},
+	"GonvimZoomOut":             {desc: "Zoom out"},
+	"GonvimZoomReset":           {desc: "Reset the zoom"},
+	"GonvimMaximize":            {desc: "Toggle the maximized window"},
+	"GonvimFullscreen":          {desc: "Toggle the full screen"},
+	"GonvimToggleHorizontalScroll": {desc: "Toggle the horizontal scroll", state: func(ws *Workspace) string {
+		return onOff(!editor.config.Editor.DisableHorizontalScroll)
+	}},
+	"GonvimLigatures": {desc: "Toggle the ligatures", state: func(ws *Workspace) string {
+		return onOff(!editor.config.Editor.DisableLigatures)
+	}},
+	"GonvimSmoothScroll": {desc: "Toggle the smooth scroll", state: func(ws *Workspace) string {
+		return onOff(editor.config.Editor.SmoothScroll)
+	}},
+	"GonvimSmoothCursor": {desc: "Toggle the smooth cursor", state: func(ws *Workspace) string {
+		return onOff(editor.config.Cursor.SmoothMove)
+	}},
+	"GonvimIndentguide": {desc: "Toggle the indent guide", state: func(ws *Workspace) string {
+		return onOff(editor.config.Editor.IndentGuide)
+	}},
+	"GonvimFocus":           {desc: "Focus the window of goneovim"},
+	"GonvimMousescrollUnit": {desc: "Set the unit of the mouse scroll"},
+	"GonvimFilerOpen":       {desc: "Open the file explorer"},
+}
+
+// commandEntry is an entry of the command palette: a command of goneovim,
+// a user command of nvim or a mapping which has the description.
+type commandEntry struct {
+	kind  string
+	label string
+	hint  string
+	name  string
+	nargs string
+	state string
+}
+
+// key identifies the entry in the history.
+func (e commandEntry) key() string {
+	return e.kind + ":" + e.name
+}
+
+// commandEntries returns the entries for the user commands and the mappings
+// which nvim returns. The commands are [name, nargs, definition], and the
+// mappings are [lhs, desc], where the first one wins for the same lhs.
+// The commands of goneovim come first, described with goneovimActions.
+func commandEntries(commands, mappings [][]string) []commandEntry {
+	var actions, users, maps []commandEntry
+	seen := make(map[string]bool)
+	for _, c := range commands {
+		if len(c) < 3 || seen[":"+c[0]] {
+			continue
+		}
+		seen[":"+c[0]] = true
+		if action, ok := goneovimActions[c[0]]; ok {
+			actions = append(actions, commandEntry{
+				kind:  commandGoneovim,
+				label: action.desc,
+				hint:  ":" + c[0],
+				name:  c[0],
+				nargs: c[1],
+			})
+			continue
+		}
+		definition := strings.Join(strings.Fields(c[2]), " ")
+		if len(definition) > 80 {
+			definition = definition[:77] + "..."
+		}
+		users = append(users, commandEntry{
+			kind:  commandUser,
+			label: ":" + c[0],
+			hint:  definition,
+			name:  c[0],
+			nargs: c[1],
+		})
+	}
+	for _, m := range mappings {
+		if len(m) < 2 || m[1] == "" || seen["map:"+m[0]] {
+			continue
+		}
+		seen["map:"+m[0]] = true
+		maps = append(maps, commandEntry{
+			kind:  commandMapping,
+			label: m[1],
+			hint:  m[0],
+			name:  m[0],
+		})
+	}
+	for _, entries := range [][]commandEntry{actions, users, maps} {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].label < entries[j].label
+		})
+	}
+
+	return append(append(actions, users...), maps...)
+}
+
+// commandUse is the number of the uses of an entry, and the last time.
+type commandUse struct {
+	Count int   `json:"count"`
+	Time  int64 `json:"time"`
+}
+
+// frecency returns the score of the entry used count times, which decays
+// with the time since the last use.
+func frecency(use commandUse, now int64) int {
+	age := now - use.Time
+	weight := 1
+	switch {
+	case age < 24*60*60:
+		weight = 8
+	case age < 7*24*60*60:
+		weight = 4
+	case age < 30*24*60*60:
+		weight = 2
+	}
+
+	return use.Count * weight
+}
+
+// commandMatch is an entry which matches the pattern of the command palette.
+type commandMatch struct {
+	entry    commandEntry
+	match    []int
+	score    int
+	frecency int
+}
+
+// rankCommandEntries returns the entries which match the pattern with the
+// label or the hint, in the order of the score and the frecency. The entries
+// used often come first, though the better match wins over them.
+func rankCommandEntries(entries []commandEntry, pattern string, uses map[string]commandUse, now int64) []commandMatch {
+	var matches []commandMatch
+	for _, e := range entries {
+		match, score, ok := fuzzyMatch(pattern, e.label)
+		if !ok {
+			_, score, ok = fuzzyMatch(pattern, e.hint)
+			if !ok {
+				continue
+			}
+			match = nil
+		}
+		f := frecency(uses[e.key()], now)
+		matches = append(matches, commandMatch{e, match, score + bits.Len(uint(f)), f})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].frecency > matches[j].frecency
+	})
+
+	return matches
+}
+
+// commandHistory is the uses of the entries of the command palette,
+// which is stored in the config dir.
+type commandHistory struct {
+	mu   sync.Mutex
+	path string
+	uses map[string]commandUse
+}
+
+func newCommandHistory(configDir string) *commandHistory {
+	h := &commandHistory{
+		path: filepath.Join(configDir, "commands.json"),
+		uses: make(map[string]commandUse),
+	}
+	if data, err := os.ReadFile(h.path); err == nil {
+		json.Unmarshal(data, &h.uses)
+	}
+
+	return h
+}
+
+// snapshot returns a copy of the uses.
+func (h *commandHistory) snapshot() map[string]commandUse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	uses := make(map[string]commandUse, len(h.uses))
+	for k, v := range h.uses {
+		uses[k] = v
+	}
+
+	return uses
+}
+
+// add records the use of the entry.
+func (h *commandHistory) add(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	use := h.uses[key]
+	use.Count++
+	use.Time = time.Now().Unix()
+	h.uses[key] = use
+
+	data, err := json.MarshalIndent(h.uses, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(h.path), 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(h.path, data, 0644)
+	}
+	if err != nil {
+		editor.putLog("failed to save the command history:", err)
+	}
+}
+
+// CommandPalette is the palette shown with Ctrl+Shift+P or
+// GonvimCommandPalette, which lists the commands of goneovim, the user
+// commands of nvim and the mappings with the description.
+type CommandPalette struct {
+	ws       *Workspace
+	palette  *Palette
+	input    *widgets.QLineEdit
+	entries  []commandEntry
+	uses     map[string]commandUse
+	matches  []commandMatch
+	selected int
+	offset   int
+	id       int
+}
+
+func newCommandPalette(ws *Workspace) *CommandPalette {
+	p := initPalette()
+	p.ws = ws
+	p.widget.SetParent(ws.widget)
+	p.pattern.Hide()
+
+	input := widgets.NewQLineEdit(nil)
+	input.SetFrame(false)
+	input.SetPlaceholderText("Run a command")
+	input.SetAttribute(core.Qt__WA_InputMethodEnabled, true)
+	row := widgets.NewQWidget(nil, 0)
+	rowLayout := widgets.NewQHBoxLayout()
+	rowLayout.SetContentsMargins(p.padding, p.padding, p.padding, p.padding)
+	rowLayout.AddWidget(input, 1, 0)
+	row.SetLayout(rowLayout)
+	p.patternWidget.Layout().AddWidget(row)
+
+	c := &CommandPalette{
+		ws:      ws,
+		palette: p,
+		input:   input,
+	}
+	input.ConnectKeyPressEvent(c.keyPress)
+	input.ConnectTextChanged(func(text string) {
+		c.selected = 0
+		c.offset = 0
+		c.rank()
+	})
+	input.ConnectFocusOutEvent(func(event *gui.QFocusEvent) {
+		input.FocusOutEventDefault(event)
+		if event.Reason() != core.Qt__PopupFocusReason {
+			c.hide()
+		}
+	})
+	p.hide()
+
+	return c
+}
+
+// openCommandPalette shows the command palette, and gets the commands
+// and the mappings from nvim again.
+func (ws *Workspace) openCommandPalette() {
+	if ws.commandPalette == nil {
+		ws.commandPalette = newCommandPalette(ws)
+	}
+	if editor.commandHistory == nil {
+		editor.commandHistory = newCommandHistory(editor.configDir)
+	}
+	c := ws.commandPalette
+	c.id++
+	c.entries = nil
+	c.matches = nil
+	c.selected = 0
+	c.offset = 0
+	c.uses = editor.commandHistory.snapshot()
+	c.input.Clear()
+	c.palette.updateFont()
+	c.input.SetFont(ws.screen.font.qfont)
+	c.palette.show()
+	c.resize()
+	c.update()
+	c.input.SetFocus2()
+
+	id := c.id
+	go func() {
+		var result [][][]string
+		if err := ws.nvim.ExecLua(`return goneovim.palette_entries()`, &result); err != nil || len(result) < 2 {
+			return
+		}
+		ws.postGui("gonvim_command_entries", id, commandEntries(result[0], result[1]))
+	}()
+}
+
+func (c *CommandPalette) hide() {
+	if c == nil || c.palette.hidden {
+		return
+	}
+	c.palette.hide()
+	c.id++
+	c.ws.widget.SetFocus2()
+}
+
+func (c *CommandPalette) resize() {
+	c.palette.resize()
+	if editor.colors.widgetFg != nil {
+		c.input.SetStyleSheet(fmt.Sprintf("background-color: rgba(0, 0, 0, 0); color: %s;", editor.colors.widgetFg.String()))
+	}
+}
+
+// setEntries handles the entries got from nvim for the palette with the id.
+func (c *CommandPalette) setEntries(id int, entries []commandEntry) {
+	if id != c.id {
+		return
+	}
+	for i, e := range entries {
+		if e.kind != commandGoneovim {
+			continue
+		}
+		if action := goneovimActions[e.name]; action.state != nil {
+			entries[i].state = action.state(c.ws)
+		}
+	}
+	c.entries = entries
+	c.rank()
+}
+
+func (c *CommandPalette) rank() {
+	c.matches = rankCommandEntries(c.entries, c.input.Text(), c.uses, time.Now().Unix())
+	if c.selected >= len(c.matches) {
+		c.selected = len(c.matches) - 1
+	}
+	if c.selected < 0 {
+		c.selected = 0
+	}
+	c.update()
+}
+
+// visibleItems returns the number of the results shown at once.
+func (c *CommandPalette) visibleItems() int {
+	n := c.palette.showTotal
+	if n > len(c.palette.resultItems) || n <= 0 {
+		n = len(c.palette.resultItems)
+	}
+
+	return n
+}
+
+// update shows the results around the selected one.
+func (c *CommandPalette) update() {
+	visible := c.visibleItems()
+	if c.selected < c.offset {
+		c.offset = c.selected
+	}
+	if c.selected >= c.offset+visible {
+		c.offset = c.selected - visible + 1
+	}
+	for i, item := range c.palette.resultItems {
+		n := c.offset + i
+		if i >= visible || n >= len(c.matches) {
+			item.hide()
+			continue
+		}
+		item.setCommand(c.matches[n])
+		item.setSelected(n == c.selected)
+		item.show()
+	}
+}
+
+// setCommand shows the entry of the command palette in the item, with the
+// hint and the state of the toggle dimmed after the label.
+func (f *PaletteResultItem) setCommand(m commandMatch) {
+	f.hideIcon()
+	label := html.EscapeString(m.entry.label)
+	if len(m.match) > 0 {
+		label = formatText(m.entry.label, append([]int(nil), m.match...), false)
+	}
+	hint := html.EscapeString(m.entry.hint)
+	if m.entry.state != "" {
+		hint = fmt.Sprintf("[%s] %s", m.entry.state, hint)
+	}
+	text := fmt.Sprintf("%s&nbsp;&nbsp;<font color='#838383'>%s</font>", label, hint)
+	if text != f.baseText {
+		f.baseText = text
+		f.base.SetText(text)
+	}
+}
+
+func (c *CommandPalette) moveSelected(delta int) {
+	if len(c.matches) == 0 {
+		return
+	}
+	c.selected = (c.selected + delta + len(c.matches)) % len(c.matches)
+	c.update()
+}
+
+// run runs the selected entry. The commands which require the arguments
+// are put in the cmdline to be completed.
+func (c *CommandPalette) run() {
+	if c.selected < 0 || c.selected >= len(c.matches) {
+		c.hide()
+		return
+	}
+	e := c.matches[c.selected].entry
+	c.hide()
+	ws := c.ws
+	go func() {
+		editor.commandHistory.add(e.key())
+		switch {
+		case e.kind == commandMapping:
+			ws.nvim.ExecLua(`goneovim.palette_feedkeys(...)`, nil, e.name)
+		case e.nargs == "1" || e.nargs == "+":
+			ws.nvim.Input(`<C-\><C-n>:` + e.name + " ")
+		default:
+			ws.nvim.Command(e.name)
+		}
+	}()
+}
+
+func (c *CommandPalette) keyPress(event *gui.QKeyEvent) {
+	ctrl := event.Modifiers()&core.Qt__ControlModifier != 0
+	switch core.Qt__Key(event.Key()) {
+	case core.Qt__Key_Escape:
+		c.hide()
+	case core.Qt__Key_Up:
+		c.moveSelected(-1)
+	case core.Qt__Key_Down:
+		c.moveSelected(1)
+	case core.Qt__Key_Return, core.Qt__Key_Enter:
+		c.run()
+	case core.Qt__Key_P, core.Qt__Key_K:
+		if !ctrl {
+			c.input.KeyPressEventDefault(event)
+			return
+		}
+		c.moveSelected(-1)
+	case core.Qt__Key_N, core.Qt__Key_J:
+		if !ctrl {
+			c.input.KeyPressEventDefault(event)
+			return
+		}
+		c.moveSelected(1)
+	default:
+		c.input.KeyPressEventDefault(event)
+	}
+}
+
+// isCommandPaletteKeyEvent reports whether the key event is the shortcut of
+// the command palette, Ctrl+Shift+P (Cmd+Shift+P on macOS).
+func isCommandPaletteKeyEvent(key int, mod core.Qt__KeyboardModifier) bool {
+	return core.Qt__Key(key) == core.Qt__Key_P && mod&^core.Qt__KeypadModifier == zoomModifier()|core.Qt__ShiftModifier
+}
+
+// handleCommandPalette handles the events of the command palette.
+func (ws *Workspace) handleCommandPalette(updates []interface{}) {
+	switch updates[0] {
+	case "gonvim_command_palette":
+		ws.openCommandPalette()
+	case "gonvim_command_entries":
+		if ws.commandPalette == nil {
+			return
+		}
+		entries, _ := updates[2].([]commandEntry)
+		ws.commandPalette.setEntries(util.ReflectToInt(updates[1]), entries)
+	}
+}
@@ -0,0 +1,97 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommandEntries(t *testing.T) {
+	commands := [][]string{
+		{"Lazy", "?", "lua require('lazy').show()"},
+		{"GonvimFind", "0", `call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_find")`},
+		{"Format", "0", "Format   the\n buffer"},
+		{"Lazy", "0", "the global one"},
+	}
+	mappings := [][]string{
+		{" ff", "Find files"},
+		{"gd", ""},
+		{" ff", "the global one"},
+	}
+	want := []commandEntry{
+		{kind: commandGoneovim, label: "Find files", hint: ":GonvimFind", name: "GonvimFind", nargs: "0"},
+		{kind: commandUser, label: ":Format", hint: "Format the buffer", name: "Format", nargs: "0"},
+		{kind: commandUser, label: ":Lazy", hint: "lua require('lazy').show()", name: "Lazy", nargs: "?"},
+		{kind: commandMapping, label: "Find files", hint: " ff", name: " ff"},
+	}
+
+	if got := commandEntries(commands, mappings); !reflect.DeepEqual(got, want) {
+		t.Errorf("commandEntries() got %+v, want %+v", got, want)
+	}
+}
+
+func TestFrecency(t *testing.T) {
+	now := int64(100 * 24 * 60 * 60)
+	tests := []struct {
+		name string
+		use  commandUse
+		want int
+	}{
+		{"frecency() is 0 for the unused entry", commandUse{}, 0},
+		{"frecency() weights the use today", commandUse{3, now - 60}, 24},
+		{"frecency() weights the use in this week", commandUse{3, now - 3*24*60*60}, 12},
+		{"frecency() weights the use in this month", commandUse{3, now - 20*24*60*60}, 6},
+		{"frecency() weights the old use", commandUse{3, now - 60*24*60*60}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := frecency(tt.use, now); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRankCommandEntries(t *testing.T) {
+	entries := []commandEntry{
+		{kind: commandGoneovim, label: "Find files", hint: ":GonvimFind", name: "GonvimFind"},
+		{kind: commandGoneovim, label: "Search the files", hint: ":GonvimGrep", name: "GonvimGrep"},
+		{kind: commandUser, label: ":Format", hint: "Format the buffer", name: "Format"},
+	}
+	now := int64(1000000)
+	uses := map[string]commandUse{
+		"command:Format": {10, now},
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+	}{
+		{
+			"rankCommandEntries() puts the frequently used entries first",
+			"",
+			[]string{"Format", "GonvimFind", "GonvimGrep"},
+		},
+		{
+			"rankCommandEntries() matches the hint",
+			"grep",
+			[]string{"GonvimGrep"},
+		},
+		{
+			"rankCommandEntries() puts the better match first",
+			"files",
+			[]string{"GonvimGrep", "GonvimFind"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, m := range rankCommandEntries(entries, tt.pattern, uses, now) {
+				got = append(got, m.entry.name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -17,12 +17,16 @@ type gonvimConfig struct {
 	FileExplore fileExploreConfig
 	Popupmenu   popupMenuConfig
 	Palette     paletteConfig
+	Grep        grepConfig
 	MiniMap     miniMapConfig
 	Cursor      cursorConfig
 	Message     messageConfig
 	mu          sync.RWMutex
 	Tabline     tabLineConfig
 	ScrollBar   scrollBarConfig
+	Gesture     gestureConfig
+
+	HighlightFonts map[string]highlightFontConfig
 }
 
 type editorConfig struct {
@@ -39,7 +43,10 @@ type editorConfig struct {
 	FontWeight                              string
 	ModeEnablingIME                         []string
 	IndentGuideIgnoreFtList                 []string
+	IndentGuideColors                       []string
+	ColorSwatchStyle                        string
 	CharsScaledLineHeight                   []string
+	BuiltinGlyphs                           []string
 	Transparent                             float64
 	EnableBackgroundBlur                    bool
 	DiffDeletePattern                       int
@@ -56,6 +63,8 @@ type editorConfig struct {
 	Height                                  int
 	Width                                   int
 	SmoothScrollDuration                    int
+	SmoothScrollEasing                      string
+	KineticScrollFriction                   float64
 	DrawWindowSeparator                     bool
 	Macmeta                                 bool
 	DrawBorder                              bool
@@ -65,12 +74,20 @@ type editorConfig struct {
 	StartFullscreen                         bool
 	SkipGlobalId                            bool
 	IndentGuide                             bool
+	IndentGuideScopeOnly                    bool
+	ColorSwatch                             bool
+	ColorSwatchNames                        bool
 	ForceImeOffOnModeChange                 bool
 	CachedDrawing                           bool
 	Clipboard                               bool
+	UISelect                                bool
+	UIInput                                 bool
 	ReversingScrollDirection                bool
 	SmoothScroll                            bool
+	KineticScroll                           bool
 	DisableHorizontalScroll                 bool
+	DisableZoomShortcuts                    bool
+	DisableCommandPaletteShortcut           bool
 	DrawBorderForFloatWindow                bool
 	DrawShadowForFloatWindow                bool
 	DesktopNotifications                    bool
@@ -91,11 +108,27 @@ type editorConfig struct {
 	ShowDiffDialogOnDrop                    bool
 	NativeTitlebarBackgroundColor           string
 	NativeTitlebarTextColor                 string
+	TextHinting                             string
+	TextAntialiasing                        string
+	TextGamma                               float64
+	TextContrast                            float64
+
+	// builtinGlyphKinds is BuiltinGlyphs parsed once,
+	// which is looked up for every cell.
+	builtinGlyphKinds int
 }
 
 type cursorConfig struct {
-	SmoothMove bool
-	Duration   int
+	AnimationEasing     string
+	Particles           string
+	TrailLength         float64
+	TrailOpacity        float64
+	Duration            int
+	ParticleCount       int
+	ParticleLifetime    int
+	SmoothMove          bool
+	Trail               bool
+	HollowWhenUnfocused bool
 }
 
 type paletteConfig struct {
@@ -104,6 +137,11 @@ type paletteConfig struct {
 	Transparent            float64
 }
 
+type grepConfig struct {
+	ContextLines int
+	MaxMatches   int
+}
+
 type messageConfig struct {
 	Transparent           float64
 	ShowMessageSeparators bool
@@ -124,32 +162,67 @@ type popupMenuConfig struct {
 }
 
 type miniMapConfig struct {
-	Visible bool
-	Disable bool
-	Width   int
+	Visible         bool
+	Disable         bool
+	Width           int
+	Backend         string
+	Marks           bool
+	Render          string
+	BlocksThreshold int
 }
 
 type scrollBarConfig struct {
-	Visible bool
-	Width   int
-	Color   string
+	Visible       bool
+	Width         int
+	Color         string
+	Horizontal    bool
+	Marks         bool
+	PerWindow     bool
+	AutoHideDelay int
+}
+
+// gestureConfig maps touchpad gestures to actions.
+// An empty string or "none" disables the gesture.
+type gestureConfig struct {
+	Pinch                  string
+	Swipe                  string
+	SwipeAnimation         bool
+	SwipeAnimationDuration int
 }
 
 type sideBarConfig struct {
-	AccentColor string
-	Width       int
-	Visible     bool
-	DropShadow  bool
+	AccentColor       string
+	Width             int
+	ThumbnailInterval int
+	Visible           bool
+	DropShadow        bool
+	Thumbnail         bool
+	Badges            bool
+	GitStatus         bool
 }
 
 type workspaceConfig struct {
 	PathStyle      string
 	RestoreSession bool
+	StartPage      bool
+	RecentItems    int
 }
 
 type fileExploreConfig struct {
 	OpenCmd         string
 	MaxDisplayItems int
+	ShowHidden      bool
+	ShowIgnored     bool
+	AutoRefresh     bool
+}
+
+// highlightFontConfig is the font override applied to a highlight group.
+// Empty fields inherit the value of the grid font.
+type highlightFontConfig struct {
+	FontFamily string
+	FontStyle  string
+	FontWeight string
+	SizeScale  float64
 }
 
 func newConfig(home string, skipConfigLoading bool) (string, gonvimConfig) {
@@ -183,6 +256,8 @@ func newConfig(home string, skipConfigLoading bool) (string, gonvimConfig) {
 		config.Editor.BorderlessWindow = true
 	}
 
+	config.Editor.builtinGlyphKinds = parseBuiltinGlyphs(config.Editor.BuiltinGlyphs)
+
 	if config.Editor.DiffAddPattern < 1 || config.Editor.DiffAddPattern > 24 {
 		config.Editor.DiffAddPattern = 1
 	}
@@ -217,28 +292,96 @@ func newConfig(home string, skipConfigLoading bool) (string, gonvimConfig) {
 		config.Editor.FontSize = 12
 	}
 
+	if config.Editor.TextGamma <= 0 {
+		config.Editor.TextGamma = 1.0
+	}
+	if config.Editor.TextContrast < 0 {
+		config.Editor.TextContrast = 0
+	} else if config.Editor.TextContrast > 1.0 {
+		config.Editor.TextContrast = 1.0
+	}
+
+	if config.Editor.KineticScrollFriction <= 0 {
+		config.Editor.KineticScrollFriction = 4.0
+	}
+
+	if config.Cursor.TrailLength < 0 {
+		config.Cursor.TrailLength = 0
+	} else if config.Cursor.TrailLength > 1.0 {
+		config.Cursor.TrailLength = 1.0
+	}
+	if config.Cursor.TrailOpacity < 0 {
+		config.Cursor.TrailOpacity = 0
+	} else if config.Cursor.TrailOpacity > 1.0 {
+		config.Cursor.TrailOpacity = 1.0
+	}
+	if config.Cursor.ParticleCount > maxCursorParticles {
+		config.Cursor.ParticleCount = maxCursorParticles
+	}
+	if config.Cursor.ParticleLifetime < cursorEffectInterval {
+		config.Cursor.ParticleLifetime = cursorEffectInterval
+	}
+
 	// The 'Linespace' config is a non-functional setting and has no effect.
 	config.Editor.Linespace = 0
 
+	if config.Editor.ColorSwatchStyle != "background" {
+		config.Editor.ColorSwatchStyle = "swatch"
+	}
+
 	if config.SideBar.Width == 0 {
 		config.SideBar.Width = 200
 	}
 	if config.SideBar.AccentColor == "" {
 		config.SideBar.AccentColor = "#5596ea"
 	}
+	if config.SideBar.ThumbnailInterval < 500 {
+		config.SideBar.ThumbnailInterval = 500
+	}
 
 	if config.FileExplore.MaxDisplayItems < 1 {
 		config.FileExplore.MaxDisplayItems = 1
 	}
 
+	if config.Grep.ContextLines < 0 {
+		config.Grep.ContextLines = 0
+	}
+	if config.Grep.MaxMatches < 1 {
+		config.Grep.MaxMatches = 1
+	}
+
 	if config.Workspace.PathStyle == "" {
 		config.Workspace.PathStyle = "minimum"
 	}
+	if config.Workspace.RecentItems < 1 {
+		config.Workspace.RecentItems = 1
+	}
 
 	if config.MiniMap.Width == 0 || config.MiniMap.Width >= 250 {
 		config.MiniMap.Width = 100
 	}
 
+	if config.MiniMap.Backend != "primary" {
+		config.MiniMap.Backend = "process"
+	}
+
+	switch config.MiniMap.Render {
+	case "glyphs", "blocks":
+	default:
+		config.MiniMap.Render = "auto"
+	}
+
+	if config.MiniMap.BlocksThreshold <= 0 {
+		config.MiniMap.BlocksThreshold = 10000
+	}
+
+	for name, hf := range config.HighlightFonts {
+		if hf.SizeScale <= 0 {
+			hf.SizeScale = 1.0
+			config.HighlightFonts[name] = hf
+		}
+	}
+
 	editor.putLog("reading config")
 
 	return configDir, config
@@ -342,6 +485,8 @@ func (c *gonvimConfig) init() {
 
 	c.Editor.DisableLigatures = false
 	c.Editor.Clipboard = true
+	c.Editor.UISelect = true
+	c.Editor.UIInput = true
 	c.Editor.Macmeta = false
 	c.Editor.ForceImeOffOnModeChange = false
 
@@ -356,10 +501,20 @@ func (c *gonvimConfig) init() {
 	c.Editor.CharsScaledLineHeight = []string{"", "", "", "", "", "", "", "", "", "", "│", "▎"}
 	c.Editor.OptionsToUseGuideWidth = "tabstop"
 
+	// Color swatch
+	c.Editor.ColorSwatch = false
+	c.Editor.ColorSwatchStyle = "swatch"
+	c.Editor.ColorSwatchNames = false
+
 	c.Editor.LineToScroll = 1
 	c.Editor.SmoothScroll = false
 	c.Editor.SmoothScrollDuration = 800
+	c.Editor.SmoothScrollEasing = "outexpo"
+	c.Editor.KineticScroll = false
+	c.Editor.KineticScrollFriction = 4.0
 	c.Editor.DisableHorizontalScroll = false
+	c.Editor.DisableZoomShortcuts = false
+	c.Editor.DisableCommandPaletteShortcut = false
 
 	c.Editor.DrawBorderForFloatWindow = false
 	c.Editor.DrawShadowForFloatWindow = false
@@ -370,12 +525,26 @@ func (c *gonvimConfig) init() {
 	c.Editor.NativeTitlebarBackgroundColor = ""
 	c.Editor.NativeTitlebarTextColor = ""
 
+	// Text rendering
+	c.Editor.TextHinting = "default"
+	c.Editor.TextAntialiasing = "default"
+	c.Editor.TextGamma = 1.0
+	c.Editor.TextContrast = 0.0
+
 	// replace diff color drawing pattern
 	c.Editor.DiffAddPattern = 1
 	c.Editor.DiffDeletePattern = 1
 	c.Editor.DiffChangePattern = 1
 
 	c.Cursor.Duration = 180
+	c.Cursor.AnimationEasing = "outexpo"
+	c.Cursor.Trail = false
+	c.Cursor.TrailLength = 0.6
+	c.Cursor.TrailOpacity = 0.5
+	c.Cursor.Particles = "none"
+	c.Cursor.ParticleCount = 12
+	c.Cursor.ParticleLifetime = 400
+	c.Cursor.HollowWhenUnfocused = true
 
 	// ----
 
@@ -384,6 +553,9 @@ func (c *gonvimConfig) init() {
 	c.Palette.MaxNumberOfResultItems = 30
 	c.Palette.Transparent = 1.0
 
+	c.Grep.ContextLines = 1
+	c.Grep.MaxMatches = 1000
+
 	// ----
 
 	c.Message.Transparent = 1.0
@@ -407,24 +579,48 @@ func (c *gonvimConfig) init() {
 
 	c.ScrollBar.Visible = false
 	c.ScrollBar.Width = 10
+	c.ScrollBar.Horizontal = false
+	c.ScrollBar.Marks = true
+	c.ScrollBar.PerWindow = false
+	c.ScrollBar.AutoHideDelay = 1200
 
 	// ----
 
 	c.MiniMap.Disable = true
 	c.MiniMap.Width = 100
+	c.MiniMap.Backend = "process"
+	c.MiniMap.Marks = true
+	c.MiniMap.Render = "auto"
+	c.MiniMap.BlocksThreshold = 10000
 
 	// ----
 
 	c.SideBar.Visible = false
 	c.SideBar.Width = 200
 	c.SideBar.AccentColor = "#5596ea"
+	c.SideBar.Thumbnail = false
+	c.SideBar.ThumbnailInterval = 3000
+	c.SideBar.Badges = true
+	c.SideBar.GitStatus = true
 
 	// ----
 
 	c.FileExplore.MaxDisplayItems = 30
+	c.FileExplore.ShowHidden = false
+	c.FileExplore.ShowIgnored = false
+	c.FileExplore.AutoRefresh = true
 
 	// ----
 
 	c.Workspace.PathStyle = "minimum"
 	c.Workspace.RestoreSession = false
+	c.Workspace.StartPage = false
+	c.Workspace.RecentItems = 10
+
+	// ----
+
+	c.Gesture.Pinch = "zoom"
+	c.Gesture.Swipe = "workspace"
+	c.Gesture.SwipeAnimation = true
+	c.Gesture.SwipeAnimationDuration = 250
 }
@@ -26,6 +26,7 @@ type Cursor struct {
 	ws                   *Workspace
 	win                  *Window
 	timer                *core.QTimer
+	effects              cursorEffects
 	cursorShape          string
 	desttext             string
 	sourcetext           string
@@ -106,6 +107,23 @@ func (c *Cursor) paintEvent(event *gui.QPaintEvent) {
 
 	p := gui.NewQPainter2(c)
 
+	// While the effects are visible, the widget covers them
+	// and the cursor cell is drawn at its offset.
+	if c.effects.expanded {
+		c.drawEffects(p)
+		p.Translate3(
+			float64(c.effects.pos[0]-c.effects.origin[0]),
+			float64(c.effects.pos[1]-c.effects.origin[1]),
+		)
+	}
+
+	if c.isHollow() {
+		c.drawHollow(p)
+		p.DestroyQPainter()
+		c.paintMutex.Unlock()
+		return
+	}
+
 	c.drawBackground(p)
 
 	if c.devicePixelRatio == 0 {
@@ -174,20 +192,26 @@ func (c *Cursor) drawForeground(p *gui.QPainter, sx, sy, dx, dy float64, text st
 	ascent := font.ascent
 
 	// Paint target cell text
-	if editor.config.Editor.CachedDrawing {
+	if editor.config.Editor.CachedDrawing || !isTextGammaLinear() {
 		var image *gui.QImage
-		cursorCache := c.win.getCursorCache()
-		imagev, err := cursorCache.get(HlTextKey{
-			text:   text,
-			fg:     *(c.fg),
-			italic: false,
-			bold:   false,
-		})
-		if err != nil {
-			image = c.newCursorCacheImage(text, c.fg, c.normalWidth)
-			c.setCursorCache(text, c.fg, image)
+		if editor.config.Editor.CachedDrawing {
+			cursorCache := c.win.getCursorCache()
+			imagev, err := cursorCache.get(HlTextKey{
+				text:   text,
+				fg:     *(c.fg),
+				italic: false,
+				bold:   false,
+			})
+			if err != nil {
+				image = c.newCursorCacheImage(text, c.fg, c.normalWidth)
+				c.setCursorCache(text, c.fg, image)
+			} else {
+				image = imagev.(*gui.QImage)
+			}
 		} else {
-			image = imagev.(*gui.QImage)
+			// TextGamma and TextContrast are applied to the rasterized
+			// glyph, so draw it through an image which is not cached.
+			image = c.newCursorCacheImage(text, c.fg, c.normalWidth)
 		}
 		yy := dy - sy - float64(c.horizontalShift)
 		if c.font.lineSpace < 0 {
@@ -252,6 +276,7 @@ func (c *Cursor) newCursorCacheImage(text string, fg *RGBA, isNormalWidth bool)
 	pi := gui.NewQPainter2(image)
 	pi.SetPen2(fg.QColor())
 	pi.SetFont(font.qfont)
+	setTextRenderHints(pi)
 
 	// TODO
 	// Set bold, italic styles
@@ -272,6 +297,8 @@ func (c *Cursor) newCursorCacheImage(text string, fg *RGBA, isNormalWidth bool)
 
 	pi.DestroyQPainter()
 
+	image = adjustTextGamma(image)
+
 	if !isNormalWidth {
 		image = scaleToGridCell(
 			image,
@@ -367,6 +394,10 @@ func (c *Cursor) move() {
 
 	iX += c.ws.screen.tooltip.cursorVisualPos
 
+	if c.moveWithEffects(iX, iY) {
+		return
+	}
+
 	c.Move2(iX, iY)
 }
 
@@ -625,6 +656,7 @@ func (c *Cursor) updateCursorPos(row, col int) {
 		c.xprime = c.x
 		c.yprime = c.y
 	}
+	c.emitTypingParticles(c.x, c.y, x, y)
 	c.x = x
 	c.y = y
 
@@ -723,6 +755,7 @@ func (c *Cursor) setColor() {
 }
 
 func (c *Cursor) redraw() {
+	c.updateOpaque()
 	c.move()
 	c.paint()
 
@@ -750,7 +783,7 @@ func (c *Cursor) initializeOrReuseSmoothMoveAnimation() {
 		c.smoothMoveAnimation.SetDuration(int(editor.config.Cursor.Duration))
 		c.smoothMoveAnimation.SetStartValue(core.NewQVariant10(float64(0.01)))
 		c.smoothMoveAnimation.SetEndValue(core.NewQVariant10(1))
-		c.smoothMoveAnimation.SetEasingCurve(core.NewQEasingCurve(core.QEasingCurve__OutExpo))
+		c.smoothMoveAnimation.SetEasingCurve(core.NewQEasingCurve(easingCurve(editor.config.Cursor.AnimationEasing)))
 
 		c.smoothMoveAnimation.ConnectValueChanged(func(value *core.QVariant) {
 			if !c.doAnimate {
@@ -784,6 +817,9 @@ func (c *Cursor) initializeOrReuseSmoothMoveAnimation() {
 			}
 
 			c.move()
+			if c.effects.expanded {
+				c.Update()
+			}
 		})
 	}
 }
@@ -806,6 +842,11 @@ func (c *Cursor) animateMove() {
 }
 
 func (c *Cursor) resize(width, height int) {
+	// The expanded widget is resized back to the cursor cell
+	// in move() once the effects are gone.
+	if c.effects.expanded {
+		return
+	}
 	c.Resize2(width, height)
 }
 
@@ -0,0 +1,402 @@
+package editor
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+	"github.com/akiyosi/qt/widgets"
+)
+
+const (
+	// cursorEffectFrameBudget is the time the cursor effects may spend
+	// in a single paintEvent. Beyond this, fewer particles are emitted so that
+	// painting the effects does not delay the text input.
+	cursorEffectFrameBudget = 2 * time.Millisecond
+	cursorEffectInterval    = 16
+	maxCursorParticles      = 128
+)
+
+// cursorEffects is the state of the effects drawn around the cursor.
+// While any effect is visible, the cursor widget is expanded to cover
+// the effect and becomes transparent outside the cursor cell.
+type cursorEffects struct {
+	particles     []cursorParticle
+	timer         *core.QTimer
+	lastFrame     time.Time
+	paintCost     time.Duration
+	origin        [2]int
+	pos           [2]int
+	expanded      bool
+	isTransparent bool
+}
+
+type cursorParticle struct {
+	x, y     float64
+	vx, vy   float64
+	radius   float64
+	age      float64
+	lifetime float64
+	ring     bool
+}
+
+// isHollow reports whether the cursor is drawn as a hollow rectangle,
+// which is the case when the window holding the grid is not active,
+// or when the grid has lost the keyboard focus to another widget.
+func (c *Cursor) isHollow() bool {
+	if !editor.config.Cursor.HollowWhenUnfocused {
+		return false
+	}
+	if c.win != nil && c.win.isExternal && c.win.extwin != nil {
+		return !c.win.extwin.IsActiveWindow()
+	}
+	if c.ws != nil && c.ws.detached != nil {
+		return !c.ws.detached.window.IsActiveWindow() || !c.hasGridFocus()
+	}
+
+	return !editor.window.IsActiveWindow() || !c.hasGridFocus()
+}
+
+// hasGridFocus reports whether the keyboard input goes to the grid rather
+// than to another widget, such as the line edits of the command palette
+// and the finder. The keys reach the grid through the workspace widget
+// or the window holding it.
+func (c *Cursor) hasGridFocus() bool {
+	if c.ws == nil || c.ws.widget == nil {
+		return true
+	}
+	focus := widgets.QApplication_FocusWidget()
+	if focus == nil || focus.Pointer() == nil {
+		return true
+	}
+
+	return focus.Pointer() == c.ws.widget.Pointer() || focus.Pointer() == c.ws.widget.Window().Pointer()
+}
+
+// updateFocus is called when the application window, a detached window or
+// an external window is activated or deactivated.
+func (c *Cursor) updateFocus() {
+	if c == nil {
+		return
+	}
+	c.updateOpaque()
+	c.Update()
+}
+
+// updateOpaque keeps WA_OpaquePaintEvent only while the cursor fills its
+// whole widget, so that the text under a hollow cursor and the grid around
+// the effects are left visible.
+func (c *Cursor) updateOpaque() {
+	transparent := c.effects.expanded || c.isHollow()
+	if c.effects.isTransparent == transparent {
+		return
+	}
+	c.effects.isTransparent = transparent
+	c.SetAttribute(core.Qt__WA_OpaquePaintEvent, !transparent)
+}
+
+func (c *Cursor) drawHollow(p *gui.QPainter) {
+	color := c.bg
+	if color == nil {
+		color = c.ws.foreground
+	}
+	pen := gui.NewQPen3(color.QColor())
+	pen.SetWidth(1)
+	p.SetPen(pen)
+	p.SetBrush(gui.NewQBrush())
+	p.DrawRect(core.NewQRectF4(0.5, 0.5, float64(c.width)-1.0, float64(c.height)-1.0))
+}
+
+// trailPos returns the positions of the tail and the head of the trail
+// during the smooth cursor animation, in the coordinates of the parent widget.
+func (c *Cursor) trailPos() (tail, head [2]float64, ok bool) {
+	if !editor.config.Cursor.Trail || !c.hasSmoothMove || !c.doAnimate {
+		return
+	}
+	v := c.delta
+	if v <= 0 || v >= 1 {
+		return
+	}
+	// The tail lags behind the head and catches up at the end of the animation.
+	tv := v * (1.0 - editor.config.Cursor.TrailLength*(1.0-v))
+
+	offsetX := float64(c.ws.screen.tooltip.cursorVisualPos)
+	offsetY := float64(c.horizontalShift)
+	head = [2]float64{
+		c.xprime + (c.x-c.xprime)*v + offsetX,
+		c.yprime + (c.y-c.yprime)*v + offsetY,
+	}
+	tail = [2]float64{
+		c.xprime + (c.x-c.xprime)*tv + offsetX,
+		c.yprime + (c.y-c.yprime)*tv + offsetY,
+	}
+	ok = true
+
+	return
+}
+
+// cursorTrailHull returns the convex hull of the cursor rectangles
+// at the tail and at the head, clockwise on the screen.
+func cursorTrailHull(tail, head [2]float64, width, height float64) [][2]float64 {
+	points := [][2]float64{}
+	for _, o := range [][2]float64{tail, head} {
+		points = append(points,
+			[2]float64{o[0], o[1]},
+			[2]float64{o[0] + width, o[1]},
+			[2]float64{o[0] + width, o[1] + height},
+			[2]float64{o[0], o[1] + height},
+		)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i][0] == points[j][0] {
+			return points[i][1] < points[j][1]
+		}
+		return points[i][0] < points[j][0]
+	})
+
+	cross := func(o, a, b [2]float64) float64 {
+		return (a[0]-o[0])*(b[1]-o[1]) - (a[1]-o[1])*(b[0]-o[0])
+	}
+	hull := make([][2]float64, 0, 2*len(points))
+	for _, pt := range points {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], pt) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, pt)
+	}
+	lower := len(hull) + 1
+	for i := len(points) - 2; i >= 0; i-- {
+		pt := points[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], pt) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, pt)
+	}
+
+	return hull[:len(hull)-1]
+}
+
+// stepParticles advances the particles by dt seconds
+// and drops the ones that reached the end of their lifetime.
+func stepParticles(particles []cursorParticle, dt float64) []cursorParticle {
+	alive := particles[:0]
+	damping := math.Pow(0.05, dt)
+	for _, pt := range particles {
+		pt.age += dt
+		if pt.age >= pt.lifetime {
+			continue
+		}
+		pt.x += pt.vx * dt
+		pt.y += pt.vy * dt
+		pt.vx *= damping
+		pt.vy *= damping
+		alive = append(alive, pt)
+	}
+
+	return alive
+}
+
+// emitTypingParticles emits the particles of the Particles setting
+// when the cursor advances by typing in insert mode.
+func (c *Cursor) emitTypingParticles(prevX, prevY, x, y float64) {
+	kind := strings.ToLower(editor.config.Cursor.Particles)
+	if kind == "" || kind == "none" {
+		return
+	}
+	if c.ws.mode != "insert" && c.ws.mode != "replace" {
+		return
+	}
+	if c.font == nil || prevY != y || prevX == x {
+		return
+	}
+	// Only single steps count as typing, not jumps within the line.
+	if math.Abs(x-prevX) > c.font.cellwidth*2.5 {
+		return
+	}
+
+	count := editor.config.Cursor.ParticleCount
+	if c.effects.paintCost > cursorEffectFrameBudget {
+		count = int(float64(count) * float64(cursorEffectFrameBudget) / float64(c.effects.paintCost))
+	}
+	if room := maxCursorParticles - len(c.effects.particles); count > room {
+		count = room
+	}
+	if count <= 0 {
+		return
+	}
+
+	lifetime := float64(editor.config.Cursor.ParticleLifetime) / 1000.0
+	offsetX := float64(c.ws.screen.tooltip.cursorVisualPos)
+	cx := prevX + offsetX + c.font.cellwidth/2.0
+	cy := prevY + float64(c.font.lineHeight)/2.0
+
+	switch kind {
+	case "railgun":
+		// Shoot the particles backwards from the typed cell.
+		dir := math.Pi
+		if x < prevX {
+			dir = 0
+		}
+		for i := 0; i < count; i++ {
+			angle := dir + (rand.Float64()-0.5)*math.Pi/3.0
+			speed := c.font.cellwidth * (6.0 + rand.Float64()*10.0)
+			c.effects.particles = append(c.effects.particles, cursorParticle{
+				x:        cx,
+				y:        cy,
+				vx:       math.Cos(angle) * speed,
+				vy:       math.Sin(angle) * speed,
+				radius:   1.0 + rand.Float64()*1.5,
+				lifetime: lifetime * (0.5 + rand.Float64()*0.5),
+			})
+		}
+	case "sonicboom":
+		c.effects.particles = append(c.effects.particles, cursorParticle{
+			x:        cx,
+			y:        cy,
+			radius:   float64(c.font.lineHeight) * 1.2,
+			lifetime: lifetime,
+			ring:     true,
+		})
+	default:
+		return
+	}
+
+	c.startEffectTimer()
+}
+
+func (c *Cursor) startEffectTimer() {
+	if c.effects.timer == nil {
+		c.effects.timer = core.NewQTimer(nil)
+		c.effects.timer.ConnectTimeout(c.stepEffects)
+	}
+	if c.effects.timer.IsActive() {
+		return
+	}
+	c.effects.lastFrame = time.Now()
+	c.effects.timer.Start(cursorEffectInterval)
+}
+
+func (c *Cursor) stepEffects() {
+	now := time.Now()
+	dt := now.Sub(c.effects.lastFrame).Seconds()
+	c.effects.lastFrame = now
+
+	c.effects.particles = stepParticles(c.effects.particles, dt)
+	if len(c.effects.particles) == 0 {
+		c.effects.timer.Stop()
+	}
+
+	c.move()
+	c.Update()
+}
+
+// effectBounds returns the area covered by the cursor at (x, y)
+// and its effects, or false if no effect is visible.
+func (c *Cursor) effectBounds(x, y int) (x0, y0, x1, y1 int, ok bool) {
+	minX, minY := float64(x), float64(y)
+	maxX, maxY := float64(x+c.width), float64(y+c.height)
+	extend := func(px, py, r float64) {
+		minX = math.Min(minX, px-r)
+		minY = math.Min(minY, py-r)
+		maxX = math.Max(maxX, px+r)
+		maxY = math.Max(maxY, py+r)
+	}
+
+	if tail, head, isTrail := c.trailPos(); isTrail {
+		extend(tail[0], tail[1], 0)
+		extend(tail[0]+float64(c.width), tail[1]+float64(c.height), 0)
+		extend(head[0], head[1], 0)
+		extend(head[0]+float64(c.width), head[1]+float64(c.height), 0)
+		ok = true
+	}
+	for _, pt := range c.effects.particles {
+		extend(pt.x, pt.y, pt.radius+1.0)
+		ok = true
+	}
+	if !ok {
+		return
+	}
+
+	x0 = int(math.Floor(minX))
+	y0 = int(math.Floor(minY))
+	x1 = int(math.Ceil(maxX))
+	y1 = int(math.Ceil(maxY))
+
+	return
+}
+
+// moveWithEffects moves the cursor widget to (x, y), expanding it to cover
+// the visible effects. It reports false if there is nothing to do beyond
+// an ordinary move.
+func (c *Cursor) moveWithEffects(x, y int) bool {
+	c.effects.pos = [2]int{x, y}
+	x0, y0, x1, y1, ok := c.effectBounds(x, y)
+	if !ok {
+		if !c.effects.expanded {
+			return false
+		}
+		c.effects.expanded = false
+		c.effects.origin = [2]int{x, y}
+		c.SetGeometry2(x, y, c.width, c.height)
+		c.updateOpaque()
+		return true
+	}
+
+	c.effects.expanded = true
+	c.effects.origin = [2]int{x0, y0}
+	c.SetGeometry2(x0, y0, x1-x0, y1-y0)
+	c.updateOpaque()
+
+	return true
+}
+
+// drawEffects draws the trail and the particles behind the cursor cell.
+func (c *Cursor) drawEffects(p *gui.QPainter) {
+	start := time.Now()
+
+	color := c.bg
+	if color == nil {
+		color = c.ws.foreground
+	}
+	ox := float64(c.effects.origin[0])
+	oy := float64(c.effects.origin[1])
+
+	if tail, head, ok := c.trailPos(); ok {
+		hull := cursorTrailHull(tail, head, float64(c.width), float64(c.height))
+		path := gui.NewQPainterPath()
+		for i, pt := range hull {
+			if i == 0 {
+				path.MoveTo(core.NewQPointF3(pt[0]-ox, pt[1]-oy))
+			} else {
+				path.LineTo(core.NewQPointF3(pt[0]-ox, pt[1]-oy))
+			}
+		}
+		path.CloseSubpath()
+		trailColor := newRGBA(color.R, color.G, color.B, editor.config.Cursor.TrailOpacity)
+		p.FillPath(path, gui.NewQBrush3(trailColor.QColor(), core.Qt__SolidPattern))
+	}
+
+	for _, pt := range c.effects.particles {
+		life := 1.0 - pt.age/pt.lifetime
+		pc := newRGBA(color.R, color.G, color.B, life).QColor()
+		center := core.NewQPointF3(pt.x-ox, pt.y-oy)
+		if pt.ring {
+			r := pt.radius * (pt.age / pt.lifetime)
+			pen := gui.NewQPen3(pc)
+			pen.SetWidthF(math.Max(2.0*life, 0.5))
+			p.SetPen(pen)
+			p.SetBrush(gui.NewQBrush())
+			p.DrawEllipse4(center, r, r)
+			continue
+		}
+		p.SetPen2(pc)
+		p.SetBrush(gui.NewQBrush3(pc, core.Qt__SolidPattern))
+		p.DrawEllipse4(center, pt.radius, pt.radius)
+	}
+
+	c.effects.paintCost = time.Since(start)
+}
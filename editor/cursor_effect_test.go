@@ -0,0 +1,75 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCursorTrailHull(t *testing.T) {
+	tests := []struct {
+		name string
+		tail [2]float64
+		head [2]float64
+		want [][2]float64
+	}{
+		{
+			"cursorTrailHull() same position",
+			[2]float64{0, 0},
+			[2]float64{0, 0},
+			[][2]float64{{0, 0}, {10, 0}, {10, 20}, {0, 20}},
+		},
+		{
+			"cursorTrailHull() horizontal move",
+			[2]float64{0, 0},
+			[2]float64{30, 0},
+			[][2]float64{{0, 0}, {40, 0}, {40, 20}, {0, 20}},
+		},
+		{
+			"cursorTrailHull() diagonal move",
+			[2]float64{0, 0},
+			[2]float64{30, 40},
+			[][2]float64{{0, 0}, {10, 0}, {40, 40}, {40, 60}, {30, 60}, {0, 20}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cursorTrailHull(tt.tail, tt.head, 10, 20)
+			if !isSameCycle(got, tt.want) {
+				t.Errorf("cursorTrailHull() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// isSameCycle reports whether a and b list the same polygon vertices
+// in the same order, starting from any vertex.
+func isSameCycle(a, b [][2]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for offset := range b {
+		rotated := append(append([][2]float64{}, b[offset:]...), b[:offset]...)
+		if reflect.DeepEqual(a, rotated) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestStepParticles(t *testing.T) {
+	particles := []cursorParticle{
+		{x: 0, y: 0, vx: 100, vy: -50, lifetime: 0.5},
+		{x: 10, y: 10, age: 0.45, lifetime: 0.5},
+	}
+	got := stepParticles(particles, 0.1)
+	if len(got) != 1 {
+		t.Fatalf("stepParticles() kept %d particles, want 1", len(got))
+	}
+	if got[0].x != 10 || got[0].y != -5 {
+		t.Errorf("stepParticles() moved the particle to (%v, %v), want (10, -5)", got[0].x, got[0].y)
+	}
+	if got[0].vx >= 100 || got[0].vx <= 0 {
+		t.Errorf("stepParticles() velocity = %v, want damped below 100", got[0].vx)
+	}
+}
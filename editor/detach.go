@@ -0,0 +1,224 @@
+package editor
+
+import (
+	"fmt"
+
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+	"github.com/akiyosi/qt/widgets"
+)
+
+// DetachedWindow is the top-level window which a workspace is moved into
+// from the main window. It has its own geometry, title and fullscreen state.
+type DetachedWindow struct {
+	ws     *Workspace
+	window *widgets.QWidget
+}
+
+// cycleAttachedIndex returns the index of the workspace next to from in the
+// direction step, skipping the detached workspaces. It returns -1 if all the
+// other workspaces are detached.
+func cycleAttachedIndex(detached []bool, from, step int) int {
+	n := len(detached)
+	if n == 0 {
+		return -1
+	}
+	for i := 1; i <= n; i++ {
+		index := ((from+step*i)%n + n) % n
+		if !detached[index] {
+			return index
+		}
+	}
+
+	return -1
+}
+
+func (e *Editor) detachedStates() []bool {
+	detached := make([]bool, len(e.workspaces))
+	for i, ws := range e.workspaces {
+		detached[i] = ws.detached != nil
+	}
+
+	return detached
+}
+
+// focusedWorkspace returns the workspace of the window which has the focus,
+// which is the active workspace of the main window unless it is detached one.
+func (e *Editor) focusedWorkspace() *Workspace {
+	if active := widgets.QApplication_ActiveWindow(); active != nil && active.Pointer() != nil {
+		for _, ws := range e.workspaces {
+			if ws.detached != nil && ws.detached.window.Pointer() == active.Pointer() {
+				return ws
+			}
+		}
+	}
+	if len(e.workspaces) <= e.active {
+		return nil
+	}
+
+	return e.workspaces[e.active]
+}
+
+func newDetachedWindow(ws *Workspace) *DetachedWindow {
+	window := widgets.NewQWidget(nil, core.Qt__Window)
+	window.SetObjectName("detachedWindow")
+	if editor.colors.bg != nil {
+		window.SetStyleSheet(fmt.Sprintf("#detachedWindow { background-color: %s; }", editor.colors.bg.String()))
+	}
+	window.SetWindowTitle(ws.sideLabel())
+	window.SetAttribute(core.Qt__WA_InputMethodEnabled, true)
+
+	d := &DetachedWindow{
+		ws:     ws,
+		window: window,
+	}
+
+	// The key events are not handled in the widgets of the workspace,
+	// so they are delivered to the window as in the main window.
+	window.ConnectKeyPressEvent(editor.keyPress)
+	window.ConnectKeyReleaseEvent(editor.keyRelease)
+	window.ConnectInputMethodEvent(ws.InputMethodEvent)
+	window.ConnectInputMethodQuery(ws.InputMethodQuery)
+	window.ConnectResizeEvent(func(event *gui.QResizeEvent) {
+		ws.updateSize()
+	})
+	window.ConnectChangeEvent(func(event *core.QEvent) {
+		window.ChangeEventDefault(event)
+		if event.Type() == core.QEvent__ActivationChange {
+			ws.cursor.updateFocus()
+		}
+	})
+	// Closing the window returns the workspace to the main window
+	// instead of quitting its nvim.
+	window.ConnectCloseEvent(func(event *gui.QCloseEvent) {
+		event.Ignore()
+		editor.workspaceAttachBack(ws.getNum())
+	})
+
+	return d
+}
+
+func (d *DetachedWindow) activate() {
+	d.window.Show()
+	d.window.Raise()
+	d.window.ActivateWindow()
+}
+
+func (d *DetachedWindow) setFullscreen(fullscreen bool) {
+	if fullscreen {
+		d.window.ShowFullScreen()
+	} else {
+		d.window.ShowNormal()
+	}
+}
+
+func (d *DetachedWindow) setMaximized(maximized bool) {
+	if maximized {
+		d.window.ShowMaximized()
+	} else {
+		d.window.ShowNormal()
+	}
+}
+
+// close closes the window without returning the workspace,
+// whose nvim has exited.
+func (d *DetachedWindow) close() {
+	d.window.DisconnectCloseEvent()
+	d.window.Hide()
+	d.window.DeleteLater()
+}
+
+// workspaceDetach moves the workspace at index into its own window at pos.
+// The window is placed where it was the last time if pos is nil.
+func (e *Editor) workspaceDetach(index int, pos *core.QPoint) {
+	if index < 0 || index >= len(e.workspaces) {
+		return
+	}
+	ws := e.workspaces[index]
+	if ws.detached != nil {
+		ws.detached.activate()
+		return
+	}
+	// The main window keeps at least one workspace.
+	if cycleAttachedIndex(e.detachedStates(), index, 1) == index {
+		e.putLog("the last workspace in the main window cannot be detached")
+		return
+	}
+
+	width := ws.widget.Width()
+	height := ws.widget.Height()
+
+	ws.detached = newDetachedWindow(ws)
+	ws.widget.SetParent(ws.detached.window)
+	ws.widget.Move2(0, 0)
+	ws.detached.window.Resize2(width, height)
+	switch {
+	case pos != nil:
+		ws.detached.window.Move(pos)
+	case ws.detachedGeometry != nil:
+		ws.detached.window.RestoreGeometry(ws.detachedGeometry)
+	default:
+		mainPos := e.window.Pos()
+		ws.detached.window.Move2(mainPos.X()+40, mainPos.Y()+40)
+	}
+	ws.hidden = true
+	ws.show()
+	ws.detached.activate()
+
+	e.workspaceUpdate()
+}
+
+// workspaceAttachBack returns the detached workspace at index
+// to the main window, and makes it active.
+func (e *Editor) workspaceAttachBack(index int) {
+	if index < 0 || index >= len(e.workspaces) {
+		return
+	}
+	ws := e.workspaces[index]
+	if ws.detached == nil {
+		return
+	}
+	d := ws.detached
+	ws.detachedGeometry = d.window.SaveGeometry()
+	ws.detached = nil
+
+	ws.widget.SetParent(e.widget)
+	ws.widget.Move2(0, 0)
+	d.close()
+
+	e.active = index
+	e.workspaceUpdate()
+	ws.updateSize()
+
+	e.window.Raise()
+	e.window.ActivateWindow()
+}
+
+func (i *WorkspaceSideItem) dragMove(event *gui.QMouseEvent) {
+	if event.Buttons()&core.Qt__LeftButton == 0 {
+		return
+	}
+	area := i.side.scrollarea
+	isOutside := !area.Rect().Contains(area.MapFromGlobal(event.GlobalPos()), false)
+	if isOutside == i.isDragging {
+		return
+	}
+	i.isDragging = isOutside
+	if isOutside {
+		i.widget.SetCursor(gui.NewQCursor2(core.Qt__DragMoveCursor))
+	} else {
+		i.widget.UnsetCursor()
+	}
+}
+
+// dragRelease detaches the workspace of the item dragged out of the sidebar
+// into the window at the released position.
+func (i *WorkspaceSideItem) dragRelease(event *gui.QMouseEvent) {
+	if !i.isDragging {
+		return
+	}
+	i.isDragging = false
+	i.widget.UnsetCursor()
+
+	editor.workspaceDetach(i.index(), event.GlobalPos())
+}
@@ -0,0 +1,32 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestCycleAttachedIndex(t *testing.T) {
+	tests := []struct {
+		name     string
+		detached []bool
+		from     int
+		step     int
+		want     int
+	}{
+		{"cycleAttachedIndex() returns the next workspace", []bool{false, false, false}, 0, 1, 1},
+		{"cycleAttachedIndex() wraps around to the first workspace", []bool{false, false, false}, 2, 1, 0},
+		{"cycleAttachedIndex() wraps around to the last workspace", []bool{false, false, false}, 0, -1, 2},
+		{"cycleAttachedIndex() skips the detached workspace", []bool{false, true, false}, 0, 1, 2},
+		{"cycleAttachedIndex() skips the detached workspace backward", []bool{false, true, false}, 2, -1, 0},
+		{"cycleAttachedIndex() returns itself if the others are detached", []bool{true, false, true}, 1, 1, 1},
+		{"cycleAttachedIndex() returns the attached one from the detached one", []bool{true, false, true}, 0, 1, 1},
+		{"cycleAttachedIndex() returns -1 if all are detached", []bool{true, true}, 0, 1, -1},
+		{"cycleAttachedIndex() returns -1 for no workspace", nil, 0, 1, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cycleAttachedIndex(tt.detached, tt.from, tt.step); got != tt.want {
+				t.Errorf("cycleAttachedIndex() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
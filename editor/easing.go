@@ -0,0 +1,40 @@
+package editor
+
+import (
+	"strings"
+
+	"github.com/akiyosi/qt/core"
+)
+
+// easingCurve returns the easing curve for the name given in settings.toml,
+// such as AnimationEasing of the [Cursor] section. Defaults to OutExpo.
+func easingCurve(name string) core.QEasingCurve__Type {
+	switch strings.ToLower(name) {
+	case "linear":
+		return core.QEasingCurve__Linear
+	case "outquad":
+		return core.QEasingCurve__OutQuad
+	case "inoutquad":
+		return core.QEasingCurve__InOutQuad
+	case "outcubic":
+		return core.QEasingCurve__OutCubic
+	case "inoutcubic":
+		return core.QEasingCurve__InOutCubic
+	case "outquart":
+		return core.QEasingCurve__OutQuart
+	case "outquint":
+		return core.QEasingCurve__OutQuint
+	case "outsine":
+		return core.QEasingCurve__OutSine
+	case "outcirc":
+		return core.QEasingCurve__OutCirc
+	case "outback":
+		return core.QEasingCurve__OutBack
+	case "outelastic":
+		return core.QEasingCurve__OutElastic
+	case "outbounce":
+		return core.QEasingCurve__OutBounce
+	}
+
+	return core.QEasingCurve__OutExpo
+}
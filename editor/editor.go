@@ -29,7 +29,6 @@ import (
 var editor *Editor
 
 const (
-	WORKSPACELEN     = 10
 	NVIMCALLTIMEOUT  = 320
 	NVIMCALLTIMEOUT2 = 45
 )
@@ -63,6 +62,7 @@ type ColorPalette struct {
 	minimapCurrentRegion  *RGBA
 	windowSeparator       *RGBA
 	indentGuide           *RGBA
+	indentGuideLevels     []*RGBA
 }
 
 // NotifyButton is
@@ -117,6 +117,8 @@ type Editor struct {
 	geometryUpdateTimer    *time.Timer
 	sysTray                *widgets.QSystemTrayIcon
 	side                   *WorkspaceSide
+	recent                 *recentList
+	commandHistory         *commandHistory
 	savedGeometry          *core.QByteArray
 	prefixToMapMetaKey     string
 	configDir              string
@@ -128,6 +130,8 @@ type Editor struct {
 	fallbackfonts          []*Font
 	fontCh                 chan []*Font
 	fontErrors             []string
+	hlFontSpecs            map[string]highlightFontConfig
+	hlFontSpecsOnce        sync.Once
 	notifications          []*Notification
 	workspaces             []*Workspace
 	args                   []string
@@ -240,6 +244,12 @@ func InitEditor(options Options, args []string) {
 	e.putLog("Detecting the goneovim configuration directory:", e.configDir)
 	e.overwriteConfigByCLIOption()
 
+	// load the recent projects, sessions and files for the start page
+	if e.config.Workspace.StartPage {
+		remote := e.opts.Server != "" || e.opts.Ssh != "" || e.opts.Wsl != nil || e.config.Editor.UseWSL
+		e.recent = newRecentList(e.configDir, e.config.Workspace.RecentItems, remote)
+	}
+
 	// put shell environment
 	e.setEnvironmentVariables()
 
@@ -353,6 +363,8 @@ func (e *Editor) exitEditor(cancel context.CancelFunc) {
 		e.app.DisconnectEvent()
 	}
 	e.saveAppWindowState()
+	e.saveWorkspaceZoom()
+	e.saveWorkspaceNames()
 	cancel()
 
 	// --------------------
@@ -438,9 +450,24 @@ func (e *Editor) addDockMenu() {
 }
 
 func parseFont(families string, size int, weight string, stretch, linespace, letterspace int) (fonts []*Font) {
-	weight = strings.ToLower(weight)
-	var fontWeight gui.QFont__Weight
-	switch weight {
+	fontWeight := parseFontWeight(weight)
+
+	for _, f := range strings.Split(families, ",") {
+		font := initFontNew(strings.TrimSpace(f), float64(size), fontWeight, stretch, linespace, letterspace)
+		fonts = append(fonts, font)
+
+		ok := checkValidFont(f)
+		if !ok {
+			editor.fontErrors = append(editor.fontErrors, f)
+			continue
+		}
+	}
+
+	return
+}
+
+func parseFontWeight(weight string) (fontWeight gui.QFont__Weight) {
+	switch strings.ToLower(weight) {
 	case "thin":
 		fontWeight = gui.QFont__Thin
 	case "extralight", "ultralight":
@@ -459,17 +486,6 @@ func parseFont(families string, size int, weight string, stretch, linespace, let
 		fontWeight = gui.QFont__Black
 	}
 
-	for _, f := range strings.Split(families, ",") {
-		font := initFontNew(strings.TrimSpace(f), float64(size), fontWeight, stretch, linespace, letterspace)
-		fonts = append(fonts, font)
-
-		ok := checkValidFont(f)
-		if !ok {
-			editor.fontErrors = append(editor.fontErrors, f)
-			continue
-		}
-	}
-
 	return
 }
 
@@ -570,19 +586,8 @@ func (e *Editor) initWorkspaces(ctx context.Context, signal *neovimSignal, redra
 	editor.putLog("start initializing workspaces")
 
 	// Detect session file
-	sessionExists := false
-	restoreFiles := []string{}
-	for i := 0; i <= WORKSPACELEN; i++ {
-		path := filepath.Join(e.configDir, "sessions", strconv.Itoa(i)+".vim")
-		_, err := os.Stat(path)
-		if err != nil {
-			continue
-		}
-		sessionExists = true
-		restoreFiles = append(restoreFiles, path)
-
-	}
-	e.doRestoreSessions = sessionExists && e.config.Workspace.RestoreSession
+	restoreFiles := sessionFiles(filepath.Join(e.configDir, "sessions"))
+	e.doRestoreSessions = len(restoreFiles) > 0 && e.config.Workspace.RestoreSession
 	if len(restoreFiles) == 0 || !e.doRestoreSessions {
 		restoreFiles = []string{""}
 	}
@@ -601,6 +606,10 @@ func (e *Editor) initWorkspaces(ctx context.Context, signal *neovimSignal, redra
 			}
 		}
 
+		if e.doRestoreSessions {
+			ws.zoomLevel = e.loadWorkspaceZoom(sessionIndex(file, i))
+			ws.name = e.loadWorkspaceName(sessionIndex(file, i))
+		}
 		ws.initFont()
 		e.initAppFont()
 		ws.registerSignal(signal, redrawUpdates, guiUpdates)
@@ -1038,6 +1047,15 @@ func (c *ColorPalette) update() {
 		}
 	}
 	c.indentGuide = warpColor(bg, -30)
+	c.indentGuideLevels = nil
+	for _, hex := range c.e.config.Editor.IndentGuideColors {
+		color := hexToRGBA(hex)
+		if color == nil {
+			c.e.putLog("invalid color in IndentGuideColors:", hex)
+			continue
+		}
+		c.indentGuideLevels = append(c.indentGuideLevels, color)
+	}
 }
 
 func (e *Editor) updateGUIColor() {
@@ -1161,10 +1179,21 @@ func (e *Editor) connectWindowEvents() {
 				e.isWindowNowActivated = false
 				e.isWindowNowInactivated = true
 			}
+			for _, ws := range e.workspaces {
+				ws.cursor.updateFocus()
+			}
 		default:
 		}
 		return e.window.QFramelessDefaultEventFilter(watched, event)
 	})
+
+	// The grid also loses the focus to the line edits of the command palette
+	// and the finder, while the application window stays active.
+	e.app.ConnectFocusChanged(func(old, now *widgets.QWidget) {
+		for _, ws := range e.workspaces {
+			ws.cursor.updateFocus()
+		}
+	})
 }
 
 func (e *Editor) setWindowOptions() {
@@ -1270,9 +1299,12 @@ func isFileExist(filename string) bool {
 }
 
 func (e *Editor) workspaceAdd() {
-	if len(e.workspaces) == WORKSPACELEN {
-		return
-	}
+	e.workspaceAddWithSession("")
+}
+
+// workspaceAddWithSession starts a new workspace,
+// which sources the session file if it is not empty.
+func (e *Editor) workspaceAddWithSession(file string) {
 	editor.isSetGuiColor = false
 
 	ws := newWorkspace()
@@ -1287,7 +1319,7 @@ func (e *Editor) workspaceAdd() {
 	e.active = len(e.workspaces) - 1
 
 	e.workspaces[e.active] = ws
-	ws.bindNvim(nvimCh, uiRemoteAttachedCh, false, false, "")
+	ws.bindNvim(nvimCh, uiRemoteAttachedCh, false, false, file)
 	e.workspaceUpdate()
 }
 
@@ -1296,23 +1328,29 @@ func (e *Editor) workspaceSwitch(index int) {
 	if index < 0 || index >= len(e.workspaces) {
 		return
 	}
+	if ws := e.workspaces[index]; ws.detached != nil {
+		ws.detached.activate()
+		return
+	}
 	e.active = index
 	e.workspaceUpdate()
 }
 
 func (e *Editor) workspaceNext() {
-	e.active++
-	if e.active >= len(e.workspaces) {
-		e.active = 0
+	index := cycleAttachedIndex(e.detachedStates(), e.active, 1)
+	if index < 0 {
+		return
 	}
+	e.active = index
 	e.workspaceUpdate()
 }
 
 func (e *Editor) workspacePrevious() {
-	e.active--
-	if e.active < 0 {
-		e.active = len(e.workspaces) - 1
+	index := cycleAttachedIndex(e.detachedStates(), e.active, -1)
+	if index < 0 {
+		return
 	}
+	e.active = index
 	e.workspaceUpdate()
 }
 
@@ -1320,14 +1358,29 @@ func (e *Editor) workspaceUpdate() {
 	if e.side == nil {
 		return
 	}
+	// The active workspace is the one shown in the main window,
+	// so it must not be detached.
+	if e.active < len(e.workspaces) && e.workspaces[e.active].detached != nil {
+		index := cycleAttachedIndex(e.detachedStates(), e.active, 1)
+		if index < 0 {
+			e.workspaceAttachBack(e.active)
+			return
+		}
+		e.active = index
+	}
 	for i, ws := range e.workspaces {
+		if ws.detached != nil {
+			continue
+		}
 		if i == e.active {
+			ws.unreadErrors = 0
 			ws.hide()
 			ws.show()
 		} else {
 			ws.hide()
 		}
 	}
+	e.side.ensureItems(len(e.workspaces))
 	for i := 0; i < len(e.side.items) && i < len(e.workspaces); i++ {
 		if e.side.items[i] == nil {
 			continue
@@ -1345,6 +1398,7 @@ func (e *Editor) workspaceUpdate() {
 		}
 		e.side.items[i].hide()
 	}
+	e.side.updateStatus()
 }
 
 func (e *Editor) close(exitcode int) {
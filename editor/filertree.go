@@ -0,0 +1,185 @@
+package editor
+
+import (
+	"strings"
+
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+	"github.com/akiyosi/qt/widgets"
+)
+
+// The roles of the data stored in the entries of the file tree.
+const (
+	filerPathRole     = int(core.Qt__UserRole)
+	filerFiletypeRole = int(core.Qt__UserRole) + 1
+)
+
+// filerKeys maps the keys typed in the file tree to the filer events.
+var filerKeys = map[string]string{
+	"l": "expand",
+	"h": "collapse",
+	"-": "parent",
+	"a": "create",
+	"A": "mkdir",
+	"r": "rename",
+	"d": "delete",
+	"c": "copy",
+	"x": "cut",
+	"p": "paste",
+	"u": "undo",
+	".": "toggle_hidden",
+}
+
+// filerItemText returns the text of the entry in the file tree,
+// which is indented by the depth.
+func filerItemText(name, filetype string, depth int) string {
+	if filetype == "/" {
+		name += "/"
+	}
+
+	return strings.Repeat("  ", depth) + name
+}
+
+// newSideItemContent creates the file tree of the workspace in the sidebar.
+// The handlers look up the workspace when they are called,
+// since the tree moves between the items when the workspaces are reordered.
+func newSideItemContent() *widgets.QListWidget {
+	content := widgets.NewQListWidget(nil)
+	content.SetFocusPolicy(core.Qt__ClickFocus)
+	content.SetFrameShape(widgets.QFrame__NoFrame)
+	content.SetHorizontalScrollBarPolicy(core.Qt__ScrollBarAlwaysOff)
+	content.SetFont(editor.font.qfont)
+	content.SetIconSize(core.NewQSize2(editor.iconSize*3/4, editor.iconSize*3/4))
+
+	// The files dragged into a window are opened there.
+	content.SetDragEnabled(true)
+	content.SetDragDropMode(widgets.QAbstractItemView__DragOnly)
+	content.ConnectMimeData(func(items []*widgets.QListWidgetItem) *core.QMimeData {
+		mime := core.NewQMimeData()
+		if len(items) > 0 {
+			// The url of the local file is file:///C:/... on Windows.
+			url := core.QUrl_FromLocalFile(items[0].Data(filerPathRole).ToString())
+			mime.SetUrls([]*core.QUrl{url})
+			mime.SetText(url.ToString(core.QUrl__None))
+		}
+		return mime
+	})
+
+	content.ConnectItemDoubleClicked(func(item *widgets.QListWidgetItem) {
+		notifyFiler(content, "activate", content.Row(item))
+	})
+	content.ConnectKeyPressEvent(func(event *gui.QKeyEvent) {
+		filerKeyPress(content, event)
+	})
+	content.ConnectContextMenuEvent(func(event *gui.QContextMenuEvent) {
+		filerContextMenu(content, event)
+	})
+
+	return content
+}
+
+// filerWorkspace returns the workspace which shows the file tree.
+func filerWorkspace(content *widgets.QListWidget) *Workspace {
+	if editor.side == nil {
+		return nil
+	}
+	for j, item := range editor.side.items {
+		if item == nil || j >= len(editor.workspaces) {
+			continue
+		}
+		if item.content.Pointer() == content.Pointer() {
+			return editor.workspaces[j]
+		}
+	}
+
+	return nil
+}
+
+// notifyFiler sends the event for the entry at row to the filer.
+func notifyFiler(content *widgets.QListWidget, event string, row int) {
+	ws := filerWorkspace(content)
+	if ws == nil || ws.nvim == nil {
+		return
+	}
+	go ws.nvim.Call("rpcnotify", nil, 0, "GonvimFiler", event, row)
+}
+
+func filerKeyPress(content *widgets.QListWidget, event *gui.QKeyEvent) {
+	mod := event.Modifiers()
+	if mod&(core.Qt__ControlModifier|core.Qt__AltModifier|core.Qt__MetaModifier) != 0 {
+		content.KeyPressEventDefault(event)
+		return
+	}
+	row := content.CurrentRow()
+	switch core.Qt__Key(event.Key()) {
+	case core.Qt__Key_Escape:
+		notifyFiler(content, "cancel", row)
+		return
+	case core.Qt__Key_Return, core.Qt__Key_Enter:
+		notifyFiler(content, "activate", row)
+		return
+	case core.Qt__Key_Backspace:
+		notifyFiler(content, "parent", row)
+		return
+	}
+	switch event.Text() {
+	case "j":
+		if row < content.Count()-1 {
+			content.SetCurrentRow(row + 1)
+		}
+	case "k":
+		if row > 0 {
+			content.SetCurrentRow(row - 1)
+		}
+	default:
+		if e, ok := filerKeys[event.Text()]; ok {
+			notifyFiler(content, e, row)
+			return
+		}
+		content.KeyPressEventDefault(event)
+	}
+}
+
+func filerContextMenu(content *widgets.QListWidget, event *gui.QContextMenuEvent) {
+	row := content.Row(content.ItemAt(event.Pos()))
+	if row >= 0 {
+		content.SetCurrentRow(row)
+	}
+
+	menu := widgets.NewQMenu(nil)
+	menu.SetAttribute(core.Qt__WA_DeleteOnClose, true)
+
+	actions := []struct {
+		text  string
+		event string
+		entry bool
+	}{
+		{"New File...", "create", false},
+		{"New Folder...", "mkdir", false},
+		{"Rename...", "rename", true},
+		{"Delete", "delete", true},
+		{"", "", false},
+		{"Copy", "copy", true},
+		{"Cut", "cut", true},
+		{"Paste", "paste", false},
+		{"Undo", "undo", false},
+		{"", "", false},
+		{"Toggle Hidden Files", "toggle_hidden", false},
+		{"Toggle Ignored Files", "toggle_ignored", false},
+		{"Refresh", "redraw", false},
+	}
+	for _, a := range actions {
+		if a.text == "" {
+			menu.AddSeparator()
+			continue
+		}
+		e := a.event
+		action := menu.AddAction(a.text)
+		action.SetEnabled(!a.entry || row >= 0)
+		action.ConnectTriggered(func(checked bool) {
+			notifyFiler(content, e, row)
+		})
+	}
+
+	menu.Popup(event.GlobalPos(), nil)
+}
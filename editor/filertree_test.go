@@ -0,0 +1,26 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestFilerItemText(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		filetype string
+		depth    int
+		want     string
+	}{
+		{"filerItemText() returns the name in the root", "main.go", "go", 0, "main.go"},
+		{"filerItemText() indents the name by the depth", "main.go", "go", 2, "    main.go"},
+		{"filerItemText() appends the slash to the directory", "src", "/", 1, "  src/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filerItemText(tt.filename, tt.filetype, tt.depth); got != tt.want {
+				t.Errorf("filerItemText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,521 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+	"github.com/akiyosi/qt/widgets"
+)
+
+// finderMaxResults is the number of the ranked files kept for the finder.
+const finderMaxResults = 200
+
+// finderPreviewLines is the number of the lines shown in the preview.
+const finderPreviewLines = 200
+
+// findMatch is a file which matches the pattern of the finder.
+type findMatch struct {
+	path  string
+	match []int
+	score int
+}
+
+// rankFiles returns the files which match the pattern, up to limit, in the
+// order of the score. The shorter path comes first for the same score, then
+// the file listed first. It also returns the number of the matched files.
+// It gives up and returns false when stale reports that the pattern changed.
+func rankFiles(files []string, pattern string, limit int, stale func() bool) ([]findMatch, int, bool) {
+	var ranked []findMatch
+	total := 0
+	for i, path := range files {
+		if i%4096 == 0 && stale != nil && stale() {
+			return nil, 0, false
+		}
+		match, score, ok := matchPath(pattern, path)
+		if !ok {
+			continue
+		}
+		total++
+		if len(ranked) == limit && !betterMatch(score, path, ranked[limit-1]) {
+			continue
+		}
+		m := findMatch{path, match, score}
+		// Insert keeping the order, which is short since limit is small.
+		j := len(ranked)
+		if j < limit {
+			ranked = append(ranked, m)
+		} else {
+			j--
+		}
+		for ; j > 0 && betterMatch(score, path, ranked[j-1]); j-- {
+			ranked[j] = ranked[j-1]
+		}
+		ranked[j] = m
+	}
+
+	return ranked, total, true
+}
+
+// matchPath matches the pattern to the path, preferring the file name
+// to the directories, since the pattern is usually a part of the name.
+func matchPath(pattern, path string) ([]int, int, bool) {
+	if pattern == "" {
+		return nil, 0, true
+	}
+	i := strings.LastIndexAny(path, `/\`) + 1
+	if match, score, ok := fuzzyMatch(pattern, path[i:]); ok {
+		for j := range match {
+			match[j] += i
+		}
+		return match, score + len(pattern), true
+	}
+
+	return fuzzyMatch(pattern, path)
+}
+
+// mergeMatches merges the ranked matches of the files listed later into
+// the ranked matches of the files listed before, keeping up to limit.
+// The match of the files listed before comes first for the same rank.
+func mergeMatches(before, later []findMatch, limit int) []findMatch {
+	merged := make([]findMatch, 0, min(len(before)+len(later), limit))
+	i, j := 0, 0
+	for len(merged) < limit && (i < len(before) || j < len(later)) {
+		if j < len(later) && (i == len(before) || betterMatch(later[j].score, later[j].path, before[i])) {
+			merged = append(merged, later[j])
+			j++
+		} else {
+			merged = append(merged, before[i])
+			i++
+		}
+	}
+
+	return merged
+}
+
+func betterMatch(score int, path string, m findMatch) bool {
+	if score != m.score {
+		return score > m.score
+	}
+
+	return len(path) < len(m.path)
+}
+
+// Finder is the fuzzy file finder shown with GonvimFind. It lists the files
+// under the cwd through nvim, so that the remote filesystem is listed for
+// the remote nvim, and ranks them in a goroutine while they are listed.
+type Finder struct {
+	ws          *Workspace
+	palette     *Palette
+	input       *widgets.QLineEdit
+	count       *widgets.QLabel
+	preview     *widgets.QPlainTextEdit
+	mu          sync.Mutex
+	files       []string
+	pattern     string
+	root        string
+	id          int
+	done        bool
+	gen         int
+	ranking     bool
+	dirty       bool
+	matches     []findMatch
+	total       int
+	selected    int
+	offset      int
+	previewPath string
+
+	// rankedPattern and rankedFiles are the pattern and the number of the
+	// files which matches and total are ranked for, and rankingPattern and
+	// rankingFiles are those of the ranking in progress.
+	rankedPattern  string
+	rankedFiles    int
+	rankingPattern string
+	rankingFiles   int
+}
+
+func newFinder(ws *Workspace) *Finder {
+	p := initPalette()
+	p.ws = ws
+	p.widget.SetParent(ws.widget)
+	p.pattern.Hide()
+
+	input := widgets.NewQLineEdit(nil)
+	input.SetFrame(false)
+	input.SetPlaceholderText("Find files")
+	input.SetAttribute(core.Qt__WA_InputMethodEnabled, true)
+
+	count := widgets.NewQLabel(nil, 0)
+	count.SetAlignment(core.Qt__AlignRight | core.Qt__AlignVCenter)
+
+	row := widgets.NewQWidget(nil, 0)
+	rowLayout := widgets.NewQHBoxLayout()
+	rowLayout.SetContentsMargins(p.padding, p.padding, p.padding, p.padding)
+	rowLayout.AddWidget(input, 1, 0)
+	rowLayout.AddWidget(count, 0, 0)
+	row.SetLayout(rowLayout)
+	p.patternWidget.Layout().AddWidget(row)
+
+	preview := widgets.NewQPlainTextEdit(nil)
+	preview.SetReadOnly(true)
+	preview.SetFocusPolicy(core.Qt__NoFocus)
+	preview.SetFrameShape(widgets.QFrame__NoFrame)
+	preview.SetLineWrapMode(widgets.QPlainTextEdit__NoWrap)
+	preview.SetHorizontalScrollBarPolicy(core.Qt__ScrollBarAlwaysOff)
+	preview.SetVerticalScrollBarPolicy(core.Qt__ScrollBarAlwaysOff)
+	p.resultMainWidget.Layout().AddWidget(preview)
+
+	f := &Finder{
+		ws:      ws,
+		palette: p,
+		input:   input,
+		count:   count,
+		preview: preview,
+	}
+	input.ConnectKeyPressEvent(f.keyPress)
+	input.ConnectTextChanged(func(text string) {
+		f.mu.Lock()
+		f.pattern = text
+		f.mu.Unlock()
+		f.selected = 0
+		f.offset = 0
+		f.rank()
+	})
+	input.ConnectFocusOutEvent(func(event *gui.QFocusEvent) {
+		input.FocusOutEventDefault(event)
+		if event.Reason() != core.Qt__PopupFocusReason {
+			f.hide()
+		}
+	})
+	p.hide()
+
+	return f
+}
+
+// openFinder shows the finder, and lists the files under the cwd again.
+func (ws *Workspace) openFinder() {
+	if ws.finder == nil {
+		ws.finder = newFinder(ws)
+	}
+	f := ws.finder
+
+	f.mu.Lock()
+	f.id++
+	f.files = nil
+	f.pattern = ""
+	f.root = ""
+	f.done = false
+	id := f.id
+	f.mu.Unlock()
+
+	// The ranking in progress is for the previous files.
+	f.gen++
+	f.ranking = false
+	f.dirty = false
+	f.matches = nil
+	f.total = 0
+	f.rankedPattern = ""
+	f.rankedFiles = 0
+	f.selected = 0
+	f.offset = 0
+	f.previewPath = ""
+	f.preview.Clear()
+	f.input.Clear()
+	f.updateFont()
+	f.palette.show()
+	f.resize()
+	f.updateCount()
+	f.input.SetFocus2()
+
+	go ws.nvim.ExecLua(`goneovim.find_files(...)`, nil, id)
+}
+
+func (f *Finder) hide() {
+	if f == nil || f.palette.hidden {
+		return
+	}
+	f.palette.hide()
+	f.mu.Lock()
+	f.id++
+	f.mu.Unlock()
+	go f.ws.nvim.ExecLua(`goneovim.find_stop()`, nil)
+	f.ws.widget.SetFocus2()
+}
+
+func (f *Finder) updateFont() {
+	f.palette.updateFont()
+	f.input.SetFont(f.ws.screen.font.qfont)
+	f.preview.SetFont(f.ws.screen.font.qfont)
+}
+
+func (f *Finder) resize() {
+	f.palette.resize()
+	f.preview.SetFixedWidth(f.palette.width * 2 / 5)
+	f.preview.SetFixedHeight(f.palette.itemHeight * f.visibleItems())
+	bg := editor.colors.widgetBg
+	if bg != nil {
+		f.input.SetStyleSheet(fmt.Sprintf("background-color: rgba(0, 0, 0, 0); color: %s;", editor.colors.widgetFg.String()))
+		f.preview.SetStyleSheet(fmt.Sprintf("background-color: %s; color: %s;", warpColor(bg, -10).String(), editor.colors.widgetFg.String()))
+		f.count.SetStyleSheet(fmt.Sprintf("color: %s;", editor.colors.inactiveFg.String()))
+	}
+}
+
+// visibleItems returns the number of the results shown at once.
+func (f *Finder) visibleItems() int {
+	n := f.palette.showTotal
+	if n > len(f.palette.resultItems) || n <= 0 {
+		n = len(f.palette.resultItems)
+	}
+
+	return n
+}
+
+// addFiles handles the files which nvim lists for the finder with the id.
+func (f *Finder) addFiles(id int, root string, files []interface{}, done bool) {
+	f.mu.Lock()
+	if id != f.id {
+		f.mu.Unlock()
+		return
+	}
+	f.root = root
+	for _, file := range files {
+		if path, ok := file.(string); ok && path != "" {
+			f.files = append(f.files, path)
+		}
+	}
+	f.done = done
+	f.mu.Unlock()
+
+	f.rank()
+}
+
+// rank ranks the files in a goroutine. The files listed while ranking
+// are ranked after that, so that the finder keeps responsive while
+// the files of a large repository are listed. Only the files listed since
+// the last ranking are ranked and merged into the matches, unless the
+// pattern has changed.
+func (f *Finder) rank() {
+	if f.ranking {
+		f.dirty = true
+		return
+	}
+	f.ranking = true
+	f.dirty = false
+	f.gen++
+	gen := f.gen
+
+	f.mu.Lock()
+	files := f.files
+	pattern := f.pattern
+	f.mu.Unlock()
+
+	var before []findMatch
+	beforeTotal := 0
+	f.rankingPattern = pattern
+	f.rankingFiles = len(files)
+	if pattern == f.rankedPattern && f.rankedFiles <= len(files) {
+		before = f.matches
+		beforeTotal = f.total
+		files = files[f.rankedFiles:]
+	}
+
+	go func() {
+		matches, total, ok := rankFiles(files, pattern, finderMaxResults, func() bool {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			return f.pattern != pattern
+		})
+		if ok {
+			matches = mergeMatches(before, matches, finderMaxResults)
+			total += beforeTotal
+		}
+		f.ws.postGui("gonvim_find_ranked", gen, matches, total, ok)
+	}()
+}
+
+// ranked handles the result of rank.
+func (f *Finder) ranked(gen int, matches []findMatch, total int, ok bool) {
+	if gen != f.gen {
+		return
+	}
+	f.ranking = false
+	if ok {
+		f.matches = matches
+		f.total = total
+		f.rankedPattern = f.rankingPattern
+		f.rankedFiles = f.rankingFiles
+		if f.selected >= len(f.matches) {
+			f.selected = len(f.matches) - 1
+		}
+		if f.selected < 0 {
+			f.selected = 0
+		}
+		f.update()
+	}
+	if f.dirty || !ok {
+		f.rank()
+	}
+}
+
+func (f *Finder) updateCount() {
+	f.mu.Lock()
+	n, done := len(f.files), f.done
+	f.mu.Unlock()
+
+	text := fmt.Sprintf("%d/%d", f.total, n)
+	if !done {
+		text += " …"
+	}
+	f.count.SetText(text)
+}
+
+// update shows the results around the selected one.
+func (f *Finder) update() {
+	f.updateCount()
+	visible := f.visibleItems()
+	if f.selected < f.offset {
+		f.offset = f.selected
+	}
+	if f.selected >= f.offset+visible {
+		f.offset = f.selected - visible + 1
+	}
+	for i, item := range f.palette.resultItems {
+		n := f.offset + i
+		if i >= visible || n >= len(f.matches) {
+			item.hide()
+			continue
+		}
+		m := f.matches[n]
+		item.setItem(m.path, "file", append([]int(nil), m.match...))
+		item.setSelected(n == f.selected)
+		item.show()
+	}
+	f.showPreview()
+}
+
+func (f *Finder) selectedPath() string {
+	if f.selected < 0 || f.selected >= len(f.matches) {
+		return ""
+	}
+	f.mu.Lock()
+	root := f.root
+	f.mu.Unlock()
+
+	if strings.HasSuffix(root, "/") || strings.HasSuffix(root, `\`) {
+		return root + f.matches[f.selected].path
+	}
+
+	return root + "/" + f.matches[f.selected].path
+}
+
+// showPreview reads the head of the selected file through nvim.
+func (f *Finder) showPreview() {
+	path := f.selectedPath()
+	if path == f.previewPath {
+		return
+	}
+	f.previewPath = path
+	if path == "" {
+		f.preview.Clear()
+		return
+	}
+	go func() {
+		var lines []string
+		if err := f.ws.nvim.ExecLua(`return goneovim.find_preview(...)`, &lines, path, finderPreviewLines); err != nil {
+			return
+		}
+		f.ws.postGui("gonvim_find_preview", path, strings.Join(lines, "\n"))
+	}()
+}
+
+func (f *Finder) setPreview(path, text string) {
+	if path != f.previewPath {
+		return
+	}
+	f.preview.SetPlainText(text)
+}
+
+func (f *Finder) moveSelected(delta int) {
+	if len(f.matches) == 0 {
+		return
+	}
+	f.selected = (f.selected + delta + len(f.matches)) % len(f.matches)
+	f.update()
+}
+
+// open opens the selected file with cmd, which is edit, split,
+// vsplit or tabedit.
+func (f *Finder) open(cmd string) {
+	path := f.selectedPath()
+	f.hide()
+	if path == "" {
+		return
+	}
+	go f.ws.nvim.Command(fmt.Sprintf("execute %q fnameescape(%q)", cmd, path))
+}
+
+func (f *Finder) keyPress(event *gui.QKeyEvent) {
+	ctrl := event.Modifiers()&core.Qt__ControlModifier != 0
+	switch core.Qt__Key(event.Key()) {
+	case core.Qt__Key_Escape:
+		f.hide()
+	case core.Qt__Key_Up:
+		f.moveSelected(-1)
+	case core.Qt__Key_Down:
+		f.moveSelected(1)
+	case core.Qt__Key_Return, core.Qt__Key_Enter:
+		f.open("edit")
+	default:
+		if !ctrl {
+			f.input.KeyPressEventDefault(event)
+			return
+		}
+		switch core.Qt__Key(event.Key()) {
+		case core.Qt__Key_P, core.Qt__Key_K:
+			f.moveSelected(-1)
+		case core.Qt__Key_N, core.Qt__Key_J:
+			f.moveSelected(1)
+		case core.Qt__Key_S, core.Qt__Key_X:
+			f.open("split")
+		case core.Qt__Key_V:
+			f.open("vsplit")
+		case core.Qt__Key_T:
+			f.open("tabedit")
+		default:
+			f.input.KeyPressEventDefault(event)
+		}
+	}
+}
+
+// postGui passes the result of a goroutine to handleGui in the GUI thread.
+func (ws *Workspace) postGui(updates ...interface{}) {
+	ws.guiUpdates <- updates
+	ws.signal.GuiSignal()
+}
+
+// handleFinder handles the events of the finder.
+func (ws *Workspace) handleFinder(updates []interface{}) {
+	switch updates[0] {
+	case "gonvim_find":
+		ws.openFinder()
+	case "gonvim_find_files":
+		if ws.finder == nil || len(updates) < 5 {
+			return
+		}
+		root, _ := updates[2].(string)
+		files, _ := updates[3].([]interface{})
+		done, _ := updates[4].(bool)
+		ws.finder.addFiles(util.ReflectToInt(updates[1]), root, files, done)
+	case "gonvim_find_ranked":
+		matches, _ := updates[2].([]findMatch)
+		ok, _ := updates[4].(bool)
+		ws.finder.ranked(updates[1].(int), matches, updates[3].(int), ok)
+	case "gonvim_find_preview":
+		path, _ := updates[1].(string)
+		text, _ := updates[2].(string)
+		ws.finder.setPreview(path, text)
+	}
+}
@@ -0,0 +1,108 @@
+package editor
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestRankFiles(t *testing.T) {
+	files := []string{
+		"docs/xmain/x.go",
+		"cmd/goneovim/main.go",
+		"main.go",
+		"editor/util.go",
+		"m/a/i/n.go",
+	}
+
+	tests := []struct {
+		name      string
+		pattern   string
+		limit     int
+		want      []string
+		wantTotal int
+	}{
+		{
+			"rankFiles() keeps the order of the same score, the shorter first",
+			"",
+			10,
+			[]string{"main.go", "m/a/i/n.go", "editor/util.go", "docs/xmain/x.go", "cmd/goneovim/main.go"},
+			5,
+		},
+		{
+			"rankFiles() puts the consecutive matches at the start of the words first",
+			"main",
+			10,
+			[]string{"main.go", "cmd/goneovim/main.go", "docs/xmain/x.go", "m/a/i/n.go"},
+			4,
+		},
+		{
+			"rankFiles() keeps the best files up to limit",
+			"main",
+			2,
+			[]string{"main.go", "cmd/goneovim/main.go"},
+			4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranked, total, ok := rankFiles(files, tt.pattern, tt.limit, nil)
+			if !ok {
+				t.Fatal("rankFiles() gave up")
+			}
+			var got []string
+			for _, m := range ranked {
+				got = append(got, m.path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rankFiles() = %v, want %v", got, tt.want)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("rankFiles() total = %d, want %d", total, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestRankFilesStale(t *testing.T) {
+	files := make([]string, 10000)
+	for i := range files {
+		files[i] = fmt.Sprintf("dir%d/file%d.go", i%100, i)
+	}
+	calls := 0
+	_, _, ok := rankFiles(files, "file", 10, func() bool {
+		calls++
+		return calls > 1
+	})
+	if ok {
+		t.Error("rankFiles() = ok, want to give up when the pattern is stale")
+	}
+}
+
+func TestMergeMatches(t *testing.T) {
+	files := []string{
+		"docs/xmain/x.go",
+		"cmd/goneovim/main.go",
+		"main.go",
+		"editor/util.go",
+		"m/a/i/n.go",
+		"editor/main.go",
+	}
+
+	for _, pattern := range []string{"", "main"} {
+		for _, limit := range []int{2, 10} {
+			for split := 0; split <= len(files); split++ {
+				want, wantTotal, _ := rankFiles(files, pattern, limit, nil)
+				before, beforeTotal, _ := rankFiles(files[:split], pattern, limit, nil)
+				later, laterTotal, _ := rankFiles(files[split:], pattern, limit, nil)
+				got := mergeMatches(before, later, limit)
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("mergeMatches() with %q, limit %d, split %d = %v, want %v", pattern, limit, split, got, want)
+				}
+				if beforeTotal+laterTotal != wantTotal {
+					t.Errorf("total with %q, split %d = %d, want %d", pattern, split, beforeTotal+laterTotal, wantTotal)
+				}
+			}
+		}
+	}
+}
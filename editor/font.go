@@ -55,11 +55,8 @@ func fontSizeNew(font *gui.QFont) (float64, int, float64, float64) {
 func initFontNew(family string, size float64, weight gui.QFont__Weight, stretch, lineSpace, letterSpace int) *Font {
 	// font := gui.NewQFont2(family, size, int(gui.QFont__Normal), false)
 	font := gui.NewQFont()
-	if editor.config.Editor.ManualFontFallback {
-		font.SetStyleHint(gui.QFont__TypeWriter, gui.QFont__NoFontMerging|gui.QFont__ForceIntegerMetrics)
-	} else {
-		font.SetStyleHint(gui.QFont__TypeWriter, gui.QFont__PreferDefault|gui.QFont__ForceIntegerMetrics)
-	}
+	font.SetStyleHint(gui.QFont__TypeWriter, fontStyleStrategy())
+	font.SetHintingPreference(fontHintingPreference())
 
 	font.SetFamily(family)
 	font.SetPointSizeF(size)
@@ -98,11 +95,8 @@ func (f *Font) change(family string, size float64, weight gui.QFont__Weight, str
 
 	f.qfont.SetFamily(family)
 
-	if editor.config.Editor.ManualFontFallback {
-		f.qfont.SetStyleHint(gui.QFont__TypeWriter, gui.QFont__NoFontMerging|gui.QFont__ForceIntegerMetrics)
-	} else {
-		f.qfont.SetStyleHint(gui.QFont__TypeWriter, gui.QFont__PreferDefault|gui.QFont__ForceIntegerMetrics)
-	}
+	f.qfont.SetStyleHint(gui.QFont__TypeWriter, fontStyleStrategy())
+	f.qfont.SetHintingPreference(fontHintingPreference())
 
 	f.qfont.SetPointSizeF(size)
 	f.qfont.SetWeight(int(weight))
@@ -186,6 +180,7 @@ func (f *Font) changeLineSpace(lineSpace int) {
 	if f.ws == nil {
 		return
 	}
+	f.ws.screen.resetHighlightFonts()
 	f.ws.screen.purgeTextCacheForWins()
 }
 
@@ -196,5 +191,6 @@ func (f *Font) changeLetterSpace(letterspace int) {
 	f.cellwidth = width + float64(letterspace)
 	f.italicWidth = italicWidth + float64(letterspace)
 
+	f.ws.screen.resetHighlightFonts()
 	f.ws.screen.purgeTextCacheForWins()
 }
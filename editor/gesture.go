@@ -0,0 +1,240 @@
+package editor
+
+import (
+	"strings"
+
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+	"github.com/akiyosi/qt/widgets"
+)
+
+type gestureKind int
+
+const (
+	gesturePinch gestureKind = iota
+	gestureSwipe
+)
+
+type gestureAction int
+
+const (
+	gestureActionZoomIn gestureAction = iota
+	gestureActionZoomOut
+	gestureActionWorkspaceNext
+	gestureActionWorkspacePrevious
+)
+
+// gestureEvent is a touchpad gesture decoupled from the Qt event types,
+// so that the recognition can be driven with synthetic events.
+type gestureEvent struct {
+	kind gestureKind
+	// scale is the change of the pinch scale since the previous event.
+	scale float64
+	// dx is the horizontal direction of a swipe, -1 to the left and 1 to the right.
+	dx  int
+	end bool
+}
+
+// gestureRecognizer turns the gesture events into editor actions
+// according to the [Gesture] section of settings.toml.
+type gestureRecognizer struct {
+	pinchScale float64
+}
+
+func (g *gestureRecognizer) recognize(ev gestureEvent, conf gestureConfig) (actions []gestureAction) {
+	switch ev.kind {
+	case gesturePinch:
+		if strings.ToLower(conf.Pinch) != "zoom" {
+			return
+		}
+		if g.pinchScale == 0 {
+			g.pinchScale = 1.0
+		}
+		if ev.scale > 0 {
+			g.pinchScale *= ev.scale
+		}
+		for g.pinchScale >= zoomStep {
+			g.pinchScale /= zoomStep
+			actions = append(actions, gestureActionZoomIn)
+		}
+		for g.pinchScale <= 1.0/zoomStep {
+			g.pinchScale *= zoomStep
+			actions = append(actions, gestureActionZoomOut)
+		}
+		if ev.end {
+			g.pinchScale = 1.0
+		}
+
+	case gestureSwipe:
+		if strings.ToLower(conf.Swipe) != "workspace" {
+			return
+		}
+		// Swiping to the left brings the next workspace in from the right.
+		if ev.dx < 0 {
+			actions = append(actions, gestureActionWorkspaceNext)
+		} else if ev.dx > 0 {
+			actions = append(actions, gestureActionWorkspacePrevious)
+		}
+	}
+
+	return
+}
+
+// isGestureEnabled reports whether any gesture is mapped to an action.
+func isGestureEnabled(conf gestureConfig) bool {
+	pinch := strings.ToLower(conf.Pinch)
+	swipe := strings.ToLower(conf.Swipe)
+
+	return (pinch != "" && pinch != "none") || (swipe != "" && swipe != "none")
+}
+
+// connectGestures makes the grid window receive touchpad gestures.
+// macOS delivers them as native gestures, other platforms through QGesture.
+func (w *Window) connectGestures() {
+	if !isGestureEnabled(editor.config.Gesture) {
+		return
+	}
+	w.GrabGesture(core.Qt__PinchGesture, 0)
+	w.GrabGesture(core.Qt__SwipeGesture, 0)
+	w.ConnectEvent(func(event *core.QEvent) bool {
+		switch event.Type() {
+		case core.QEvent__NativeGesture:
+			if ev, ok := nativeGestureEvent(gui.NewQNativeGestureEventFromPointer(event.Pointer())); ok {
+				w.s.ws.handleGesture(ev)
+				event.Accept()
+				return true
+			}
+		case core.QEvent__Gesture:
+			if w.handleQGestureEvent(widgets.NewQGestureEventFromPointer(event.Pointer())) {
+				return true
+			}
+		}
+
+		return w.EventDefault(event)
+	})
+}
+
+func nativeGestureEvent(event *gui.QNativeGestureEvent) (gestureEvent, bool) {
+	switch event.GestureType() {
+	case core.Qt__ZoomNativeGesture:
+		return gestureEvent{kind: gesturePinch, scale: 1.0 + event.Value()}, true
+	case core.Qt__EndNativeGesture:
+		return gestureEvent{kind: gesturePinch, scale: 1.0, end: true}, true
+	case core.Qt__SwipeNativeGesture:
+		// The value is the angle of the swipe in degrees.
+		switch int(event.Value()) {
+		case 180:
+			return gestureEvent{kind: gestureSwipe, dx: -1}, true
+		case 0:
+			return gestureEvent{kind: gestureSwipe, dx: 1}, true
+		}
+	}
+
+	return gestureEvent{}, false
+}
+
+func (w *Window) handleQGestureEvent(event *widgets.QGestureEvent) bool {
+	handled := false
+	if g := event.Gesture(core.Qt__PinchGesture); g != nil && g.Pointer() != nil {
+		pinch := widgets.NewQPinchGestureFromPointer(g.Pointer())
+		w.s.ws.handleGesture(gestureEvent{
+			kind:  gesturePinch,
+			scale: pinch.ScaleFactor(),
+			end:   pinch.State() == core.Qt__GestureFinished || pinch.State() == core.Qt__GestureCanceled,
+		})
+		event.Accept(g)
+		handled = true
+	}
+	if g := event.Gesture(core.Qt__SwipeGesture); g != nil && g.Pointer() != nil {
+		swipe := widgets.NewQSwipeGestureFromPointer(g.Pointer())
+		if swipe.State() == core.Qt__GestureFinished {
+			dx := 0
+			switch swipe.HorizontalDirection() {
+			case widgets.QSwipeGesture__Left:
+				dx = -1
+			case widgets.QSwipeGesture__Right:
+				dx = 1
+			}
+			w.s.ws.handleGesture(gestureEvent{kind: gestureSwipe, dx: dx, end: true})
+		}
+		event.Accept(g)
+		handled = true
+	}
+
+	return handled
+}
+
+func (ws *Workspace) handleGesture(ev gestureEvent) {
+	for _, action := range ws.gesture.recognize(ev, editor.config.Gesture) {
+		switch action {
+		case gestureActionZoomIn:
+			ws.zoomIn()
+		case gestureActionZoomOut:
+			ws.zoomOut()
+		case gestureActionWorkspaceNext:
+			if ws.detached != nil {
+				continue
+			}
+			editor.slideWorkspace(editor.workspaceNext, -1)
+		case gestureActionWorkspacePrevious:
+			if ws.detached != nil {
+				continue
+			}
+			editor.slideWorkspace(editor.workspacePrevious, 1)
+		}
+	}
+}
+
+// slideWorkspace switches the workspace with switchWorkspace, sliding the
+// snapshots of the old and new workspace widgets in the direction dx.
+func (e *Editor) slideWorkspace(switchWorkspace func(), dx int) {
+	if len(e.workspaces) < 2 {
+		return
+	}
+	duration := e.config.Gesture.SwipeAnimationDuration
+	if !e.config.Gesture.SwipeAnimation || duration <= 0 {
+		switchWorkspace()
+		return
+	}
+
+	oldWs := e.workspaces[e.active]
+	oldPixmap := oldWs.widget.Grab(core.NewQRect4(0, 0, -1, -1))
+	switchWorkspace()
+	newWs := e.workspaces[e.active]
+	if newWs == oldWs {
+		return
+	}
+	newPixmap := newWs.widget.Grab(core.NewQRect4(0, 0, -1, -1))
+
+	width := e.widget.Width()
+	height := e.widget.Height()
+
+	// Lay out both snapshots side by side in a strip
+	// and move the strip across the workspace area.
+	strip := widgets.NewQWidget(e.widget, 0)
+	left, right := oldPixmap, newPixmap
+	start, end := 0, -width
+	if dx > 0 {
+		left, right = newPixmap, oldPixmap
+		start, end = -width, 0
+	}
+	for i, pixmap := range []*gui.QPixmap{left, right} {
+		label := widgets.NewQLabel(strip, 0)
+		label.SetPixmap(pixmap)
+		label.SetGeometry2(width*i, 0, width, height)
+	}
+	strip.SetGeometry2(start, 0, width*2, height)
+	strip.Show()
+	strip.Raise()
+
+	a := core.NewQPropertyAnimation2(strip, core.NewQByteArray2("pos", len("pos")), strip)
+	a.SetDuration(duration)
+	a.SetStartValue(core.NewQVariant27(core.NewQPoint2(start, 0)))
+	a.SetEndValue(core.NewQVariant27(core.NewQPoint2(end, 0)))
+	a.SetEasingCurve(core.NewQEasingCurve(core.QEasingCurve__OutCubic))
+	a.ConnectFinished(func() {
+		strip.Hide()
+		strip.DeleteLater()
+	})
+	a.Start(core.QAbstractAnimation__DeletionPolicy(core.QAbstractAnimation__KeepWhenStopped))
+}
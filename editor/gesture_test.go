@@ -0,0 +1,78 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGestureRecognizerRecognize(t *testing.T) {
+	conf := gestureConfig{
+		Pinch: "zoom",
+		Swipe: "workspace",
+	}
+	tests := []struct {
+		name   string
+		conf   gestureConfig
+		events []gestureEvent
+		want   []gestureAction
+	}{
+		{
+			"recognize() small pinch steps accumulate into a zoom in",
+			conf,
+			[]gestureEvent{
+				{kind: gesturePinch, scale: 1.04},
+				{kind: gesturePinch, scale: 1.04},
+				{kind: gesturePinch, scale: 1.04},
+			},
+			[]gestureAction{gestureActionZoomIn},
+		},
+		{
+			"recognize() pinch in zooms out",
+			conf,
+			[]gestureEvent{
+				{kind: gesturePinch, scale: 0.8},
+			},
+			[]gestureAction{gestureActionZoomOut, gestureActionZoomOut},
+		},
+		{
+			"recognize() the end of a pinch discards the remainder",
+			conf,
+			[]gestureEvent{
+				{kind: gesturePinch, scale: 1.08, end: true},
+				{kind: gesturePinch, scale: 1.08},
+			},
+			nil,
+		},
+		{
+			"recognize() swipe to the left and right",
+			conf,
+			[]gestureEvent{
+				{kind: gestureSwipe, dx: -1},
+				{kind: gestureSwipe, dx: 1},
+				{kind: gestureSwipe, dx: 0},
+			},
+			[]gestureAction{gestureActionWorkspaceNext, gestureActionWorkspacePrevious},
+		},
+		{
+			"recognize() disabled gestures",
+			gestureConfig{Pinch: "none", Swipe: "none"},
+			[]gestureEvent{
+				{kind: gesturePinch, scale: 2.0},
+				{kind: gestureSwipe, dx: -1},
+			},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &gestureRecognizer{}
+			var got []gestureAction
+			for _, ev := range tt.events {
+				got = append(got, g.recognize(ev, tt.conf)...)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("recognize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,443 @@
+package editor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+	"github.com/akiyosi/qt/widgets"
+)
+
+// gitFile is a file which git status lists,
+// with the status in the index and in the work tree.
+type gitFile struct {
+	path     string
+	index    byte
+	worktree byte
+}
+
+// gitStatus is the git status of the repository of the cwd of a workspace,
+// which nvim of the workspace reports so that a remote repository is shown
+// for the remote nvim.
+type gitStatus struct {
+	root   string
+	branch string
+	files  []gitFile
+	byPath map[string]string
+}
+
+// parseGitStatus parses the output of git status --porcelain=v1 --branch
+// in the repository at root. It returns nil out of a repository.
+func parseGitStatus(root string, lines []string) *gitStatus {
+	if root == "" || len(lines) == 0 || !strings.HasPrefix(lines[0], "## ") {
+		return nil
+	}
+	g := &gitStatus{
+		root:   strings.TrimRight(slashPath(root), "/"),
+		branch: parseGitBranch(lines[0]),
+		byPath: make(map[string]string),
+	}
+	for _, line := range lines[1:] {
+		if len(line) < 4 {
+			continue
+		}
+		path := line[3:]
+		// The renamed file is listed as "old -> new".
+		if i := strings.Index(path, " -> "); i >= 0 {
+			path = path[i+4:]
+		}
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			path = unquoted
+		}
+		f := gitFile{path: strings.TrimRight(path, "/"), index: line[0], worktree: line[1]}
+		g.files = append(g.files, f)
+		g.byPath[g.root+"/"+f.path] = f.status()
+	}
+
+	return g
+}
+
+// parseGitBranch returns the branch in the header line of git status.
+func parseGitBranch(header string) string {
+	branch := strings.TrimPrefix(header, "## ")
+	for _, prefix := range []string{"No commits yet on ", "Initial commit on "} {
+		branch = strings.TrimPrefix(branch, prefix)
+	}
+	if i := strings.Index(branch, "..."); i >= 0 {
+		branch = branch[:i]
+	}
+	if i := strings.Index(branch, " "); i >= 0 {
+		branch = branch[:i]
+	}
+
+	return branch
+}
+
+// status returns the letter for the status of the file.
+func (f gitFile) status() string {
+	x, y := f.index, f.worktree
+	switch {
+	case x == '?' && y == '?':
+		return "U"
+	case x == '!' && y == '!':
+		return "I"
+	case x == 'U' || y == 'U' || (x == 'A' && y == 'A') || (x == 'D' && y == 'D'):
+		return "C"
+	case x == 'A' || y == 'A':
+		return "A"
+	case x == 'D' || y == 'D':
+		return "D"
+	case x == 'R' || y == 'R':
+		return "R"
+	}
+
+	return "M"
+}
+
+// isStaged reports whether the file has changes in the index.
+func (f gitFile) isStaged() bool {
+	return f.index != ' ' && f.index != '?' && f.index != '!'
+}
+
+// isChanged reports whether the file has changes in the work tree.
+func (f gitFile) isChanged() bool {
+	return f.worktree != ' ' && f.worktree != '!'
+}
+
+// changes returns the files which are not ignored.
+func (g *gitStatus) changes() []gitFile {
+	if g == nil {
+		return nil
+	}
+	var files []gitFile
+	for _, f := range g.files {
+		if f.status() != "I" {
+			files = append(files, f)
+		}
+	}
+
+	return files
+}
+
+// decoration returns the mark of the entry at path in the file tree.
+// A directory is marked if it contains a changed file.
+func (g *gitStatus) decoration(path string, isDir bool) string {
+	if g == nil {
+		return ""
+	}
+	path = strings.TrimRight(slashPath(path), "/")
+	if status, ok := g.byPath[path]; ok {
+		return status
+	}
+	// The files in an ignored or untracked directory are listed as the directory.
+	for dir := gitParentDir(path); len(dir) > len(g.root); dir = gitParentDir(dir) {
+		if status := g.byPath[dir]; status == "I" || status == "U" {
+			return status
+		}
+	}
+	if isDir {
+		for p, status := range g.byPath {
+			if status != "I" && strings.HasPrefix(p, path+"/") {
+				return "●"
+			}
+		}
+	}
+
+	return ""
+}
+
+// gitBadges returns the badges for the branch and the number of the changes.
+func gitBadges(g *gitStatus) []workspaceBadge {
+	if g == nil {
+		return nil
+	}
+	badges := []workspaceBadge{{"branch", "⎇ " + g.branch}}
+	if n := len(g.changes()); n > 0 {
+		badges = append(badges, workspaceBadge{"changes", fmt.Sprintf("± %d", n)})
+	}
+
+	return badges
+}
+
+// slashPath replaces the backslashes in path with the slashes,
+// so that the paths reported by git and by nvim on Windows are compared.
+func slashPath(path string) string {
+	return strings.ReplaceAll(path, `\`, "/")
+}
+
+// gitParentDir returns the directory which contains the slashed path.
+func gitParentDir(path string) string {
+	i := strings.LastIndex(path, "/")
+	if i <= 0 {
+		return ""
+	}
+
+	return path[:i]
+}
+
+// setGitStatus handles the git status which the autocmds notify.
+func (ws *Workspace) setGitStatus(root string, lines []string) {
+	ws.git = parseGitStatus(root, lines)
+	ws.updateSideBadges()
+	if editor.side == nil {
+		return
+	}
+	if ws.getNum() == editor.active {
+		editor.side.git.update(ws)
+	}
+	index := ws.getNum()
+	if index < len(editor.side.items) && !editor.side.items[index].isContentHide {
+		go ws.nvim.Call("rpcnotify", nil, 0, "GonvimFiler", "redraw")
+	}
+}
+
+// GitPanel is the panel in the sidebar which lists the changed files
+// in the repository of the active workspace.
+type GitPanel struct {
+	widget  *widgets.QWidget
+	header  *widgets.QLabel
+	content *widgets.QListWidget
+	ws      *Workspace
+	files   []gitFile
+}
+
+func newGitPanel() *GitPanel {
+	widget := widgets.NewQWidget(nil, 0)
+	widget.SetStyleSheet(" * { background-color: rgba(0, 0, 0, 0); }")
+	layout := widgets.NewQBoxLayout(widgets.QBoxLayout__TopToBottom, widget)
+	layout.SetContentsMargins(0, 0, 0, 5)
+	layout.SetSpacing(0)
+
+	header := widgets.NewQLabel(nil, 0)
+	header.SetContentsMargins(22, 15, 20, 10)
+	header.SetText("CHANGES")
+
+	content := widgets.NewQListWidget(nil)
+	content.SetFocusPolicy(core.Qt__ClickFocus)
+	content.SetFrameShape(widgets.QFrame__NoFrame)
+	content.SetHorizontalScrollBarPolicy(core.Qt__ScrollBarAlwaysOff)
+	content.SetFont(editor.font.qfont)
+
+	layout.AddWidget(header, 0, 0)
+	layout.AddWidget(content, 0, 0)
+
+	p := &GitPanel{
+		widget:  widget,
+		header:  header,
+		content: content,
+	}
+	content.ConnectItemDoubleClicked(func(item *widgets.QListWidgetItem) {
+		p.diff(content.Row(item))
+	})
+	content.ConnectKeyPressEvent(p.keyPress)
+	content.ConnectContextMenuEvent(p.contextMenu)
+	widget.Hide()
+
+	return p
+}
+
+// update shows the changes of the workspace.
+func (p *GitPanel) update(ws *Workspace) {
+	if p == nil {
+		return
+	}
+	p.ws = ws
+	if ws == nil || ws.git == nil {
+		p.files = nil
+		p.widget.Hide()
+		return
+	}
+	p.files = ws.git.changes()
+	p.header.SetText(fmt.Sprintf("CHANGES  ⎇ %s", ws.git.branch))
+
+	row := p.content.CurrentRow()
+	p.content.Clear()
+	for _, f := range p.files {
+		p.content.AddItem(fmt.Sprintf("%s%s  %s", gitMark(f.index), gitMark(f.worktree), f.path))
+	}
+	if row >= 0 && row < len(p.files) {
+		p.content.SetCurrentRow(row)
+	}
+
+	rowNum := len(p.files)
+	if rowNum > editor.config.FileExplore.MaxDisplayItems {
+		rowNum = editor.config.FileExplore.MaxDisplayItems
+	}
+	itemHeight := p.content.SizeHintForRow(0)
+	if itemHeight <= 0 {
+		itemHeight = editor.font.lineHeight
+	}
+	p.content.SetFixedHeight(itemHeight * rowNum)
+	p.content.SetVisible(rowNum > 0)
+	p.widget.Show()
+}
+
+// gitMark returns the letter of git status shown in the panel,
+// which is "·" for no change.
+func gitMark(c byte) string {
+	if c == ' ' {
+		return "·"
+	}
+
+	return string(c)
+}
+
+func (p *GitPanel) setColor() {
+	if p == nil {
+		return
+	}
+	p.header.SetStyleSheet(fmt.Sprintf(" .QLabel{ color: %s;} ", editor.colors.sideBarFg.String()))
+	p.content.SetStyleSheet(
+		fmt.Sprintf(`
+			QListWidget::item {
+			   color: %s;
+			   padding-left: 20px;
+			   background-color: rgba(0, 0, 0, 0.0);
+			}
+			QListWidget::item:selected {
+			   background-color: %s;
+			}`,
+			editor.colors.sideBarFg.String(),
+			editor.colors.selectedBg.String(),
+		),
+	)
+}
+
+func (p *GitPanel) file(row int) (gitFile, bool) {
+	if row < 0 || row >= len(p.files) || p.ws == nil || p.ws.git == nil {
+		return gitFile{}, false
+	}
+
+	return p.files[row], true
+}
+
+// diff opens the file in a vertical diffsplit with the version in HEAD.
+func (p *GitPanel) diff(row int) {
+	f, ok := p.file(row)
+	if !ok {
+		return
+	}
+	ws := p.ws
+	go ws.nvim.ExecLua(`goneovim.git_diff(...)`, nil, ws.git.root, f.path)
+	ws.widget.SetFocus2()
+}
+
+// run runs git with args for the file at row.
+func (p *GitPanel) run(row int, args ...string) {
+	f, ok := p.file(row)
+	if !ok {
+		return
+	}
+	go runGit(p.ws, p.ws.git.root, f.path, args...)
+}
+
+// runGit starts git with args for the file at path in the repository at root
+// through nvim of the workspace, which shows the error and refreshes the status
+// when git exits.
+func runGit(ws *Workspace, root, path string, args ...string) {
+	args = append(args, "--", path)
+	if err := ws.nvim.ExecLua(`goneovim.git(...)`, nil, root, args); err != nil {
+		ws.nvim.WritelnErr("goneovim: " + err.Error())
+	}
+}
+
+func (p *GitPanel) stage(row int) {
+	p.run(row, "add")
+}
+
+func (p *GitPanel) unstage(row int) {
+	p.run(row, "reset", "-q")
+}
+
+// discard drops the changes in the work tree of the file after confirming.
+// The untracked file is removed.
+func (p *GitPanel) discard(row int) {
+	f, ok := p.file(row)
+	if !ok || !f.isChanged() {
+		return
+	}
+	ws := p.ws
+	root := ws.git.root
+	go func() {
+		var choice int
+		message := fmt.Sprintf("Discard the changes of %s?", f.path)
+		if err := ws.nvim.Call("confirm", &choice, message, "&Yes\n&No", 2); err != nil || choice != 1 {
+			return
+		}
+		if f.status() == "U" {
+			runGit(ws, root, f.path, "clean", "-f", "-q")
+		} else {
+			runGit(ws, root, f.path, "checkout", "-q")
+		}
+	}()
+}
+
+func (p *GitPanel) keyPress(event *gui.QKeyEvent) {
+	row := p.content.CurrentRow()
+	switch core.Qt__Key(event.Key()) {
+	case core.Qt__Key_Return, core.Qt__Key_Enter:
+		p.diff(row)
+		return
+	case core.Qt__Key_Escape:
+		if p.ws != nil {
+			p.ws.widget.SetFocus2()
+		}
+		return
+	}
+	switch event.Text() {
+	case "j":
+		if row < p.content.Count()-1 {
+			p.content.SetCurrentRow(row + 1)
+		}
+	case "k":
+		if row > 0 {
+			p.content.SetCurrentRow(row - 1)
+		}
+	case "s":
+		p.stage(row)
+	case "u":
+		p.unstage(row)
+	case "X":
+		p.discard(row)
+	default:
+		p.content.KeyPressEventDefault(event)
+	}
+}
+
+func (p *GitPanel) contextMenu(event *gui.QContextMenuEvent) {
+	row := p.content.Row(p.content.ItemAt(event.Pos()))
+	f, ok := p.file(row)
+	if !ok {
+		return
+	}
+	p.content.SetCurrentRow(row)
+
+	menu := widgets.NewQMenu(nil)
+	menu.SetAttribute(core.Qt__WA_DeleteOnClose, true)
+
+	menu.AddAction("Open Diff").ConnectTriggered(func(checked bool) {
+		p.diff(row)
+	})
+	stage := menu.AddAction("Stage")
+	stage.SetEnabled(f.isChanged())
+	stage.ConnectTriggered(func(checked bool) {
+		p.stage(row)
+	})
+	unstage := menu.AddAction("Unstage")
+	unstage.SetEnabled(f.isStaged())
+	unstage.ConnectTriggered(func(checked bool) {
+		p.unstage(row)
+	})
+
+	menu.AddSeparator()
+
+	discard := menu.AddAction("Discard Changes...")
+	discard.SetEnabled(f.isChanged())
+	discard.ConnectTriggered(func(checked bool) {
+		p.discard(row)
+	})
+
+	menu.Popup(event.GlobalPos(), nil)
+}
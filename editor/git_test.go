@@ -0,0 +1,153 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGitBranch(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"parseGitBranch() returns the local branch", "## main", "main"},
+		{"parseGitBranch() strips the upstream", "## main...origin/main [ahead 1]", "main"},
+		{"parseGitBranch() returns the branch without commits", "## No commits yet on main", "main"},
+		{"parseGitBranch() returns HEAD for the detached HEAD", "## HEAD (no branch)", "HEAD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseGitBranch(tt.header); got != tt.want {
+				t.Errorf("parseGitBranch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGitStatus(t *testing.T) {
+	lines := []string{
+		"## main...origin/main",
+		" M editor/git.go",
+		"A  editor/new.go",
+		"R  old.go -> new.go",
+		"?? \"with space.go\"",
+		"?? tmp/",
+		"!! build/",
+	}
+	g := parseGitStatus("/repo/", lines)
+	if g == nil {
+		t.Fatal("parseGitStatus() = nil, want the status")
+	}
+	if g.root != "/repo" || g.branch != "main" {
+		t.Errorf("parseGitStatus() root = %q, branch = %q", g.root, g.branch)
+	}
+	want := map[string]string{
+		"/repo/editor/git.go": "M",
+		"/repo/editor/new.go": "A",
+		"/repo/new.go":        "R",
+		"/repo/with space.go": "U",
+		"/repo/tmp":           "U",
+		"/repo/build":         "I",
+	}
+	if !reflect.DeepEqual(g.byPath, want) {
+		t.Errorf("parseGitStatus() byPath = %v, want %v", g.byPath, want)
+	}
+	if got := len(g.changes()); got != 5 {
+		t.Errorf("changes() returns %d files, want 5", got)
+	}
+
+	if g := parseGitStatus("", nil); g != nil {
+		t.Errorf("parseGitStatus() = %v, want nil out of a repository", g)
+	}
+}
+
+func TestGitFileStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		xy         string
+		want       string
+		wantStaged bool
+		wantChange bool
+	}{
+		{"status() modified in the work tree", " M", "M", false, true},
+		{"status() modified in the index", "M ", "M", true, false},
+		{"status() added and modified", "AM", "A", true, true},
+		{"status() deleted", " D", "D", false, true},
+		{"status() conflicted", "UU", "C", true, true},
+		{"status() untracked", "??", "U", false, true},
+		{"status() ignored", "!!", "I", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := gitFile{index: tt.xy[0], worktree: tt.xy[1]}
+			if got := f.status(); got != tt.want {
+				t.Errorf("status() = %q, want %q", got, tt.want)
+			}
+			if got := f.isStaged(); got != tt.wantStaged {
+				t.Errorf("isStaged() = %v, want %v", got, tt.wantStaged)
+			}
+			if got := f.isChanged(); got != tt.wantChange {
+				t.Errorf("isChanged() = %v, want %v", got, tt.wantChange)
+			}
+		})
+	}
+}
+
+func TestGitDecoration(t *testing.T) {
+	g := parseGitStatus(`C:\repo`, []string{
+		"## main",
+		" M src/main.go",
+		"?? tmp/",
+		"!! build/",
+	})
+
+	tests := []struct {
+		name  string
+		path  string
+		isDir bool
+		want  string
+	}{
+		{"decoration() marks the changed file", `C:\repo\src\main.go`, false, "M"},
+		{"decoration() marks the directory with the changes", `C:\repo\src`, true, "●"},
+		{"decoration() marks the file in the untracked directory", `C:\repo\tmp\a.go`, false, "U"},
+		{"decoration() marks the file in the ignored directory", `C:\repo\build\out\a.o`, false, "I"},
+		{"decoration() does not mark the clean file", `C:\repo\src\util.go`, false, ""},
+		{"decoration() marks the directory which contains the changes deeply", `C:\repo`, true, "●"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := g.decoration(tt.path, tt.isDir); got != tt.want {
+				t.Errorf("decoration() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	var none *gitStatus
+	if got := none.decoration("/a", false); got != "" {
+		t.Errorf("decoration() = %q, want empty out of a repository", got)
+	}
+}
+
+func TestGitBadges(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []workspaceBadge
+	}{
+		{"gitBadges() shows the branch of the clean repository", []string{"## main"}, []workspaceBadge{{"branch", "⎇ main"}}},
+		{
+			"gitBadges() shows the number of the changes",
+			[]string{"## dev", " M a.go", "!! build/"},
+			[]workspaceBadge{{"branch", "⎇ dev"}, {"changes", "± 1"}},
+		},
+		{"gitBadges() shows nothing out of a repository", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gitBadges(parseGitStatus("/repo", tt.lines)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("gitBadges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
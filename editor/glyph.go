@@ -0,0 +1,459 @@
+package editor
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+)
+
+// Goneovim can draw box-drawing characters, block elements and Powerline
+// separators by itself instead of using the glyphs of the font.
+// The glyphs are drawn to exactly fill the cell given by the CellMetrics,
+// so that they connect seamlessly even if Linespace or Letterspace is set.
+
+const (
+	builtinGlyphBox = 1 << iota
+	builtinGlyphBlock
+	builtinGlyphPowerline
+)
+
+// HlGlyphKey is used in screen cache
+type HlGlyphKey struct {
+	fg   RGBA
+	char rune
+}
+
+// Weight of each arm of the box-drawing characters
+const (
+	boxNone = iota
+	boxLight
+	boxHeavy
+	boxDouble
+)
+
+// boxDrawingArms holds the arms of U+2500 - U+257F in the form of 0xURDL,
+// where each hex digit is the weight of up, right, down and left arm.
+// Dashed lines, arcs and diagonals are zero and handled separately.
+var boxDrawingArms = [0x80]uint16{
+	0x0101, 0x0202, 0x1010, 0x2020, 0, 0, 0, 0, 0, 0, 0, 0, 0x0110, 0x0210, 0x0120, 0x0220, // 2500
+	0x0011, 0x0012, 0x0021, 0x0022, 0x1100, 0x1200, 0x2100, 0x2200, 0x1001, 0x1002, 0x2001, 0x2002, 0x1110, 0x1210, 0x2110, 0x1120, // 2510
+	0x2120, 0x2210, 0x1220, 0x2220, 0x1011, 0x1012, 0x2011, 0x1021, 0x2021, 0x2012, 0x1022, 0x2022, 0x0111, 0x0112, 0x0211, 0x0212, // 2520
+	0x0121, 0x0122, 0x0221, 0x0222, 0x1101, 0x1102, 0x1201, 0x1202, 0x2101, 0x2102, 0x2201, 0x2202, 0x1111, 0x1112, 0x1211, 0x1212, // 2530
+	0x2111, 0x1121, 0x2121, 0x2112, 0x2211, 0x1122, 0x1221, 0x2212, 0x1222, 0x2122, 0x2221, 0x2222, 0, 0, 0, 0, // 2540
+	0x0303, 0x3030, 0x0310, 0x0130, 0x0330, 0x0013, 0x0031, 0x0033, 0x1300, 0x3100, 0x3300, 0x1003, 0x3001, 0x3003, 0x1310, 0x3130, // 2550
+	0x3330, 0x1013, 0x3031, 0x3033, 0x0313, 0x0131, 0x0333, 0x1303, 0x3101, 0x3303, 0x1313, 0x3131, 0x3333, 0, 0, 0, // 2560
+	0, 0, 0, 0, 0x0001, 0x1000, 0x0100, 0x0010, 0x0002, 0x2000, 0x0200, 0x0020, 0x0201, 0x1020, 0x0102, 0x2010, // 2570
+}
+
+// builtinGlyphKind returns the kind of the code point drawn by goneovim itself,
+// or 0 if it is drawn by the font.
+func builtinGlyphKind(r rune) int {
+	switch {
+	case r >= 0x2500 && r <= 0x257F:
+		return builtinGlyphBox
+	case r >= 0x2580 && r <= 0x259F:
+		return builtinGlyphBlock
+	case r >= 0xE0B0 && r <= 0xE0BF:
+		return builtinGlyphPowerline
+	}
+
+	return 0
+}
+
+// parseBuiltinGlyphs returns the kinds of the code points drawn by goneovim
+// itself, which are named in the BuiltinGlyphs config.
+func parseBuiltinGlyphs(ranges []string) (kinds int) {
+	for _, r := range ranges {
+		switch strings.ToLower(r) {
+		case "box":
+			kinds |= builtinGlyphBox
+		case "block":
+			kinds |= builtinGlyphBlock
+		case "powerline":
+			kinds |= builtinGlyphPowerline
+		}
+	}
+
+	return
+}
+
+// builtinGlyph returns the code point of char if goneovim draws it by itself.
+func builtinGlyph(char string) (rune, bool) {
+	return selectBuiltinGlyph(char, editor.config.Editor.builtinGlyphKinds)
+}
+
+// selectBuiltinGlyph returns the code point of char
+// if it is one of the kinds.
+func selectBuiltinGlyph(char string, kinds int) (rune, bool) {
+	if len(char) < 3 || kinds == 0 {
+		return 0, false
+	}
+	r, size := utf8.DecodeRuneInString(char)
+	if size != len(char) {
+		return 0, false
+	}
+	if kinds&builtinGlyphKind(r) == 0 {
+		return 0, false
+	}
+
+	return r, true
+}
+
+func (w *Window) drawBuiltinGlyph(p *gui.QPainter, x, y int, r rune, fg *RGBA) {
+	font := w.getFont()
+
+	if !editor.config.Editor.CachedDrawing {
+		p.Save()
+		p.Translate3(float64(x), float64(y))
+		paintBuiltinGlyph(p, r, font.cellwidth, float64(font.lineHeight), fg)
+		p.Restore()
+		return
+	}
+
+	cache := w.getCache()
+	key := HlGlyphKey{
+		fg:   *fg,
+		char: r,
+	}
+	var image *gui.QImage
+	imagev, err := cache.get(key)
+	if err != nil {
+		image = w.newBuiltinGlyphCache(r, fg)
+		cache.set(key, image)
+	} else {
+		image = imagev.(*gui.QImage)
+	}
+
+	p.DrawImage9(
+		x, y,
+		image,
+		0, 0,
+		-1, -1,
+		core.Qt__AutoColor,
+	)
+}
+
+func (w *Window) newBuiltinGlyphCache(r rune, fg *RGBA) *gui.QImage {
+	font := w.getFont()
+
+	image := gui.NewQImage3(
+		int(math.Ceil(w.devicePixelRatio*font.cellwidth)),
+		int(w.devicePixelRatio*float64(font.lineHeight)),
+		gui.QImage__Format_ARGB32_Premultiplied,
+	)
+	image.SetDevicePixelRatio(w.devicePixelRatio)
+	image.Fill3(core.Qt__transparent)
+
+	pi := gui.NewQPainter2(image)
+	paintBuiltinGlyph(pi, r, font.cellwidth, float64(font.lineHeight), fg)
+	pi.DestroyQPainter()
+
+	return image
+}
+
+// paintBuiltinGlyph draws r into the cell of the given size at the origin.
+func paintBuiltinGlyph(p *gui.QPainter, r rune, width, height float64, fg *RGBA) {
+	color := fg.QColor()
+	light := math.Max(1.0, math.Floor(height/16.0))
+
+	switch builtinGlyphKind(r) {
+	case builtinGlyphBox:
+		paintBoxDrawing(p, r, width, height, light, color)
+	case builtinGlyphBlock:
+		paintBlockElement(p, r, width, height, color)
+	case builtinGlyphPowerline:
+		paintPowerline(p, r, width, height, light, color)
+	}
+}
+
+func paintBoxDrawing(p *gui.QPainter, r rune, width, height, light float64, color *gui.QColor) {
+	heavy := light * 2
+	cx := math.Floor(width / 2)
+	cy := math.Floor(height / 2)
+
+	switch {
+	case r >= 0x2504 && r <= 0x250B:
+		// triple and quadruple dashes
+		n := 3
+		if r >= 0x2508 {
+			n = 4
+		}
+		paintBoxDash(p, r&1 == 1, (r-0x2504)%4 >= 2, n, width, height, light, heavy, color)
+		return
+	case r >= 0x254C && r <= 0x254F:
+		// double dashes
+		paintBoxDash(p, r&1 == 1, r >= 0x254E, 2, width, height, light, heavy, color)
+		return
+	case r >= 0x256D && r <= 0x2570:
+		paintBoxArc(p, r, width, height, light, color)
+		return
+	case r >= 0x2571 && r <= 0x2573:
+		p.SetRenderHint(gui.QPainter__Antialiasing, true)
+		pen := gui.NewQPen3(color)
+		pen.SetWidthF(light)
+		p.SetPen(pen)
+		if r != 0x2572 {
+			p.DrawLine(core.NewQLineF3(width, 0, 0, height))
+		}
+		if r != 0x2571 {
+			p.DrawLine(core.NewQLineF3(0, 0, width, height))
+		}
+		return
+	}
+
+	arms := boxDrawingArms[r-0x2500]
+	up := int(arms>>12) & 0xf
+	right := int(arms>>8) & 0xf
+	down := int(arms>>4) & 0xf
+	left := int(arms) & 0xf
+
+	stroke := func(weight int) float64 {
+		if weight == boxHeavy {
+			return heavy
+		}
+		return light
+	}
+	// extent returns how far an arm reaches beyond the center, to cover the
+	// width of the perpendicular arms and form a square joint.
+	// Against a double line, the arm stops at the nearer of the two lines.
+	extent := func(a, b int) float64 {
+		if a == boxDouble || b == boxDouble {
+			return -light
+		}
+		return math.Max(stroke(a)*float64(min(a, 1)), stroke(b)*float64(min(b, 1))) / 2
+	}
+	// doubleEnd returns where a line of a double arm ends. sameSide is the
+	// perpendicular arm on the side of the line, otherSide is the opposite one.
+	doubleEnd := func(sameSide, otherSide int) float64 {
+		switch {
+		case sameSide == boxDouble:
+			return light
+		case sameSide != boxNone:
+			return 0
+		case otherSide == boxDouble:
+			return -light
+		}
+		return 0
+	}
+
+	fillH := func(x1, x2, y, w float64) {
+		p.FillRect4(core.NewQRectF4(math.Min(x1, x2), y-w/2, math.Abs(x2-x1), w), color)
+	}
+	fillV := func(y1, y2, x, w float64) {
+		p.FillRect4(core.NewQRectF4(x-w/2, math.Min(y1, y2), w, math.Abs(y2-y1)), color)
+	}
+
+	// horizontal arms
+	if right == boxDouble {
+		fillH(width, cx+doubleEnd(up, down), cy-light, light)
+		fillH(width, cx+doubleEnd(down, up), cy+light, light)
+	} else if right != boxNone {
+		fillH(width, cx-extent(up, down), cy, stroke(right))
+	}
+	if left == boxDouble {
+		fillH(0, cx-doubleEnd(up, down), cy-light, light)
+		fillH(0, cx-doubleEnd(down, up), cy+light, light)
+	} else if left != boxNone {
+		fillH(0, cx+extent(up, down), cy, stroke(left))
+	}
+
+	// vertical arms
+	if down == boxDouble {
+		fillV(height, cy+doubleEnd(left, right), cx-light, light)
+		fillV(height, cy+doubleEnd(right, left), cx+light, light)
+	} else if down != boxNone {
+		fillV(height, cy-extent(left, right), cx, stroke(down))
+	}
+	if up == boxDouble {
+		fillV(0, cy-doubleEnd(left, right), cx-light, light)
+		fillV(0, cy-doubleEnd(right, left), cx+light, light)
+	} else if up != boxNone {
+		fillV(0, cy+extent(left, right), cx, stroke(up))
+	}
+}
+
+func paintBoxDash(p *gui.QPainter, isHeavy, isVertical bool, n int, width, height, light, heavy float64, color *gui.QColor) {
+	w := light
+	if isHeavy {
+		w = heavy
+	}
+	length := width
+	if isVertical {
+		length = height
+	}
+	seg := length / float64(n)
+	gap := math.Max(1.0, math.Floor(seg/3))
+	for i := 0; i < n; i++ {
+		start := float64(i)*seg + gap/2
+		if isVertical {
+			p.FillRect4(core.NewQRectF4(math.Floor(width/2)-w/2, start, w, seg-gap), color)
+		} else {
+			p.FillRect4(core.NewQRectF4(start, math.Floor(height/2)-w/2, seg-gap, w), color)
+		}
+	}
+}
+
+func paintBoxArc(p *gui.QPainter, r rune, width, height, light float64, color *gui.QColor) {
+	p.SetRenderHint(gui.QPainter__Antialiasing, true)
+	pen := gui.NewQPen3(color)
+	pen.SetWidthF(light)
+	pen.SetCapStyle(core.Qt__FlatCap)
+	p.SetPen(pen)
+
+	cx := math.Floor(width / 2)
+	cy := math.Floor(height / 2)
+	radius := math.Min(width, height) / 2
+
+	// The arc connects the edges of the two arms through the center.
+	var x1, y1, x2, y2, sx, sy float64
+	switch r {
+	case 0x256D: // ╭ down and right
+		x1, y1, x2, y2, sx, sy = width, cy, cx, height, 1, 1
+	case 0x256E: // ╮ down and left
+		x1, y1, x2, y2, sx, sy = 0, cy, cx, height, -1, 1
+	case 0x256F: // ╯ up and left
+		x1, y1, x2, y2, sx, sy = 0, cy, cx, 0, -1, -1
+	case 0x2570: // ╰ up and right
+		x1, y1, x2, y2, sx, sy = width, cy, cx, 0, 1, -1
+	}
+
+	path := gui.NewQPainterPath()
+	path.MoveTo(core.NewQPointF3(x1, y1))
+	path.LineTo(core.NewQPointF3(cx+sx*radius, cy))
+	path.QuadTo(
+		core.NewQPointF3(cx, cy),
+		core.NewQPointF3(cx, cy+sy*radius),
+	)
+	path.LineTo(core.NewQPointF3(x2, y2))
+	p.StrokePath(path, pen)
+}
+
+func paintBlockElement(p *gui.QPainter, r rune, width, height float64, color *gui.QColor) {
+	fill := func(x, y, w, h float64) {
+		p.FillRect4(core.NewQRectF4(x, y, w, h), color)
+	}
+
+	switch {
+	case r == 0x2580: // ▀
+		fill(0, 0, width, math.Round(height/2))
+	case r >= 0x2581 && r <= 0x2588: // ▁ - █
+		h := math.Round(height * float64(r-0x2580) / 8)
+		fill(0, height-h, width, h)
+	case r >= 0x2589 && r <= 0x258F: // ▉ - ▏
+		w := math.Round(width * float64(0x2590-r) / 8)
+		fill(0, 0, w, height)
+	case r == 0x2590: // ▐
+		x := math.Round(width / 2)
+		fill(x, 0, width-x, height)
+	case r >= 0x2591 && r <= 0x2593: // ░ ▒ ▓
+		shade := gui.NewQColor3(color.Red(), color.Green(), color.Blue(), int(255*float64(r-0x2590)/4))
+		p.FillRect4(core.NewQRectF4(0, 0, width, height), shade)
+	case r == 0x2594: // ▔
+		fill(0, 0, width, math.Round(height/8))
+	case r == 0x2595: // ▕
+		w := math.Round(width / 8)
+		fill(width-w, 0, w, height)
+	default: // quadrants
+		quadrants := map[rune]int{
+			0x2596: 4, 0x2597: 8, 0x2598: 1, 0x2599: 1 | 4 | 8,
+			0x259A: 1 | 8, 0x259B: 1 | 2 | 4, 0x259C: 1 | 2 | 8, 0x259D: 2,
+			0x259E: 2 | 4, 0x259F: 2 | 4 | 8,
+		}
+		q := quadrants[r]
+		hw := math.Round(width / 2)
+		hh := math.Round(height / 2)
+		if q&1 != 0 {
+			fill(0, 0, hw, hh)
+		}
+		if q&2 != 0 {
+			fill(hw, 0, width-hw, hh)
+		}
+		if q&4 != 0 {
+			fill(0, hh, hw, height-hh)
+		}
+		if q&8 != 0 {
+			fill(hw, hh, width-hw, height-hh)
+		}
+	}
+}
+
+func paintPowerline(p *gui.QPainter, r rune, width, height, light float64, color *gui.QColor) {
+	p.SetRenderHint(gui.QPainter__Antialiasing, true)
+	brush := gui.NewQBrush3(color, core.Qt__SolidPattern)
+	pen := gui.NewQPen3(color)
+	pen.SetWidthF(light)
+
+	path := gui.NewQPainterPath()
+	solid := true
+
+	switch r {
+	case 0xE0B0: // right-pointing triangle
+		path.MoveTo(core.NewQPointF3(0, 0))
+		path.LineTo(core.NewQPointF3(width, height/2))
+		path.LineTo(core.NewQPointF3(0, height))
+	case 0xE0B1: // right-pointing chevron
+		path.MoveTo(core.NewQPointF3(0, 0))
+		path.LineTo(core.NewQPointF3(width, height/2))
+		path.LineTo(core.NewQPointF3(0, height))
+		solid = false
+	case 0xE0B2: // left-pointing triangle
+		path.MoveTo(core.NewQPointF3(width, 0))
+		path.LineTo(core.NewQPointF3(0, height/2))
+		path.LineTo(core.NewQPointF3(width, height))
+	case 0xE0B3: // left-pointing chevron
+		path.MoveTo(core.NewQPointF3(width, 0))
+		path.LineTo(core.NewQPointF3(0, height/2))
+		path.LineTo(core.NewQPointF3(width, height))
+		solid = false
+	case 0xE0B4, 0xE0B5: // right half circle
+		path.MoveTo(core.NewQPointF3(0, 0))
+		path.CubicTo(
+			core.NewQPointF3(width*4/3, 0),
+			core.NewQPointF3(width*4/3, height),
+			core.NewQPointF3(0, height),
+		)
+		solid = r == 0xE0B4
+	case 0xE0B6, 0xE0B7: // left half circle
+		path.MoveTo(core.NewQPointF3(width, 0))
+		path.CubicTo(
+			core.NewQPointF3(-width/3, 0),
+			core.NewQPointF3(-width/3, height),
+			core.NewQPointF3(width, height),
+		)
+		solid = r == 0xE0B6
+	case 0xE0B8: // lower left triangle
+		path.MoveTo(core.NewQPointF3(0, 0))
+		path.LineTo(core.NewQPointF3(width, height))
+		path.LineTo(core.NewQPointF3(0, height))
+	case 0xE0BA: // lower right triangle
+		path.MoveTo(core.NewQPointF3(width, 0))
+		path.LineTo(core.NewQPointF3(width, height))
+		path.LineTo(core.NewQPointF3(0, height))
+	case 0xE0BC: // upper left triangle
+		path.MoveTo(core.NewQPointF3(0, 0))
+		path.LineTo(core.NewQPointF3(width, 0))
+		path.LineTo(core.NewQPointF3(0, height))
+	case 0xE0BE: // upper right triangle
+		path.MoveTo(core.NewQPointF3(0, 0))
+		path.LineTo(core.NewQPointF3(width, 0))
+		path.LineTo(core.NewQPointF3(width, height))
+	case 0xE0B9, 0xE0BF: // backslash separator
+		path.MoveTo(core.NewQPointF3(0, 0))
+		path.LineTo(core.NewQPointF3(width, height))
+		solid = false
+	case 0xE0BB, 0xE0BD: // forward slash separator
+		path.MoveTo(core.NewQPointF3(0, height))
+		path.LineTo(core.NewQPointF3(width, 0))
+		solid = false
+	}
+
+	if solid {
+		path.CloseSubpath()
+		p.FillPath(path, brush)
+	} else {
+		p.StrokePath(path, pen)
+	}
+}
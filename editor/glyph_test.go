@@ -0,0 +1,48 @@
+package editor
+
+import "testing"
+
+func TestParseBuiltinGlyphs(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranges []string
+		want   int
+	}{
+		{"parseBuiltinGlyphs() returns nothing for no ranges", nil, 0},
+		{"parseBuiltinGlyphs() parses the ranges in any case", []string{"Box", "powerline"}, builtinGlyphBox | builtinGlyphPowerline},
+		{"parseBuiltinGlyphs() ignores the unknown ranges", []string{"block", "braille"}, builtinGlyphBlock},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseBuiltinGlyphs(tt.ranges); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectBuiltinGlyph(t *testing.T) {
+	kinds := builtinGlyphBox | builtinGlyphPowerline
+	tests := []struct {
+		name   string
+		char   string
+		kinds  int
+		want   rune
+		wantOk bool
+	}{
+		{"selectBuiltinGlyph() selects the box-drawing character", "─", kinds, '─', true},
+		{"selectBuiltinGlyph() selects the Powerline separator", "\ue0b0", kinds, 0xE0B0, true},
+		{"selectBuiltinGlyph() skips the kind which is not enabled", "█", kinds, 0, false},
+		{"selectBuiltinGlyph() skips the other character", "a", kinds, 0, false},
+		{"selectBuiltinGlyph() skips the character with a combining mark", "─\u0301", kinds, 0, false},
+		{"selectBuiltinGlyph() skips everything without the kinds", "─", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := selectBuiltinGlyph(tt.char, tt.kinds)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("got %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
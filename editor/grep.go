@@ -0,0 +1,371 @@
+package editor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// grepLine is a matched line, or a context line around it.
+type grepLine struct {
+	num     int
+	text    string
+	spans   [][2]int
+	context bool
+}
+
+// grepFile is a file which has the matched lines.
+type grepFile struct {
+	path  string
+	lines []grepLine
+}
+
+// matches returns the number of the matched lines of the file.
+func (f *grepFile) matches() int {
+	n := 0
+	for _, l := range f.lines {
+		if !l.context {
+			n++
+		}
+	}
+
+	return n
+}
+
+// grepOptions is the options of the search of GonvimGrep.
+type grepOptions struct {
+	regex         bool
+	caseSensitive bool
+	context       int
+	max           int
+}
+
+// rgArgs returns the command line of ripgrep which searches the pattern in
+// the cwd. The results are printed as JSON lines. The maximum number of the
+// matches is not given, since --max-count of ripgrep limits each file rather
+// than the total, which is counted by the panel instead.
+func rgArgs(pattern string, opts grepOptions) []string {
+	args := []string{"rg", "--json", "--max-columns", "1000", "-C", strconv.Itoa(opts.context)}
+	if !opts.regex {
+		args = append(args, "--fixed-strings")
+	}
+	if opts.caseSensitive {
+		args = append(args, "--case-sensitive")
+	} else {
+		args = append(args, "--smart-case")
+	}
+
+	// The path is given explicitly, or ripgrep searches its stdin.
+	return append(args, "--", pattern, ".")
+}
+
+// grepRegexp compiles the pattern for the search without ripgrep and for
+// the replacement. It is case-insensitive unless the pattern has an upper
+// case letter, as --smart-case of ripgrep.
+func grepRegexp(pattern string, opts grepOptions) (*regexp.Regexp, error) {
+	if !opts.regex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if !opts.caseSensitive && strings.IndexFunc(pattern, unicode.IsUpper) < 0 {
+		pattern = "(?i)" + pattern
+	}
+
+	return regexp.Compile(pattern)
+}
+
+// rgText is a text in the output of ripgrep, which is in bytes
+// if it is not valid UTF-8.
+type rgText struct {
+	Text  *string `json:"text"`
+	Bytes string  `json:"bytes"`
+}
+
+type rgMessage struct {
+	Type string `json:"type"`
+	Data struct {
+		Path       rgText `json:"path"`
+		Lines      rgText `json:"lines"`
+		LineNumber int    `json:"line_number"`
+		Submatches []struct {
+			Start int `json:"start"`
+			End   int `json:"end"`
+		} `json:"submatches"`
+	} `json:"data"`
+}
+
+// grepResults gathers the results of ripgrep.
+type grepResults struct {
+	current *grepFile
+}
+
+// addRg adds a JSON line printed by ripgrep, and returns the file
+// when all the lines of the file are added.
+func (r *grepResults) addRg(line string) *grepFile {
+	var msg rgMessage
+	if err := json.Unmarshal([]byte(line), &msg); err != nil {
+		return nil
+	}
+	// The files whose path is not valid UTF-8 cannot be opened by the path.
+	if msg.Data.Path.Text == nil {
+		return nil
+	}
+	switch msg.Type {
+	case "begin":
+		r.current = &grepFile{path: strings.TrimPrefix(*msg.Data.Path.Text, "./")}
+	case "match", "context":
+		if r.current == nil || msg.Data.Lines.Text == nil {
+			return nil
+		}
+		l := grepLine{
+			num:     msg.Data.LineNumber,
+			text:    strings.TrimRight(*msg.Data.Lines.Text, "\r\n"),
+			context: msg.Type == "context",
+		}
+		for _, s := range msg.Data.Submatches {
+			if s.End <= len(l.text) {
+				l.spans = append(l.spans, [2]int{s.Start, s.End})
+			}
+		}
+		r.current.lines = append(r.current.lines, l)
+	case "end":
+		f := r.current
+		r.current = nil
+		if f != nil && f.matches() > 0 {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// grepContent searches the lines of content with re, and returns the
+// matched lines with context lines around them, up to max matched lines.
+func grepContent(content []byte, re *regexp.Regexp, context, max int) []grepLine {
+	lines := strings.Split(string(content), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var result []grepLine
+	last := -1
+	matched := 0
+	for i, text := range lines {
+		text = strings.TrimRight(text, "\r")
+		spans := re.FindAllStringIndex(text, -1)
+		if len(spans) == 0 {
+			continue
+		}
+		start := i - context
+		if start <= last {
+			start = last + 1
+		}
+		if start < 0 {
+			start = 0
+		}
+		for j := start; j < i; j++ {
+			result = append(result, grepLine{num: j + 1, text: strings.TrimRight(lines[j], "\r"), context: true})
+		}
+		l := grepLine{num: i + 1, text: text}
+		for _, s := range spans {
+			l.spans = append(l.spans, [2]int{s[0], s[1]})
+		}
+		result = append(result, l)
+		last = i
+		matched++
+
+		// The context lines after the match, which may be matched lines.
+		end := i + context
+		if end >= len(lines) {
+			end = len(lines) - 1
+		}
+		for j := i + 1; j <= end; j++ {
+			next := strings.TrimRight(lines[j], "\r")
+			if re.MatchString(next) {
+				break
+			}
+			result = append(result, grepLine{num: j + 1, text: next, context: true})
+			last = j
+		}
+		if max > 0 && matched >= max {
+			break
+		}
+	}
+
+	return result
+}
+
+// isBinary reports whether the content looks like a binary file,
+// as ripgrep does.
+func isBinary(content []byte) bool {
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+// ignoreRule is a pattern of a .gitignore file.
+type ignoreRule struct {
+	base     string
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// parseIgnore parses the .gitignore file in the directory base, which is
+// the slash separated path relative to the searched root.
+func parseIgnore(base string, content []byte) []ignoreRule {
+	var rules []ignoreRule
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, " \r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{base: base}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, "\\") {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// isIgnored reports whether the slash separated path rel is ignored by
+// rules. The last matched rule wins, as git does.
+func isIgnored(rules []ignoreRule, rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		sub := rel
+		if rule.base != "" {
+			if !strings.HasPrefix(rel, rule.base+"/") {
+				continue
+			}
+			sub = rel[len(rule.base)+1:]
+		}
+		if !rule.anchored {
+			sub = path.Base(sub)
+		}
+		if matchGlob(strings.Split(rule.pattern, "/"), strings.Split(sub, "/")) {
+			ignored = !rule.negate
+		}
+	}
+
+	return ignored
+}
+
+// matchGlob reports whether the path elements match the pattern elements,
+// in which "**" matches any number of the elements.
+func matchGlob(pattern, elems []string) bool {
+	if len(pattern) == 0 {
+		return len(elems) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(elems); i++ {
+			if matchGlob(pattern[1:], elems[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(elems) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], elems[0]); !ok {
+		return false
+	}
+
+	return matchGlob(pattern[1:], elems[1:])
+}
+
+// grepDir searches the files under root with re without ripgrep. The hidden
+// files and directories, and the files ignored by the .gitignore files are
+// skipped, as ripgrep does. It calls found for each file which has the
+// matched lines, and stops walking when found returns false or ctx is
+// canceled.
+func grepDir(ctx context.Context, root string, re *regexp.Regexp, opts grepOptions, found func(f *grepFile) bool) {
+	var rules []ignoreRule
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return filepath.SkipAll
+		}
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			rel = p
+		}
+		rel = filepath.ToSlash(rel)
+		if p != root {
+			if strings.HasPrefix(d.Name(), ".") || isIgnored(rules, rel, d.IsDir()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		if d.IsDir() {
+			if content, err := os.ReadFile(filepath.Join(p, ".gitignore")); err == nil {
+				base := rel
+				if p == root {
+					base = ""
+				}
+				rules = append(rules, parseIgnore(base, content)...)
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil || isBinary(content) {
+			return nil
+		}
+		lines := grepContent(content, re, opts.context, opts.max)
+		if len(lines) == 0 {
+			return nil
+		}
+		if !found(&grepFile{path: rel, lines: lines}) {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+}
+
+// replaceLine returns the line in which the matches of re are replaced.
+// $1 and so on in repl are expanded in the regex mode.
+func replaceLine(re *regexp.Regexp, text, repl string, regex bool) string {
+	if regex {
+		return re.ReplaceAllString(text, repl)
+	}
+
+	return re.ReplaceAllLiteralString(text, repl)
+}
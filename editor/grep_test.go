@@ -0,0 +1,285 @@
+package editor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestRgArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		opts    grepOptions
+		want    []string
+	}{
+		{
+			"rgArgs() searches the literal string with smart case",
+			"-foo",
+			grepOptions{context: 1, max: 100},
+			[]string{"rg", "--json", "--max-columns", "1000", "-C", "1", "--fixed-strings", "--smart-case", "--", "-foo", "."},
+		},
+		{
+			"rgArgs() searches the regular expression with case sensitive",
+			"fo+",
+			grepOptions{regex: true, caseSensitive: true},
+			[]string{"rg", "--json", "--max-columns", "1000", "-C", "0", "--case-sensitive", "--", "fo+", "."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rgArgs(tt.pattern, tt.opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrepRegexp(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		opts    grepOptions
+		text    string
+		want    bool
+	}{
+		{
+			"grepRegexp() quotes the literal string",
+			"a.b",
+			grepOptions{},
+			"axb",
+			false,
+		},
+		{
+			"grepRegexp() is case-insensitive without an upper case letter",
+			"foo",
+			grepOptions{},
+			"FOO",
+			true,
+		},
+		{
+			"grepRegexp() is case-sensitive with an upper case letter",
+			"Foo",
+			grepOptions{},
+			"foo",
+			false,
+		},
+		{
+			"grepRegexp() is case-sensitive if the option is set",
+			"foo",
+			grepOptions{caseSensitive: true},
+			"FOO",
+			false,
+		},
+		{
+			"grepRegexp() compiles the regular expression",
+			"a.b",
+			grepOptions{regex: true},
+			"axb",
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re, err := grepRegexp(tt.pattern, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := re.MatchString(tt.text); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrepResultsAddRg(t *testing.T) {
+	lines := []string{
+		`{"type":"begin","data":{"path":{"text":"./a.go"}}}`,
+		`{"type":"context","data":{"path":{"text":"./a.go"},"lines":{"text":"package a\n"},"line_number":1,"submatches":[]}}`,
+		`{"type":"match","data":{"path":{"text":"./a.go"},"lines":{"text":"func foo() {}\r\n"},"line_number":2,"submatches":[{"match":{"text":"foo"},"start":5,"end":8}]}}`,
+		`{"type":"end","data":{"path":{"text":"./a.go"}}}`,
+		`{"type":"begin","data":{"path":{"bytes":"/w=="}}}`,
+		`{"type":"end","data":{"path":{"bytes":"/w=="}}}`,
+		`{"type":"summary","data":{}}`,
+	}
+	want := []*grepFile{
+		{
+			path: "a.go",
+			lines: []grepLine{
+				{num: 1, text: "package a", context: true},
+				{num: 2, text: "func foo() {}", spans: [][2]int{{5, 8}}},
+			},
+		},
+	}
+
+	var r grepResults
+	var got []*grepFile
+	for _, line := range lines {
+		if f := r.addRg(line); f != nil {
+			got = append(got, f)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("addRg() got %+v, want %+v", got, want)
+	}
+}
+
+func TestGrepContent(t *testing.T) {
+	content := []byte("a\nfoo\nb\nc\nfoo foo\nfoo\nd\n")
+	re := regexp.MustCompile("foo")
+
+	tests := []struct {
+		name    string
+		context int
+		max     int
+		want    []grepLine
+	}{
+		{
+			"grepContent() returns the matched lines",
+			0,
+			0,
+			[]grepLine{
+				{num: 2, text: "foo", spans: [][2]int{{0, 3}}},
+				{num: 5, text: "foo foo", spans: [][2]int{{0, 3}, {4, 7}}},
+				{num: 6, text: "foo", spans: [][2]int{{0, 3}}},
+			},
+		},
+		{
+			"grepContent() merges the context lines",
+			1,
+			0,
+			[]grepLine{
+				{num: 1, text: "a", context: true},
+				{num: 2, text: "foo", spans: [][2]int{{0, 3}}},
+				{num: 3, text: "b", context: true},
+				{num: 4, text: "c", context: true},
+				{num: 5, text: "foo foo", spans: [][2]int{{0, 3}, {4, 7}}},
+				{num: 6, text: "foo", spans: [][2]int{{0, 3}}},
+				{num: 7, text: "d", context: true},
+			},
+		},
+		{
+			"grepContent() stops at max",
+			1,
+			1,
+			[]grepLine{
+				{num: 1, text: "a", context: true},
+				{num: 2, text: "foo", spans: [][2]int{{0, 3}}},
+				{num: 3, text: "b", context: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := grepContent(content, re, tt.context, tt.max); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsIgnored(t *testing.T) {
+	rules := append(parseIgnore("", []byte("# comment\n*.log\n!keep.log\n/build\ntmp/\ndocs/**/*.html\n")),
+		parseIgnore("sub", []byte("*.txt\n/only\n"))...)
+
+	tests := []struct {
+		name  string
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"isIgnored() matches the name in any directory", "a/b/x.log", false, true},
+		{"isIgnored() lets the later negation win", "a/keep.log", false, false},
+		{"isIgnored() anchors the pattern with the leading slash", "build", true, true},
+		{"isIgnored() does not match the anchored pattern below", "a/build", true, false},
+		{"isIgnored() matches the directory pattern only to directories", "tmp", false, false},
+		{"isIgnored() matches the directory pattern", "a/tmp", true, true},
+		{"isIgnored() matches ** to any directories", "docs/a/b/x.html", false, true},
+		{"isIgnored() matches ** to no directory", "docs/x.html", false, true},
+		{"isIgnored() applies the rules of a directory below it", "sub/a/x.txt", false, true},
+		{"isIgnored() does not apply the rules of a directory outside it", "x.txt", false, false},
+		{"isIgnored() anchors the pattern to the directory of .gitignore", "sub/only", false, true},
+		{"isIgnored() keeps the other files", "main.go", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isIgnored(rules, tt.rel, tt.isDir); got != tt.want {
+				t.Errorf("isIgnored(%q) = %v, want %v", tt.rel, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrepDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".gitignore":       "*.log\nvendor/\n",
+		"main.go":          "foo",
+		"debug.log":        "foo",
+		"vendor/a.go":      "foo",
+		".hidden/a.go":     "foo",
+		"sub/.gitignore":   "a.go\n",
+		"sub/a.go":         "foo",
+		"sub/b.go":         "foo",
+		"other/a.go":       "foo",
+		"other/nomatch.go": "bar",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	grepDir(context.Background(), dir, regexp.MustCompile("foo"), grepOptions{}, func(f *grepFile) bool {
+		got = append(got, f.path)
+		return true
+	})
+	want := []string{"main.go", "other/a.go", "sub/b.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("grepDir() = %v, want %v", got, want)
+	}
+}
+
+func TestReplaceLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		regex   bool
+		repl    string
+		want    string
+	}{
+		{
+			"replaceLine() replaces the literal string",
+			"a.b",
+			false,
+			"$1",
+			"x $1 axb",
+		},
+		{
+			"replaceLine() expands the groups of the regular expression",
+			`(\w)\.(\w)`,
+			true,
+			"$2.$1",
+			"x b.a axb",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re, err := grepRegexp(tt.pattern, grepOptions{regex: tt.regex, caseSensitive: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := replaceLine(re, "x a.b axb", tt.repl, tt.regex); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
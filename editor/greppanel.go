@@ -0,0 +1,600 @@
+package editor
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+	"github.com/akiyosi/qt/widgets"
+)
+
+// The roles of the data stored in the rows of the results of GonvimGrep.
+const (
+	grepPathRole = int(core.Qt__UserRole)
+	grepLineRole = int(core.Qt__UserRole) + 1
+	grepColRole  = int(core.Qt__UserRole) + 2
+)
+
+// GrepPanel is the panel of GonvimGrep, which searches the files under
+// the cwd as the pattern is typed. The search runs ripgrep through nvim,
+// so that the remote files are searched for the remote nvim. Without
+// ripgrep, the local files are searched in a goroutine.
+type GrepPanel struct {
+	ws         *Workspace
+	widget     *widgets.QWidget
+	input      *widgets.QLineEdit
+	replace    *widgets.QLineEdit
+	status     *widgets.QLabel
+	results    *widgets.QListWidget
+	timer      *core.QTimer
+	opts       grepOptions
+	id         int
+	cancel     context.CancelFunc
+	root       string
+	rg         grepResults
+	files      []*grepFile
+	matches    int
+	done       bool
+	confirming bool
+	hidden     bool
+}
+
+func newGrepPanel(ws *Workspace) *GrepPanel {
+	widget := widgets.NewQWidget(ws.widget, 0)
+	widget.SetContentsMargins(1, 1, 1, 1)
+	widget.SetObjectName("grep")
+	widget.SetGraphicsEffect(util.DropShadow(0, 15, 130, 120))
+	layout := widgets.NewQVBoxLayout()
+	layout.SetContentsMargins(8, 8, 8, 8)
+	layout.SetSpacing(4)
+	widget.SetLayout(layout)
+
+	input := widgets.NewQLineEdit(nil)
+	input.SetFrame(false)
+	input.SetPlaceholderText("Search")
+	replace := widgets.NewQLineEdit(nil)
+	replace.SetFrame(false)
+	replace.SetPlaceholderText("Replace")
+	replace.Hide()
+	status := widgets.NewQLabel(nil, 0)
+	status.SetTextFormat(core.Qt__RichText)
+
+	results := widgets.NewQListWidget(nil)
+	results.SetFocusPolicy(core.Qt__NoFocus)
+	results.SetFrameShape(widgets.QFrame__NoFrame)
+	results.SetHorizontalScrollBarPolicy(core.Qt__ScrollBarAlwaysOff)
+
+	layout.AddWidget(input, 0, 0)
+	layout.AddWidget(replace, 0, 0)
+	layout.AddWidget(status, 0, 0)
+	layout.AddWidget(results, 1, 0)
+
+	timer := core.NewQTimer(nil)
+	timer.SetSingleShot(true)
+
+	g := &GrepPanel{
+		ws:      ws,
+		widget:  widget,
+		input:   input,
+		replace: replace,
+		status:  status,
+		results: results,
+		timer:   timer,
+		opts: grepOptions{
+			context: editor.config.Grep.ContextLines,
+			max:     editor.config.Grep.MaxMatches,
+		},
+	}
+	timer.ConnectTimeout(g.search)
+	input.ConnectTextChanged(func(string) {
+		g.timer.Start(150)
+	})
+	replace.ConnectTextChanged(func(string) {
+		g.confirming = false
+		g.render()
+	})
+	input.ConnectKeyPressEvent(func(event *gui.QKeyEvent) {
+		g.keyPress(input, event)
+	})
+	replace.ConnectKeyPressEvent(func(event *gui.QKeyEvent) {
+		g.keyPress(replace, event)
+	})
+	results.ConnectItemDoubleClicked(func(item *widgets.QListWidgetItem) {
+		g.open(results.Row(item))
+	})
+	widget.Hide()
+	g.hidden = true
+
+	return g
+}
+
+// openGrep shows the panel of GonvimGrep with the pattern.
+func (ws *Workspace) openGrep(pattern string) {
+	if ws.grep == nil {
+		ws.grep = newGrepPanel(ws)
+	}
+	g := ws.grep
+	g.hidden = false
+	g.confirming = false
+	g.setColor()
+	g.resize()
+	g.widget.Raise()
+	g.widget.Show()
+	g.input.SetFocus2()
+	if pattern != "" {
+		g.input.SetText(pattern)
+	}
+	g.input.SelectAll()
+	g.search()
+}
+
+func (g *GrepPanel) hide() {
+	if g == nil || g.hidden {
+		return
+	}
+	g.hidden = true
+	g.stop()
+	g.widget.Hide()
+	g.ws.widget.SetFocus2()
+}
+
+func (g *GrepPanel) setColor() {
+	fg := editor.colors.widgetFg
+	bg := editor.colors.widgetBg
+	if fg == nil || bg == nil {
+		return
+	}
+	g.widget.SetStyleSheet(fmt.Sprintf(`
+		#grep { background-color: %s; }
+		* { color: %s; background-color: rgba(0, 0, 0, 0); }
+		QListWidget::item:selected { background-color: %s; }`,
+		bg.String(), fg.String(), editor.colors.selectedBg.String(),
+	))
+	g.widget.SetFont(g.ws.screen.font.qfont)
+}
+
+func (g *GrepPanel) resize() {
+	if g == nil || g.hidden {
+		return
+	}
+	width := int(math.Trunc(float64(g.ws.width) * 0.8))
+	height := int(math.Trunc(float64(g.ws.height) * 0.7))
+	g.widget.SetFixedSize2(width, height)
+	g.widget.Move2((g.ws.width-width)/2, 10)
+}
+
+// stop stops the search in progress.
+func (g *GrepPanel) stop() {
+	g.id++
+	g.cancelLocal()
+	go g.ws.nvim.ExecLua(`goneovim.grep_stop()`, nil)
+}
+
+// cancelLocal stops the search without ripgrep in progress.
+func (g *GrepPanel) cancelLocal() {
+	if g.cancel != nil {
+		g.cancel()
+		g.cancel = nil
+	}
+}
+
+// limited reports whether the search has stopped at the maximum number of
+// the matches, so that the results may not have all the matches.
+func (g *GrepPanel) limited() bool {
+	return g.opts.max > 0 && g.matches >= g.opts.max
+}
+
+// search starts the search of the pattern, and clears the results.
+func (g *GrepPanel) search() {
+	// goneovim.grep stops the previous search by itself.
+	g.id++
+	g.cancelLocal()
+	g.files = nil
+	g.matches = 0
+	g.done = false
+	g.confirming = false
+	g.rg = grepResults{}
+	g.results.Clear()
+
+	pattern := g.input.Text()
+	if pattern == "" {
+		g.done = true
+		g.updateStatus("")
+		return
+	}
+	if g.opts.regex {
+		if _, err := regexp.Compile(pattern); err != nil {
+			g.done = true
+			g.updateStatus("invalid pattern")
+			return
+		}
+	}
+	g.updateStatus("")
+
+	id := g.id
+	opts := g.opts
+	ws := g.ws
+	go func() {
+		var found bool
+		if err := ws.nvim.ExecLua(`return goneovim.grep(...)`, &found, id, rgArgs(pattern, opts)); err != nil {
+			return
+		}
+		if !found {
+			ws.postGui("gonvim_grep_local", id, pattern)
+		}
+	}()
+}
+
+// searchLocal searches the local files without ripgrep, which is possible
+// only when nvim runs on this machine.
+func (g *GrepPanel) searchLocal(id int, pattern string) {
+	if id != g.id {
+		return
+	}
+	if editor.opts.Server != "" || editor.opts.Ssh != "" || editor.opts.Wsl != nil || editor.config.Editor.UseWSL {
+		g.done = true
+		g.updateStatus("ripgrep is not found in the remote host")
+		return
+	}
+	re, err := grepRegexp(pattern, g.opts)
+	if err != nil {
+		g.done = true
+		g.updateStatus("invalid pattern")
+		return
+	}
+	root := g.ws.cwd
+	g.root = root
+	opts := g.opts
+	ctx, cancel := context.WithCancel(context.Background())
+	g.cancel = cancel
+	go func() {
+		var batch []*grepFile
+		matches := 0
+		grepDir(ctx, root, re, opts, func(f *grepFile) bool {
+			batch = append(batch, f)
+			matches += f.matches()
+			if len(batch) >= 20 {
+				g.ws.postGui("gonvim_grep_files", id, batch, false)
+				batch = nil
+			}
+			return opts.max <= 0 || matches < opts.max
+		})
+		g.ws.postGui("gonvim_grep_files", id, batch, true)
+	}()
+}
+
+// addRgLines handles the JSON lines which ripgrep prints for the search.
+func (g *GrepPanel) addRgLines(id int, root string, lines []interface{}, done bool) {
+	if id != g.id {
+		return
+	}
+	g.root = root
+	var files []*grepFile
+	for _, l := range lines {
+		line, _ := l.(string)
+		if f := g.rg.addRg(line); f != nil {
+			files = append(files, f)
+		}
+	}
+	g.addFiles(id, files, done)
+}
+
+func (g *GrepPanel) addFiles(id int, files []*grepFile, done bool) {
+	if id != g.id {
+		return
+	}
+	for _, f := range files {
+		g.files = append(g.files, f)
+		g.matches += f.matches()
+		if !g.confirming {
+			g.appendFile(f)
+		}
+	}
+	g.done = done
+	if g.limited() && !done {
+		g.stop()
+		g.done = true
+	}
+	if g.results.CurrentRow() < 0 {
+		g.moveSelected(1)
+	}
+	g.updateStatus("")
+}
+
+func (g *GrepPanel) updateStatus(message string) {
+	flags := ""
+	if g.opts.regex {
+		flags += " [.*]"
+	}
+	if g.opts.caseSensitive {
+		flags += " [Aa]"
+	}
+	text := fmt.Sprintf("%d matches in %d files", g.matches, len(g.files))
+	if !g.done {
+		text += " …"
+	}
+	if g.limited() {
+		text += " (limited)"
+	}
+	if message != "" {
+		text = message
+	}
+	if g.confirming {
+		text = fmt.Sprintf("Replace %d matches in %d files? &lt;Enter&gt; to apply, &lt;Esc&gt; to cancel", g.matches, len(g.files))
+	}
+	g.status.SetText(html.EscapeString(flags) + " " + strings.TrimSpace(text))
+}
+
+// render shows all the results again, for the change of the replacement.
+func (g *GrepPanel) render() {
+	row := g.results.CurrentRow()
+	g.results.Clear()
+	for _, f := range g.files {
+		g.appendFile(f)
+	}
+	if row >= 0 && row < g.results.Count() {
+		g.results.SetCurrentRow(row)
+	} else {
+		g.moveSelected(1)
+	}
+	g.updateStatus("")
+}
+
+// appendFile adds the rows of the file, which are the path and the lines.
+func (g *GrepPanel) appendFile(f *grepFile) {
+	g.addRow(fmt.Sprintf(
+		"<b>%s</b> <font color='%s'>%d</font>",
+		html.EscapeString(f.path), editor.colors.inactiveFg.Hex(), f.matches(),
+	), f.path, 0, 0)
+
+	var re *regexp.Regexp
+	repl := g.replace.Text()
+	if g.replace.IsVisible() && repl != "" {
+		re, _ = grepRegexp(g.input.Text(), g.opts)
+	}
+	for _, l := range f.lines {
+		if g.confirming && l.context {
+			continue
+		}
+		col := 0
+		if len(l.spans) > 0 {
+			col = l.spans[0][0]
+		}
+		text := g.formatLine(l, re, repl)
+		g.addRow(text, f.path, l.num, col+1)
+	}
+}
+
+// formatLine returns the rich text of the line, which shows the matches,
+// and the replacement if re is given.
+func (g *GrepPanel) formatLine(l grepLine, re *regexp.Regexp, repl string) string {
+	dim := editor.colors.inactiveFg.Hex()
+	match := editor.colors.matchFg.Hex()
+	num := fmt.Sprintf("<font color='%s'>%5d </font>", dim, l.num)
+	if l.context {
+		return num + fmt.Sprintf("<font color='%s'>%s</font>", dim, preText(l.text))
+	}
+	if re != nil {
+		replaced := replaceLine(re, l.text, repl, g.opts.regex)
+		return num + fmt.Sprintf(
+			"<s><font color='#e53935'>%s</font></s><br>%s<font color='#9ccc65'>%s</font>",
+			preText(l.text), strings.Repeat("&nbsp;", 6), preText(replaced),
+		)
+	}
+
+	text := ""
+	last := 0
+	for _, s := range l.spans {
+		if s[0] < last || s[1] > len(l.text) {
+			continue
+		}
+		text += preText(l.text[last:s[0]])
+		text += fmt.Sprintf("<font color='%s'><b>%s</b></font>", match, preText(l.text[s[0]:s[1]]))
+		last = s[1]
+	}
+	text += preText(l.text[last:])
+
+	return num + text
+}
+
+// preText escapes the text keeping the spaces.
+func preText(text string) string {
+	text = strings.ReplaceAll(text, "\t", "    ")
+	text = html.EscapeString(text)
+
+	return strings.ReplaceAll(text, " ", "&nbsp;")
+}
+
+func (g *GrepPanel) addRow(text, path string, line, col int) {
+	item := widgets.NewQListWidgetItem(g.results, 0)
+	item.SetData(grepPathRole, core.NewQVariant15(path))
+	item.SetData(grepLineRole, core.NewQVariant1(line))
+	item.SetData(grepColRole, core.NewQVariant1(col))
+	label := widgets.NewQLabel(nil, 0)
+	label.SetTextFormat(core.Qt__RichText)
+	label.SetText(text)
+	label.SetContentsMargins(4, 1, 4, 1)
+	item.SetSizeHint(label.SizeHint())
+	g.results.SetItemWidget(item, label)
+}
+
+// moveSelected selects the next matched line in the direction.
+func (g *GrepPanel) moveSelected(delta int) {
+	n := g.results.Count()
+	if n == 0 {
+		return
+	}
+	row := g.results.CurrentRow()
+	for i := 0; i < n; i++ {
+		row = (row + delta + n) % n
+		if g.results.Item(row).Data(grepColRole).ToInt(nil) > 0 {
+			g.results.SetCurrentRow(row)
+			return
+		}
+	}
+}
+
+// open opens the file of the row at the line.
+func (g *GrepPanel) open(row int) {
+	item := g.results.Item(row)
+	if item == nil || item.Pointer() == nil {
+		return
+	}
+	path := item.Data(grepPathRole).ToString()
+	line := item.Data(grepLineRole).ToInt(nil)
+	col := item.Data(grepColRole).ToInt(nil)
+	if line < 1 {
+		line = 1
+	}
+	if col < 1 {
+		col = 1
+	}
+	file := g.root + "/" + path
+	g.hide()
+	go g.ws.nvim.Command(fmt.Sprintf("execute %q fnameescape(%q) | call cursor(%d, %d)", "edit", file, line, col))
+}
+
+// apply replaces the matches through nvim, which edits the buffers of the
+// files so that the replacement can be undone. It is refused if the results
+// are limited, since the matches which are not listed would be left.
+func (g *GrepPanel) apply() {
+	if g.limited() {
+		return
+	}
+	re, err := grepRegexp(g.input.Text(), g.opts)
+	if err != nil {
+		return
+	}
+	repl := g.replace.Text()
+	changes := make(map[string][][]interface{})
+	for _, f := range g.files {
+		for _, l := range f.lines {
+			if l.context {
+				continue
+			}
+			replaced := replaceLine(re, l.text, repl, g.opts.regex)
+			if replaced == l.text {
+				continue
+			}
+			changes[f.path] = append(changes[f.path], []interface{}{l.num, l.text, replaced})
+		}
+	}
+	root := g.root
+	ws := g.ws
+	g.hide()
+	go func() {
+		var result []int
+		if err := ws.nvim.ExecLua(`return goneovim.grep_replace(...)`, &result, root, changes); err != nil {
+			ws.nvim.WritelnErr("goneovim: " + err.Error())
+			return
+		}
+		if len(result) == 2 {
+			ws.nvim.Command(fmt.Sprintf(
+				`echo "goneovim: replaced %d lines in %d files, which are not written yet"`,
+				result[1], result[0],
+			))
+		}
+	}()
+}
+
+func (g *GrepPanel) keyPress(input *widgets.QLineEdit, event *gui.QKeyEvent) {
+	mod := event.Modifiers()
+	ctrl := mod&core.Qt__ControlModifier != 0
+	alt := mod&core.Qt__AltModifier != 0
+	switch core.Qt__Key(event.Key()) {
+	case core.Qt__Key_Escape:
+		if g.confirming {
+			g.confirming = false
+			g.render()
+			return
+		}
+		g.hide()
+	case core.Qt__Key_Up:
+		g.moveSelected(-1)
+	case core.Qt__Key_Down:
+		g.moveSelected(1)
+	case core.Qt__Key_Return, core.Qt__Key_Enter:
+		switch {
+		case g.confirming:
+			g.apply()
+		case ctrl && g.replace.IsVisible() && g.done && g.limited():
+			g.updateStatus("too many matches to replace, narrow the pattern")
+		case ctrl && g.replace.IsVisible() && g.done && g.matches > 0:
+			g.confirming = true
+			g.render()
+		default:
+			g.open(g.results.CurrentRow())
+		}
+	case core.Qt__Key_N:
+		if !ctrl {
+			input.KeyPressEventDefault(event)
+			return
+		}
+		g.moveSelected(1)
+	case core.Qt__Key_P:
+		if !ctrl {
+			input.KeyPressEventDefault(event)
+			return
+		}
+		g.moveSelected(-1)
+	case core.Qt__Key_R:
+		switch {
+		case ctrl:
+			g.replace.SetVisible(!g.replace.IsVisible())
+			if g.replace.IsVisible() {
+				g.replace.SetFocus2()
+			} else {
+				g.input.SetFocus2()
+			}
+			g.confirming = false
+			g.render()
+		case alt:
+			g.opts.regex = !g.opts.regex
+			g.search()
+		default:
+			input.KeyPressEventDefault(event)
+		}
+	case core.Qt__Key_C:
+		if !alt {
+			input.KeyPressEventDefault(event)
+			return
+		}
+		g.opts.caseSensitive = !g.opts.caseSensitive
+		g.search()
+	default:
+		input.KeyPressEventDefault(event)
+	}
+}
+
+// handleGrep handles the events of GonvimGrep.
+func (ws *Workspace) handleGrep(updates []interface{}) {
+	switch updates[0] {
+	case "gonvim_grep":
+		pattern := ""
+		if len(updates) > 1 {
+			pattern, _ = updates[1].(string)
+		}
+		ws.openGrep(pattern)
+	case "gonvim_grep_results":
+		if ws.grep == nil || len(updates) < 5 {
+			return
+		}
+		root, _ := updates[2].(string)
+		lines, _ := updates[3].([]interface{})
+		done, _ := updates[4].(bool)
+		ws.grep.addRgLines(util.ReflectToInt(updates[1]), root, lines, done)
+	case "gonvim_grep_local":
+		pattern, _ := updates[2].(string)
+		ws.grep.searchLocal(updates[1].(int), pattern)
+	case "gonvim_grep_files":
+		files, _ := updates[2].([]*grepFile)
+		done, _ := updates[3].(bool)
+		ws.grep.addFiles(updates[1].(int), files, done)
+	}
+}
@@ -0,0 +1,227 @@
+package editor
+
+import (
+	"math"
+	"strings"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+)
+
+// hlFontKey identifies a highlight font built on top of a particular base font.
+// Since Font.change() mutates a font in place, the key holds the base font
+// metrics instead of the pointer so that a guifont change produces new fonts.
+type hlFontKey struct {
+	hlName      string
+	family      string
+	size        float64
+	weight      gui.QFont__Weight
+	stretch     int
+	lineSpace   int
+	letterSpace int
+}
+
+// getHighlightFont returns the font configured for the highlight group of hl,
+// or nil if the group has no font override.
+func (w *Window) getHighlightFont(hl *Highlight) *Font {
+	if hl == nil || hl.hlName == "" {
+		return nil
+	}
+	if w.s.name == "minimap" {
+		return nil
+	}
+
+	return w.s.getHighlightFont(hl.hlName, w.getFont())
+}
+
+func (s *Screen) getHighlightFont(hlName string, base *Font) *Font {
+	s.hlFontMutex.RLock()
+	if len(s.hlFontSpecs) == 0 {
+		s.hlFontMutex.RUnlock()
+		return nil
+	}
+	key := hlFontKey{
+		hlName:      hlName,
+		family:      base.family,
+		size:        base.size,
+		weight:      base.weight,
+		stretch:     base.stretch,
+		lineSpace:   base.lineSpace,
+		letterSpace: base.letterSpace,
+	}
+	font, ok := s.hlFonts[key]
+	s.hlFontMutex.RUnlock()
+	if ok {
+		return font
+	}
+
+	s.hlFontMutex.Lock()
+	defer s.hlFontMutex.Unlock()
+
+	spec, ok := lookupHighlightFontSpec(s.hlFontSpecs, hlName)
+	if ok {
+		font = newHighlightFont(spec, base)
+	}
+	// Also cache misses, so that groups without an override cost a map lookup.
+	s.hlFonts[key] = font
+
+	return font
+}
+
+// lookupHighlightFontSpec finds the override for hlName. Treesitter groups
+// such as "@keyword.function.lua" fall back to "@keyword.function" and then
+// "@keyword".
+func lookupHighlightFontSpec(specs map[string]highlightFontConfig, hlName string) (highlightFontConfig, bool) {
+	name := hlName
+	for {
+		spec, ok := specs[name]
+		if ok {
+			return spec, true
+		}
+		i := strings.LastIndex(name, ".")
+		if i <= 0 {
+			break
+		}
+		name = name[:i]
+	}
+
+	return highlightFontConfig{}, false
+}
+
+// highlightFontSpecs returns the HighlightFonts config, whose font families
+// are checked once for all the workspaces.
+func (e *Editor) highlightFontSpecs() map[string]highlightFontConfig {
+	e.hlFontSpecsOnce.Do(func() {
+		e.hlFontSpecs = make(map[string]highlightFontConfig)
+		for name, spec := range e.config.HighlightFonts {
+			e.hlFontSpecs[name] = checkHighlightFontSpec(spec)
+		}
+	})
+
+	return e.hlFontSpecs
+}
+
+// checkHighlightFontSpec records the error of the font family of spec if it
+// is not found, and returns spec with the family of the base font instead,
+// so that the font is not looked up again while the text is drawn.
+func checkHighlightFontSpec(spec highlightFontConfig) highlightFontConfig {
+	if spec.FontFamily != "" && !checkValidFont(spec.FontFamily) {
+		editor.fontErrors = append(editor.fontErrors, spec.FontFamily)
+		spec.FontFamily = ""
+	}
+
+	return spec
+}
+
+func newHighlightFont(spec highlightFontConfig, base *Font) *Font {
+	family := base.family
+	if spec.FontFamily != "" {
+		family = spec.FontFamily
+	}
+	weight := base.weight
+	if spec.FontWeight != "" {
+		weight = parseFontWeight(spec.FontWeight)
+	}
+	scale := spec.SizeScale
+	if scale <= 0 {
+		scale = 1.0
+	}
+
+	font := initFontNew(family, base.size*scale, weight, base.stretch, base.lineSpace, base.letterSpace)
+
+	// Neovim grids have a fixed row height, so a scaled up font
+	// is shrunk until its glyphs fit into the line of the base font.
+	if font.height > base.height {
+		size := font.size * float64(base.height) / float64(font.height)
+		font = initFontNew(family, math.Max(size, 1.0), weight, base.stretch, base.lineSpace, base.letterSpace)
+	}
+
+	switch strings.ToLower(spec.FontStyle) {
+	case "italic":
+		font.qfont.SetStyle(gui.QFont__StyleItalic)
+	case "oblique":
+		font.qfont.SetStyle(gui.QFont__StyleOblique)
+	}
+	font.fontMetrics = gui.NewQFontMetricsF(font.qfont)
+
+	// Draw on the baseline of the base font, so that the text
+	// of the overridden group lines up with the surrounding text.
+	font.lineHeight = base.lineHeight
+	font.descent = base.descent
+	font.lineSpace = base.lineSpace
+	font.baselineOffset = base.baselineOffset
+
+	return font
+}
+
+// setHighlightFont handles goneovim.set_highlight_font() of the Lua API.
+// An empty option table removes the override of the group.
+func (s *Screen) setHighlightFont(args []interface{}) {
+	if len(args) < 1 {
+		return
+	}
+	hlName, ok := args[0].(string)
+	if !ok || hlName == "" {
+		return
+	}
+
+	var opts map[string]interface{}
+	if len(args) >= 2 {
+		opts, _ = args[1].(map[string]interface{})
+	}
+
+	s.hlFontMutex.Lock()
+	if len(opts) == 0 {
+		delete(s.hlFontSpecs, hlName)
+	} else {
+		spec := highlightFontConfig{
+			SizeScale: 1.0,
+		}
+		if v, ok := opts["family"].(string); ok {
+			spec.FontFamily = v
+		}
+		if v, ok := opts["style"].(string); ok {
+			spec.FontStyle = v
+		}
+		if v, ok := opts["weight"].(string); ok {
+			spec.FontWeight = v
+		}
+		if v, ok := opts["scale"]; ok {
+			scale := util.ReflectToFloat(v)
+			if scale > 0 {
+				spec.SizeScale = scale
+			}
+		}
+		s.hlFontSpecs[hlName] = checkHighlightFontSpec(spec)
+	}
+	s.hlFonts = make(map[hlFontKey]*Font)
+	s.hlFontMutex.Unlock()
+
+	editor.showFontErrors()
+	s.purgeTextCacheForWins()
+	s.refresh()
+}
+
+// resetHighlightFonts discards the highlight fonts built on top of the
+// base font, which are built again for the new size of it.
+func (s *Screen) resetHighlightFonts() {
+	s.hlFontMutex.Lock()
+	s.hlFonts = make(map[hlFontKey]*Font)
+	s.hlFontMutex.Unlock()
+}
+
+// scaleToTextWidth squeezes or stretches the text image horizontally,
+// so that text drawn with a highlight font keeps the cell alignment of the grid.
+func scaleToTextWidth(image *gui.QImage, width int) *gui.QImage {
+	if image.Width() == width || width <= 0 {
+		return image
+	}
+
+	return image.Scaled2(
+		width,
+		image.Height(),
+		core.Qt__IgnoreAspectRatio,
+		core.Qt__SmoothTransformation,
+	)
+}
@@ -0,0 +1,58 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestLookupHighlightFontSpec(t *testing.T) {
+	specs := map[string]highlightFontConfig{
+		"Comment":           {FontStyle: "italic"},
+		"@keyword":          {FontWeight: "bold"},
+		"@keyword.function": {FontFamily: "Victor Mono"},
+	}
+	tests := []struct {
+		name   string
+		hlName string
+		want   highlightFontConfig
+		wantOk bool
+	}{
+		{
+			"lookupHighlightFontSpec() exact match",
+			"Comment",
+			highlightFontConfig{FontStyle: "italic"},
+			true,
+		},
+		{
+			"lookupHighlightFontSpec() falls back to the parent treesitter group",
+			"@keyword.return.lua",
+			highlightFontConfig{FontWeight: "bold"},
+			true,
+		},
+		{
+			"lookupHighlightFontSpec() prefers the most specific group",
+			"@keyword.function.lua",
+			highlightFontConfig{FontFamily: "Victor Mono"},
+			true,
+		},
+		{
+			"lookupHighlightFontSpec() no match",
+			"Normal",
+			highlightFontConfig{},
+			false,
+		},
+		{
+			"lookupHighlightFontSpec() does not strip a leading dot",
+			".Comment",
+			highlightFontConfig{},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := lookupHighlightFontSpec(specs, tt.hlName)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("lookupHighlightFontSpec() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
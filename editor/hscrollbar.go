@@ -0,0 +1,252 @@
+package editor
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+	"github.com/akiyosi/qt/widgets"
+)
+
+// HScrollBar is the horizontal scrollbar laid over the bottom of a window
+// whose buffer is displayed with 'nowrap'.
+type HScrollBar struct {
+	scrollBarBase
+	win *Window
+	// leftcol is the first column displayed in the window, as winsaveview() returns.
+	leftcol int
+	// widest is the display width of the longest line in the window.
+	widest int
+	// textWidth is the number of columns of the text area of the window,
+	// excluding the number, sign and fold columns.
+	textWidth      int
+	wrap           bool
+	beginPosX      int
+	beginLeftcol   int
+	requestLeftcol int
+	isPressed      bool
+}
+
+func newHScrollBar(win *Window) *HScrollBar {
+	widget := widgets.NewQWidget(win, 0)
+	widget.SetContentsMargins(0, 0, 0, 0)
+	widget.SetFixedHeight(editor.config.ScrollBar.Width)
+	thumb := widgets.NewQWidget(widget, 0)
+	thumb.SetFixedHeight(8)
+
+	h := &HScrollBar{
+		scrollBarBase: scrollBarBase{
+			widget: widget,
+			thumb:  thumb,
+		},
+		win:     win,
+		wrap:    true,
+		leftcol: -1,
+	}
+
+	h.thumb.ConnectMousePressEvent(h.thumbPress)
+	h.thumb.ConnectMouseMoveEvent(h.thumbScroll)
+	h.thumb.ConnectMouseReleaseEvent(h.thumbRelease)
+	h.thumb.ConnectEnterEvent(h.thumbEnter)
+	h.thumb.ConnectLeaveEvent(h.thumbLeave)
+
+	h.setColor()
+	h.widget.Hide()
+
+	return h
+}
+
+func (h *HScrollBar) thumbEnter(e *core.QEvent) {
+	h.highlightThumb(h.win.s.ws.screenbg)
+}
+
+func (h *HScrollBar) thumbLeave(e *core.QEvent) {
+	h.unhighlightThumb()
+}
+
+func (h *HScrollBar) thumbPress(e *gui.QMouseEvent) {
+	if e.Button() != core.Qt__LeftButton {
+		return
+	}
+	h.beginPosX = e.GlobalPos().X()
+	h.beginLeftcol = h.leftcol
+	h.requestLeftcol = h.leftcol
+	h.isPressed = true
+}
+
+func (h *HScrollBar) thumbScroll(e *gui.QMouseEvent) {
+	if !h.isPressed {
+		return
+	}
+
+	leftcol := scrollOffset(
+		h.widget.Width(),
+		h.beginLeftcol,
+		e.GlobalPos().X()-h.beginPosX,
+		h.widest,
+		h.textWidth,
+	)
+	if leftcol == h.requestLeftcol {
+		return
+	}
+	h.requestLeftcol = leftcol
+
+	// Move the thumb along with the mouse without waiting for Neovim.
+	if pos, _, ok := scrollThumb(h.widget.Width(), leftcol, h.widest, h.textWidth); ok {
+		h.thumb.Move2(pos, h.widget.Height()-h.thumb.Height())
+	}
+
+	go h.win.s.ws.nvim.Command(
+		fmt.Sprintf(`call win_execute(%d, 'call winrestview({"leftcol": %d})')`, h.win.id, leftcol),
+	)
+}
+
+func (h *HScrollBar) thumbRelease(e *gui.QMouseEvent) {
+	h.isPressed = false
+}
+
+// update moves the thumb to the leftcol reported by Neovim,
+// and shows the bar only when the window has lines wider than itself.
+func (h *HScrollBar) update(leftcol, widest, textWidth int, wrap bool) {
+	oldLeftcol := h.leftcol
+	h.leftcol = leftcol
+	h.widest = widest
+	h.textWidth = textWidth
+	h.wrap = wrap
+
+	h.layout()
+
+	if oldLeftcol >= 0 && oldLeftcol != leftcol && !wrap {
+		h.win.smoothScrollHorizontal(leftcol - oldLeftcol)
+	}
+}
+
+func (h *HScrollBar) layout() {
+	win := h.win
+	if !editor.config.ScrollBar.Horizontal || h.wrap || win.isMsgGrid || win.isPopupmenu {
+		h.widget.Hide()
+		return
+	}
+
+	font := win.getFont()
+	x := int(float64(win.cols-h.textWidth) * font.cellwidth)
+	if x < 0 {
+		x = 0
+	}
+	barWidth := int(float64(win.cols)*font.cellwidth) - x
+	barHeight := h.widget.Height()
+
+	pos, thumbWidth, ok := scrollThumb(barWidth, h.leftcol, h.widest, h.textWidth)
+	if !ok {
+		h.widget.Hide()
+		return
+	}
+
+	h.widget.SetGeometry2(x, win.rows*font.lineHeight-barHeight, barWidth, barHeight)
+	h.thumb.SetFixedWidth(thumbWidth)
+	if !h.isPressed {
+		h.thumb.Move2(pos, barHeight-h.thumb.Height())
+	}
+	h.widget.Show()
+	h.widget.Raise()
+}
+
+func (h *HScrollBar) isShown() bool {
+	return h != nil && h.widget.IsVisible()
+}
+
+// updateHScrollBar handles the horizontal scroll state of the window
+// which the autocmds notify.
+func (w *Window) updateHScrollBar(leftcol, widest, textWidth int, wrap bool) {
+	if w.hscrollBar == nil {
+		if wrap || !editor.config.ScrollBar.Horizontal {
+			return
+		}
+		w.hscrollBar = newHScrollBar(w)
+	}
+	w.hscrollBar.update(leftcol, widest, textWidth, wrap)
+}
+
+// isHorizontalScrollDisabled reports whether the horizontal wheel movement
+// is ignored. While the horizontal scrollbar of the window is shown, it is
+// allowed regardless of DisableHorizontalScroll.
+func (w *Window) isHorizontalScrollDisabled() bool {
+	if w.hscrollBar.isShown() {
+		return false
+	}
+
+	return editor.config.Editor.DisableHorizontalScroll
+}
+
+// horizontalScrollPixels returns the horizontal offset of the grid content
+// during the smooth scroll with the touchpad or by a change of leftcol.
+func (w *Window) horizontalScrollPixels() int {
+	var pixels int
+	if w.s.ws.mouseScroll != "" {
+		pixels = w.scrollPixels[0]
+	}
+
+	return pixels + int(w.hScrollOffset)
+}
+
+// smoothScrollHorizontal slides the content by delta columns back to
+// its position, in the same way as the smooth scroll by commands.
+func (w *Window) smoothScrollHorizontal(delta int) {
+	if !editor.config.Editor.SmoothScroll {
+		return
+	}
+	if delta == 0 || int(math.Abs(float64(delta))) >= w.cols {
+		return
+	}
+
+	if w.hScrollAnimation == nil {
+		w.hScrollAnimation = core.NewQVariantAnimation(w)
+		w.hScrollAnimation.SetEasingCurve(core.NewQEasingCurve(easingCurve(editor.config.Editor.SmoothScrollEasing)))
+		w.hScrollAnimation.SetDuration(editor.config.Editor.SmoothScrollDuration)
+		w.hScrollAnimation.ConnectValueChanged(func(value *core.QVariant) {
+			ok := false
+			v := value.ToDouble(&ok)
+			if !ok {
+				return
+			}
+			w.hScrollOffset = v
+			w.Update()
+		})
+		w.hScrollAnimation.ConnectFinished(func() {
+			w.hScrollOffset = 0
+			w.Update()
+		})
+	}
+
+	offset := float64(delta) * w.getFont().cellwidth
+	if w.hScrollAnimation.State() == core.QAbstractAnimation__Running {
+		w.hScrollAnimation.Stop()
+		offset += w.hScrollOffset
+	}
+	w.hScrollOffset = offset
+	w.hScrollAnimation.SetStartValue(core.NewQVariant10(offset))
+	w.hScrollAnimation.SetEndValue(core.NewQVariant10(0))
+	w.hScrollAnimation.Start(core.QAbstractAnimation__DeletionPolicy(core.QAbstractAnimation__KeepWhenStopped))
+}
+
+// fillHorizontalScrollGap erases the strip which the content slid off
+// during the horizontal smooth scroll.
+func (w *Window) fillHorizontalScrollGap(p *gui.QPainter) {
+	offset := int(w.hScrollOffset)
+	if offset == 0 || w.background == nil {
+		return
+	}
+
+	font := w.getFont()
+	width := int(float64(w.cols) * font.cellwidth)
+	y := w.viewportMargins[0] * font.lineHeight
+	height := (w.rows - w.viewportMargins[0] - w.viewportMargins[1]) * font.lineHeight
+
+	x := 0
+	if offset < 0 {
+		x = width + offset
+		offset = -offset
+	}
+	p.FillRect6(core.NewQRect4(x, y, offset, height), w.background.QColor())
+}
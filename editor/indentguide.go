@@ -0,0 +1,293 @@
+package editor
+
+import (
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+)
+
+// indentGuideRow is the indentation of a row of the grid.
+type indentGuideRow struct {
+	// offset is the number of the cells of the sign, number and fold columns.
+	offset int
+	// indent is the number of the blank cells before the text.
+	indent int
+	// blank is true if the row has no text.
+	blank bool
+	// wrapped is true if the row continues the line of the previous row.
+	wrapped bool
+}
+
+// indentGuideScope is the block of the rows containing the cursor,
+// whose guide is highlighted.
+type indentGuideScope struct {
+	col    int
+	top    int
+	bottom int
+}
+
+// indentGuideIndents returns the indentation which the guides of each row
+// are drawn for. A wrapped row takes the indentation of its line, and
+// a blank row takes the smaller one of the rows with text around it,
+// so that the guides continue through the blank lines inside a block.
+func indentGuideIndents(rows []indentGuideRow) []int {
+	indents := make([]int, len(rows))
+
+	prev := make([]int, len(rows))
+	last := -1
+	for y, row := range rows {
+		switch {
+		case row.blank:
+		case row.wrapped:
+			if last >= 0 {
+				indents[y] = last
+			}
+		default:
+			indents[y] = row.indent
+			last = row.indent
+		}
+		prev[y] = last
+	}
+
+	next := -1
+	for y := len(rows) - 1; y >= 0; y-- {
+		if !rows[y].blank {
+			next = indents[y]
+			continue
+		}
+		if prev[y] < 0 || next < 0 {
+			continue
+		}
+		indents[y] = prev[y]
+		if next < indents[y] {
+			indents[y] = next
+		}
+	}
+
+	return indents
+}
+
+// indentGuideLevels returns the number of the guides drawn for the indent.
+// The guides are drawn at every multiple of ts inside the indent.
+func indentGuideLevels(indent, ts int) int {
+	if ts <= 0 || indent <= 0 {
+		return 0
+	}
+
+	return (indent - 1) / ts
+}
+
+// findIndentGuideScope returns the innermost block containing the row
+// of the cursor: the rows around the cursor indented deeper than the
+// last guide of the cursor row.
+func findIndentGuideScope(indents []int, cursorRow, ts int) (indentGuideScope, bool) {
+	if cursorRow < 0 || cursorRow >= len(indents) {
+		return indentGuideScope{}, false
+	}
+	level := indentGuideLevels(indents[cursorRow], ts)
+	if level == 0 {
+		return indentGuideScope{}, false
+	}
+
+	col := level * ts
+	scope := indentGuideScope{col: col, top: cursorRow, bottom: cursorRow}
+	for scope.top > 0 && indents[scope.top-1] > col {
+		scope.top--
+	}
+	for scope.bottom < len(indents)-1 && indents[scope.bottom+1] > col {
+		scope.bottom++
+	}
+
+	return scope, true
+}
+
+// indentGuideRows reads the indentation of every row from the grid.
+func (w *Window) indentGuideRows() []indentGuideRow {
+	rows := make([]indentGuideRow, len(w.content))
+	for y, line := range w.content {
+		row := indentGuideRow{blank: true}
+		hasNumber := false
+		isNumberBlank := true
+		for _, c := range line {
+			if c == nil {
+				continue
+			}
+			if c.highlight.isSignColumn() {
+				row.offset++
+				if c.highlight.hlName == "LineNr" || c.highlight.hlName == "CursorLineNr" {
+					hasNumber = true
+					if c.char != " " {
+						isNumberBlank = false
+					}
+				}
+				continue
+			}
+			if c.char == " " {
+				row.indent++
+				continue
+			}
+			row.blank = false
+			break
+		}
+		// A wrapped line shows no line number in its following rows.
+		row.wrapped = !row.blank && hasNumber && isNumberBlank
+		rows[y] = row
+	}
+
+	return rows
+}
+
+func (w *Window) drawIndentguide(p *gui.QPainter) {
+	if w == nil {
+		return
+	}
+	if w.grid == 1 || w.isMsgGrid {
+		return
+	}
+	if w.s.name == "minimap" {
+		return
+	}
+	if w.ft == "" {
+		return
+	}
+	for _, v := range editor.config.Editor.IndentGuideIgnoreFtList {
+		if v == w.ft {
+			return
+		}
+	}
+	if !w.isShown() {
+		return
+	}
+	if w.ts == 0 {
+		return
+	}
+
+	// The guides are computed over the whole grid regardless of the
+	// painted region, since a scope can extend beyond it.
+	guideRows := w.indentGuideRows()
+	indents := indentGuideIndents(guideRows)
+	var scope indentGuideScope
+	var hasScope bool
+	if w.grid == w.s.ws.cursor.gridid {
+		scope, hasScope = findIndentGuideScope(indents, w.s.cursor[0], w.ts)
+	}
+	if editor.config.Editor.IndentGuideScopeOnly && !hasScope {
+		return
+	}
+
+	// Clip the guides to the scrolling region, so that the guides shifted
+	// by the smooth scroll do not overlap the winbar or the margins,
+	// nor the snapshot of the previous content.
+	font := w.getFont()
+	top := w.viewportMargins[0]
+	bottom := w.rows - w.viewportMargins[1]
+	p.Save()
+	p.SetClipRect2(
+		core.NewQRect4(
+			0,
+			top*font.lineHeight,
+			int(float64(w.cols)*font.cellwidth),
+			(bottom-top)*font.lineHeight,
+		),
+		core.Qt__IntersectClip,
+	)
+	defer p.Restore()
+
+	for y := top; y < bottom && y < len(w.content); y++ {
+		levels := indentGuideLevels(indents[y], w.ts)
+		for level := 1; level <= levels; level++ {
+			isScope := hasScope && level*w.ts == scope.col && y >= scope.top && y <= scope.bottom
+			if editor.config.Editor.IndentGuideScopeOnly && !isScope {
+				continue
+			}
+			x := guideRows[y].offset + level*w.ts
+			if x < len(w.content[y]) {
+				if c := w.content[y][x]; c != nil && c.char != " " {
+					continue
+				}
+			}
+			w.drawIndentline(p, x, y, level, isScope)
+		}
+	}
+}
+
+// indentGuideColor returns the color of the guide of the level.
+// The IndentGuideColors option takes precedence over the
+// IndentGuideLevelN highlight groups of the colorscheme.
+func (w *Window) indentGuideColor(level int, isScope bool) *RGBA {
+	ws := w.s.ws
+	if isScope && ws.indentScopeColor != nil {
+		return ws.indentScopeColor
+	}
+
+	color := editor.colors.indentGuide
+	if n := len(editor.colors.indentGuideLevels); n > 0 {
+		color = editor.colors.indentGuideLevels[(level-1)%n]
+	} else if n := len(ws.indentGuideColors); n > 0 {
+		color = ws.indentGuideColors[(level-1)%n]
+	}
+	if isScope {
+		color = warpColor(color, -40)
+	}
+
+	return color
+}
+
+func (w *Window) drawIndentline(p *gui.QPainter, x, y, level int, isScope bool) {
+	font := w.getFont()
+
+	// Set smooth scroll offset
+	var horScrollPixels, verScrollPixels int
+	if w.lastScrollphase != core.Qt__NoScrollPhase {
+		verScrollPixels = w.scrollPixels2
+	}
+	if editor.config.Editor.LineToScroll == 1 {
+		verScrollPixels += w.scrollPixels[1]
+	}
+
+	horScrollPixels += w.horizontalScrollPixels()
+
+	X := float64(x)*font.cellwidth + float64(horScrollPixels)
+	Y := float64(y*font.lineHeight) + float64(verScrollPixels)
+	color := w.indentGuideColor(level, isScope)
+	var lineWeight float64 = 1
+	if isScope {
+		lineWeight = 1.5
+	}
+	p.FillRect4(
+		core.NewQRectF4(
+			X,
+			Y,
+			lineWeight,
+			float64(font.lineHeight),
+		),
+		color.QColor(),
+	)
+
+	if w.lenContent[y] < x {
+		w.lenContent[y] = x
+	}
+}
+
+// setIndentGuideColors handles the colors of the IndentGuideLevelN and
+// IndentGuideScope highlight groups which the autocmds notify.
+func (ws *Workspace) setIndentGuideColors(levelsITF, scopeITF interface{}) {
+	ws.indentGuideColors = nil
+	if levels, ok := levelsITF.([]interface{}); ok {
+		for _, l := range levels {
+			hex, ok := l.(string)
+			if !ok || hex == "" {
+				continue
+			}
+			if color := hexToRGBA(hex); color != nil {
+				ws.indentGuideColors = append(ws.indentGuideColors, color)
+			}
+		}
+	}
+
+	ws.indentScopeColor = nil
+	if hex, ok := scopeITF.(string); ok && hex != "" {
+		ws.indentScopeColor = hexToRGBA(hex)
+	}
+
+	ws.screen.refresh()
+}
@@ -0,0 +1,105 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIndentGuideIndents(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []indentGuideRow
+		want []int
+	}{
+		{
+			"indentGuideIndents() keeps the guides through the blank lines in a block",
+			[]indentGuideRow{
+				{indent: 0},
+				{indent: 8},
+				{blank: true},
+				{indent: 8},
+				{indent: 4},
+			},
+			[]int{0, 8, 8, 8, 4},
+		},
+		{
+			"indentGuideIndents() takes the smaller indent after the block",
+			[]indentGuideRow{
+				{indent: 8},
+				{blank: true},
+				{blank: true},
+				{indent: 4},
+			},
+			[]int{8, 4, 4, 4},
+		},
+		{
+			"indentGuideIndents() does not draw the blank lines at the edges",
+			[]indentGuideRow{
+				{blank: true},
+				{indent: 4},
+				{blank: true},
+			},
+			[]int{0, 4, 0},
+		},
+		{
+			"indentGuideIndents() continues the wrapped line",
+			[]indentGuideRow{
+				{indent: 8},
+				{indent: 0, wrapped: true},
+				{indent: 4},
+			},
+			[]int{8, 8, 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := indentGuideIndents(tt.rows); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("indentGuideIndents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndentGuideLevels(t *testing.T) {
+	tests := []struct {
+		name       string
+		indent, ts int
+		want       int
+	}{
+		{"indentGuideLevels() without indent", 0, 4, 0},
+		{"indentGuideLevels() of the first level", 4, 4, 0},
+		{"indentGuideLevels() of the second level", 8, 4, 1},
+		{"indentGuideLevels() of an odd indent", 9, 4, 2},
+		{"indentGuideLevels() without tabstop", 8, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := indentGuideLevels(tt.indent, tt.ts); got != tt.want {
+				t.Errorf("indentGuideLevels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindIndentGuideScope(t *testing.T) {
+	indents := []int{0, 4, 8, 12, 12, 8, 4, 8, 0}
+	tests := []struct {
+		name      string
+		cursorRow int
+		want      indentGuideScope
+		wantOk    bool
+	}{
+		{"findIndentGuideScope() in the innermost block", 3, indentGuideScope{col: 8, top: 3, bottom: 4}, true},
+		{"findIndentGuideScope() in the outer block", 5, indentGuideScope{col: 4, top: 2, bottom: 5}, true},
+		{"findIndentGuideScope() at the first level", 6, indentGuideScope{}, false},
+		{"findIndentGuideScope() out of the grid", 20, indentGuideScope{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := findIndentGuideScope(indents, tt.cursorRow, 4)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("findIndentGuideScope() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
@@ -31,11 +31,8 @@ func (e *Editor) keyRelease(event *gui.QKeyEvent) {
 }
 
 func (e *Editor) keyPress(event *gui.QKeyEvent) {
-	if len(e.workspaces) <= e.active {
-		return
-	}
-	ws := e.workspaces[e.active]
-	if ws.nvim == nil {
+	ws := e.focusedWorkspace()
+	if ws == nil || ws.nvim == nil {
 		return
 	}
 	if event.IsAutoRepeat() {
@@ -51,6 +48,26 @@ func (e *Editor) keyPress(event *gui.QKeyEvent) {
 		e.isHideMouse = true
 	}
 
+	if !e.config.Editor.DisableZoomShortcuts {
+		if delta, ok := isZoomKeyEvent(event.Key(), event.Modifiers()); ok {
+			// Let nvim decide, so that the user mappings of the keys win.
+			zoomEvent := "gonvim_zoom_reset"
+			switch delta {
+			case 1:
+				zoomEvent = "gonvim_zoom_in"
+			case -1:
+				zoomEvent = "gonvim_zoom_out"
+			}
+			go ws.nvim.ExecLua(`goneovim.zoom_key(...)`, nil, e.convertKey(event), zoomEvent)
+			return
+		}
+	}
+
+	if !e.config.Editor.DisableCommandPaletteShortcut && isCommandPaletteKeyEvent(event.Key(), event.Modifiers()) {
+		ws.openCommandPalette()
+		return
+	}
+
 	input := e.convertKey(event)
 
 	e.putLog("key input for nvim::", "input:", input)
@@ -0,0 +1,269 @@
+package editor
+
+import (
+	"math"
+	"runtime"
+	"time"
+
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+)
+
+const (
+	kineticScrollInterval = 8
+	// kineticStopVelocity is the speed in pixels per second
+	// below which the kinetic scroll comes to rest.
+	kineticStopVelocity = 20.0
+	// scrollSpringRest is the offset in lines below which
+	// the viewport jump animation is regarded as finished.
+	scrollSpringRest = 0.01
+)
+
+// kineticScroller moves the grid with a velocity decaying by friction.
+// The motion is computed in closed form from the elapsed time, so that
+// the distance travelled does not depend on the refresh rate.
+type kineticScroller struct {
+	timer          *core.QTimer
+	lastTime       time.Time
+	sampleTime     time.Time
+	mod            string
+	velocity       float64
+	remainder      float64
+	sampleVelocity float64
+	row            int
+	col            int
+	sawMomentum    bool
+}
+
+// fling adds the velocity v in pixels per second. The velocity of an ongoing
+// motion in the same direction is carried over, the opposite one is cancelled.
+func (k *kineticScroller) fling(v float64) {
+	if k.velocity*v > 0 {
+		k.velocity += v
+	} else {
+		k.velocity = v
+	}
+}
+
+// step advances the motion by dt seconds and returns the distance
+// travelled in pixels, and whether the motion came to rest.
+func (k *kineticScroller) step(dt, friction float64) (distance float64, done bool) {
+	decay := math.Exp(-friction * dt)
+	distance = k.velocity * (1.0 - decay) / friction
+	k.velocity *= decay
+	if math.Abs(k.velocity) < kineticStopVelocity {
+		k.velocity = 0
+		done = true
+	}
+
+	return
+}
+
+// consume returns the whole pixels of distance, keeping the fraction
+// for the next step.
+func (k *kineticScroller) consume(distance float64) int {
+	d := distance + k.remainder
+	pixels := math.Trunc(d)
+	k.remainder = d - pixels
+
+	return int(pixels)
+}
+
+// track estimates the velocity of the touchpad scroll from its pixel deltas.
+func (k *kineticScroller) track(pixels int, now time.Time) {
+	dt := now.Sub(k.sampleTime).Seconds()
+	k.sampleTime = now
+	if dt <= 0 || dt > 0.1 {
+		k.sampleVelocity = 0
+		return
+	}
+	v := float64(pixels) / dt
+	k.sampleVelocity = 0.6*v + 0.4*k.sampleVelocity
+}
+
+func (k *kineticScroller) stop() {
+	k.velocity = 0
+	k.remainder = 0
+	if k.timer != nil {
+		k.timer.Stop()
+	}
+}
+
+// scrollSpring brings the offset of a viewport jump back to zero
+// with a critically damped spring. A jump during the motion keeps
+// the current velocity, so consecutive jumps blend into each other.
+type scrollSpring struct {
+	timer    *core.QTimer
+	lastTime time.Time
+	offset   float64
+	velocity float64
+}
+
+// step advances the spring by dt seconds with the angular frequency omega,
+// and reports whether the spring came to rest.
+func (s *scrollSpring) step(dt, omega float64) bool {
+	e := math.Exp(-omega * dt)
+	c := s.velocity + omega*s.offset
+	offset := (s.offset + c*dt) * e
+	velocity := (s.velocity - omega*c*dt) * e
+
+	// Do not swing past the destination.
+	if offset*s.offset < 0 || math.Abs(offset) < scrollSpringRest {
+		s.offset = 0
+		s.velocity = 0
+		return true
+	}
+	s.offset = offset
+	s.velocity = velocity
+
+	return false
+}
+
+// scrollSpringOmega derives the stiffness of the spring from
+// SmoothScrollDuration, so that the jump settles in about that time.
+func scrollSpringOmega() float64 {
+	duration := float64(editor.config.Editor.SmoothScrollDuration) / 1000.0
+	if duration <= 0 {
+		duration = 0.3
+	}
+
+	return 6.0 / duration
+}
+
+func (win *Window) springScroll(delta float64) {
+	win.spring.offset += delta
+	win.scrollDelta = win.spring.offset
+
+	if win.spring.timer == nil {
+		win.spring.timer = core.NewQTimer(nil)
+		win.spring.timer.ConnectTimeout(win.stepSpringScroll)
+	}
+	if win.spring.timer.IsActive() {
+		return
+	}
+	win.spring.lastTime = time.Now()
+	win.spring.timer.Start(kineticScrollInterval)
+}
+
+func (win *Window) stepSpringScroll() {
+	now := time.Now()
+	dt := now.Sub(win.spring.lastTime).Seconds()
+	win.spring.lastTime = now
+
+	if win.spring.step(dt, scrollSpringOmega()) {
+		win.spring.timer.Stop()
+	}
+	win.applySmoothScrollOffset(win.spring.offset)
+}
+
+// kineticWheel starts or accelerates the kinetic scroll with a mouse wheel
+// event. It reports false if the event is to be handled line by line.
+func (w *Window) kineticWheel(event *gui.QWheelEvent) bool {
+	if !editor.config.Editor.KineticScroll || w.s.ws.mode == "terminal" {
+		return false
+	}
+	if event.Phase() != core.Qt__NoScrollPhase {
+		return false
+	}
+	angle := event.AngleDelta().Y()
+	if angle == 0 {
+		return false
+	}
+
+	// A notch alone travels LineToScroll lines,
+	// since the distance of a fling is its velocity divided by the friction.
+	friction := editor.config.Editor.KineticScrollFriction
+	lines := float64(angle) / 120.0 * float64(editor.config.Editor.LineToScroll)
+	w.startKineticScroll(
+		lines*float64(w.getFont().lineHeight)*friction,
+		editor.modPrefix(event.Modifiers()),
+		event.X(),
+		event.Y(),
+	)
+
+	return true
+}
+
+// trackTouchpadScroll follows the touchpad scroll and continues it with
+// inertia when the fingers are lifted, on platforms which send no momentum
+// phase of their own. It reports true if the kinetic scroll took over.
+func (w *Window) trackTouchpadScroll(event *gui.QWheelEvent, v int) bool {
+	if !editor.config.Editor.KineticScroll || w.s.ws.mode == "terminal" {
+		return false
+	}
+
+	switch event.Phase() {
+	case core.Qt__ScrollBegin:
+		w.kinetic.stop()
+		w.kinetic.sawMomentum = false
+		w.kinetic.sampleVelocity = 0
+		w.kinetic.sampleTime = time.Now()
+	case core.Qt__ScrollMomentum:
+		w.kinetic.sawMomentum = true
+	case core.Qt__ScrollUpdate:
+		w.kinetic.track(v, time.Now())
+	case core.Qt__ScrollEnd:
+		if w.kinetic.sawMomentum || runtime.GOOS == "darwin" {
+			return false
+		}
+		if math.Abs(w.kinetic.sampleVelocity) < kineticStopVelocity*10 {
+			return false
+		}
+		w.startKineticScroll(
+			w.kinetic.sampleVelocity,
+			editor.modPrefix(event.Modifiers()),
+			event.X(),
+			event.Y(),
+		)
+		return true
+	}
+
+	return false
+}
+
+func (w *Window) startKineticScroll(velocity float64, mod string, x, y int) {
+	font := w.getFont()
+	w.kinetic.mod = mod
+	w.kinetic.col = int(float64(x) / font.cellwidth)
+	w.kinetic.row = y / font.lineHeight
+	w.kinetic.fling(velocity)
+
+	// Keep the smooth scroll by commands off while the grid is in motion.
+	w.lastScrollphase = core.Qt__ScrollMomentum
+	if w.s.ws.mouseScroll != "" && w.s.ws.mouseScrollTemp != "ver:1,hor:1" {
+		w.applyTemporaryMousescroll("ver:1,hor:1")
+	}
+
+	if w.kinetic.timer == nil {
+		w.kinetic.timer = core.NewQTimer(nil)
+		w.kinetic.timer.ConnectTimeout(w.stepKineticScroll)
+	}
+	if w.kinetic.timer.IsActive() {
+		return
+	}
+	w.kinetic.lastTime = time.Now()
+	w.kinetic.timer.Start(kineticScrollInterval)
+}
+
+func (w *Window) stepKineticScroll() {
+	now := time.Now()
+	dt := now.Sub(w.kinetic.lastTime).Seconds()
+	w.kinetic.lastTime = now
+
+	distance, done := w.kinetic.step(dt, editor.config.Editor.KineticScrollFriction)
+	if pixels := w.kinetic.consume(distance); pixels != 0 {
+		vert, _ := w.smoothUpdate(pixels, 0, false)
+		if vert != 0 {
+			w.scrollByWheel(vert, 0, w.kinetic.mod, w.kinetic.row, w.kinetic.col)
+		}
+	}
+
+	if done {
+		w.kinetic.stop()
+		w.lastScrollphase = core.Qt__ScrollEnd
+		w.smoothUpdate(0, 0, true)
+		if w.s.ws.mouseScroll != "" {
+			w.applyTemporaryMousescroll(w.s.ws.mouseScroll)
+		}
+	}
+}
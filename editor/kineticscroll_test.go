@@ -0,0 +1,93 @@
+package editor
+
+import (
+	"math"
+	"testing"
+)
+
+func TestKineticScrollerStep(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps int
+	}{
+		{"step() at 30Hz", 30},
+		{"step() at 60Hz", 60},
+		{"step() at 144Hz", 144},
+	}
+	const (
+		velocity = 1200.0
+		friction = 4.0
+	)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &kineticScroller{}
+			k.fling(velocity)
+			var distance float64
+			for i := 0; i < tt.steps*5; i++ {
+				d, _ := k.step(1.0/float64(tt.steps), friction)
+				distance += d
+			}
+			// The motion comes to rest below kineticStopVelocity,
+			// which cuts off at most that velocity divided by the friction.
+			want := velocity / friction
+			if math.Abs(distance-want) > kineticStopVelocity/friction {
+				t.Errorf("step() travelled %v, want %v", distance, want)
+			}
+		})
+	}
+}
+
+func TestKineticScrollerFling(t *testing.T) {
+	tests := []struct {
+		name    string
+		flings  []float64
+		wantVel float64
+	}{
+		{"fling() carries over the velocity", []float64{100, 200}, 300},
+		{"fling() cancels the opposite velocity", []float64{100, -50}, -50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &kineticScroller{}
+			for _, v := range tt.flings {
+				k.fling(v)
+			}
+			if k.velocity != tt.wantVel {
+				t.Errorf("fling() velocity = %v, want %v", k.velocity, tt.wantVel)
+			}
+		})
+	}
+}
+
+func TestKineticScrollerConsume(t *testing.T) {
+	k := &kineticScroller{}
+	total := 0
+	for i := 0; i < 10; i++ {
+		total += k.consume(0.35)
+	}
+	if total != 3 {
+		t.Errorf("consume() = %v in total, want 3", total)
+	}
+}
+
+func TestScrollSpringStep(t *testing.T) {
+	const omega = 20.0
+	coarse := &scrollSpring{offset: 10}
+	coarse.step(0.05, omega)
+
+	fine := &scrollSpring{offset: 10}
+	for i := 0; i < 50; i++ {
+		fine.step(0.001, omega)
+	}
+	if math.Abs(coarse.offset-fine.offset) > 1e-9 {
+		t.Errorf("step() depends on the refresh rate: %v != %v", coarse.offset, fine.offset)
+	}
+
+	done := false
+	for i := 0; i < 1000 && !done; i++ {
+		done = fine.step(1.0/60.0, omega)
+	}
+	if !done || fine.offset != 0 {
+		t.Errorf("step() did not come to rest, offset = %v", fine.offset)
+	}
+}
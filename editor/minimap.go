@@ -30,19 +30,33 @@ type MiniMap struct {
 	nvim          *nvim.Nvim
 	stop          chan struct{}
 	redrawUpdates chan [][]interface{}
+	preview       *MiniMapPreview
 	Screen
+	marks              []scrollBarMark
+	markColors         map[string]*RGBA
+	blockChunks        map[int]*minimapBlockChunk
+	blockImageWidth    int
+	blockImageRatio    float64
+	blockRowLines      []int
+	frameTimes         minimapFrameTimes
+	frameCount         int
 	currBuf            string
 	colorscheme        string
+	hlIDs              map[int]int
 	viewport           [4]int
+	gridSize           [2]int
 	rows               int
 	curHeight          int
 	curPos             int
 	cols               int
+	topline            int
 	stopOnce           sync.Once
 	mu                 sync.Mutex
 	visible            bool
 	uiAttached         bool
 	isProcessSync      bool
+	isRequesting       bool
+	isRequestPending   bool
 	scrollPixelsDeltaY int
 }
 
@@ -65,6 +79,8 @@ func newMiniMap() *MiniMap {
 		stop:          make(chan struct{}),
 		signal:        NewMiniMapSignal(nil),
 		redrawUpdates: make(chan [][]interface{}, 1000),
+		hlIDs:         make(map[int]int),
+		blockChunks:   make(map[int]*minimapBlockChunk),
 	}
 	m.signal.ConnectRedrawSignal(func() {
 		updates := <-m.redrawUpdates
@@ -73,7 +89,10 @@ func newMiniMap() *MiniMap {
 	m.widget.ConnectResizeEvent(func(event *gui.QResizeEvent) {
 		m.updateSize()
 	})
+	m.widget.SetMouseTracking(true)
 	m.widget.ConnectMousePressEvent(m.mouseEvent)
+	m.widget.ConnectMouseMoveEvent(m.hoverEvent)
+	m.widget.ConnectLeaveEvent(m.leaveEvent)
 	m.widget.ConnectWheelEvent(m.wheelEvent)
 	m.widget.Hide()
 
@@ -112,6 +131,13 @@ func (m *MiniMap) asyncWSNvim(f func(n *nvim.Nvim)) {
 }
 
 func (m *MiniMap) startMinimapProc(ctx context.Context) {
+	// The primary backend reads the buffer from the nvim of the workspace.
+	if isMinimapPrimary() {
+		m.uiAttached = true
+		m.updateSize()
+		return
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -212,7 +238,12 @@ func (m *MiniMap) startMinimapProc(ctx context.Context) {
 	}(ws, mini)
 }
 
-func (m *MiniMap) exit() { go m.nvim.Command(":q!") }
+func (m *MiniMap) exit() {
+	if m.nvim == nil {
+		return
+	}
+	go m.nvim.Command(":q!")
+}
 
 func (m *MiniMap) attachUIOption() map[string]interface{} {
 	return map[string]interface{}{"rgb": true, "ext_linegrid": true}
@@ -253,6 +284,10 @@ func (m *MiniMap) updateCols() bool {
 
 func (m *MiniMap) updateSize() {
 	if m.uiAttached && (m.updateCols() || m.updateRows()) {
+		if isMinimapPrimary() {
+			m.requestChunks()
+			return
+		}
 		m.nvim.TryResizeUI(m.cols, m.rows)
 	}
 }
@@ -265,7 +300,7 @@ func (m *MiniMap) bufUpdate() {
 		m.mu.Unlock()
 		return
 	}
-	if m.ws == nil || m.ws.nvim == nil || m.nvim == nil {
+	if m.ws == nil || m.ws.nvim == nil || (m.nvim == nil && !isMinimapPrimary()) {
 		m.mu.Unlock()
 		return
 	}
@@ -305,6 +340,10 @@ func (m *MiniMap) bufUpdate() {
 
 	changed := (newKey != m.currBuf)
 	m.currBuf = newKey
+	if changed {
+		// Follow the viewport of the new buffer.
+		m.topline = 0
+	}
 	m.mu.Unlock()
 
 	if !changed {
@@ -369,10 +408,15 @@ func (m *MiniMap) setColorscheme() {
 	colo := m.ws.colorscheme
 	m.colorscheme = colo
 
-	m.asyncMiniNvim(func(n *nvim.Nvim) {
-		_ = n.Command("silent! colorscheme " + colo)
-		editor.putLog("[minimap] coloscheme is ", colo)
-	})
+	if !isMinimapPrimary() {
+		m.asyncMiniNvim(func(n *nvim.Nvim) {
+			_ = n.Command("silent! colorscheme " + colo)
+			editor.putLog("[minimap] coloscheme is ", colo)
+		})
+	} else {
+		// The colors of the chunks are read again in the new colorscheme.
+		go m.requestChunks()
+	}
 
 	if mmWin, ok := m.getWindow(1); ok {
 		mmWin.refreshUpdateArea(1)
@@ -475,6 +519,11 @@ func (m *MiniMap) mapScroll() {
 }
 
 func (m *MiniMap) bufSync() {
+	if isMinimapPrimary() {
+		m.requestChunks()
+		return
+	}
+
 	m.mu.Lock()
 	if m.isProcessSync {
 		m.mu.Unlock()
@@ -565,9 +614,14 @@ func (m *MiniMap) handleRedraw(updates [][]interface{}) {
 	for _, update := range updates {
 		event := update[0].(string)
 		args := update[1:]
+		m.invalidateBlocksForRedraw(event, args)
 		switch event {
 		case "grid_resize":
 			m.gridResize(args)
+			// The mouse events over the grid are handled by the minimap widget.
+			if mmWin, ok := m.getWindow(1); ok {
+				mmWin.SetAttribute(core.Qt__WA_TransparentForMouseEvents, true)
+			}
 		case "hl_attr_define":
 			m.setHlAttrDef(args)
 		case "hl_group_set":
@@ -604,9 +658,6 @@ func (m *MiniMap) transparent(bg *RGBA) int {
 }
 
 func (m *MiniMap) wheelEvent(event *gui.QWheelEvent) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	var v, h, vert, horiz, accel int
 	font := m.font
 	win, ok := m.getWindow(1)
@@ -663,6 +714,24 @@ func (m *MiniMap) wheelEvent(event *gui.QWheelEvent) {
 	acc := accel
 	n := m.nvim
 
+	if isMinimapPrimary() {
+		// topline is guarded by m.mu, since requestChunks reads it
+		// in a goroutine.
+		m.mu.Lock()
+		if delta > 0 {
+			m.topline -= acc
+		} else if delta < 0 {
+			m.topline += acc
+		}
+		if m.topline < 1 {
+			m.topline = 1
+		}
+		m.mu.Unlock()
+		go m.requestChunks()
+		event.Accept()
+		return
+	}
+
 	go func(delta, accel int, n *nvim.Nvim) {
 		if n == nil {
 			return
@@ -677,33 +746,6 @@ func (m *MiniMap) wheelEvent(event *gui.QWheelEvent) {
 	event.Accept()
 }
 
-func (m *MiniMap) mouseEvent(event *gui.QMouseEvent) {
-	font := m.font
-	y := int(float64(event.Y()) / float64(font.lineHeight))
-	targetPos := m.viewport[0] + y
-
-	m.asyncWSNvim(func(n *nvim.Nvim) {
-		_ = n.Command(fmt.Sprintf("%d", targetPos))
-	})
-
-	m.asyncWSNvim(func(n *nvim.Nvim) {
-		mappings, err := n.KeyMap("normal")
-		if err != nil {
-			return
-		}
-		var isThereZzMap bool
-		for _, mapping := range mappings {
-			if mapping.LHS == "zz" {
-				isThereZzMap = true
-				break
-			}
-		}
-		if !isThereZzMap {
-			_, _ = n.Input("zz")
-		}
-	})
-}
-
 func (w *Window) drawMinimap(p *gui.QPainter, y int, col int, cols int) {
 	if y >= len(w.content) {
 		return
@@ -0,0 +1,325 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+)
+
+// minimapChunkRows is the number of the buffer lines drawn into one
+// cached image in the blocks rendering mode.
+const minimapChunkRows = 64
+
+// minimapMaxChunks is the number of the cached images kept at most.
+const minimapMaxChunks = 64
+
+// minimapBlock is a run of non-blank cells with the same highlight,
+// drawn as a single rectangle in the blocks rendering mode.
+type minimapBlock struct {
+	col       int
+	width     int
+	highlight *Highlight
+}
+
+// minimapBlocks splits the line into the runs of non-blank cells
+// with the same highlight.
+func minimapBlocks(line []*Cell) []minimapBlock {
+	var blocks []minimapBlock
+	for x, cell := range line {
+		if cell == nil || cell.char == "" || cell.char == " " {
+			continue
+		}
+		n := len(blocks)
+		if n > 0 && blocks[n-1].col+blocks[n-1].width == x && blocks[n-1].highlight == cell.highlight {
+			blocks[n-1].width++
+			continue
+		}
+		blocks = append(blocks, minimapBlock{col: x, width: 1, highlight: cell.highlight})
+	}
+
+	return blocks
+}
+
+// minimapLineChunk returns the chunk which contains the buffer line,
+// which is 1-based, and the index of the line in the chunk.
+func minimapLineChunk(line int) (int, int) {
+	if line < 1 {
+		line = 1
+	}
+
+	return (line - 1) / minimapChunkRows, (line - 1) % minimapChunkRows
+}
+
+// minimapBlockChunk is the cached image of a chunk of the buffer lines,
+// and whether the blocks of each line are drawn in it.
+type minimapBlockChunk struct {
+	image *gui.QImage
+	drawn [minimapChunkRows]bool
+}
+
+// scrollMinimapBlockRows moves the buffer lines recorded for the rows
+// between top and bot along with grid_scroll, which moves the rows up by n,
+// or down if n is negative. The rows left behind are marked as changed
+// with 0, since grid_line redraws them.
+func scrollMinimapBlockRows(lines []int, top, bot, n int) {
+	if bot > len(lines) {
+		bot = len(lines)
+	}
+	if top < 0 || top >= bot {
+		return
+	}
+	if n > 0 {
+		for y := top; y < bot-n; y++ {
+			lines[y] = lines[y+n]
+		}
+		for y := max(bot-n, top); y < bot; y++ {
+			lines[y] = 0
+		}
+	} else if n < 0 {
+		for y := bot - 1; y >= top-n; y-- {
+			lines[y] = lines[y+n]
+		}
+		for y := top; y < min(top-n, bot); y++ {
+			lines[y] = 0
+		}
+	}
+}
+
+type minimapFrameTime struct {
+	frames int
+	total  time.Duration
+}
+
+// minimapFrameTimes accumulates the paint time of the minimap
+// for each rendering mode, so that the modes can be compared.
+type minimapFrameTimes map[string]*minimapFrameTime
+
+func (t minimapFrameTimes) add(mode string, d time.Duration) {
+	f, ok := t[mode]
+	if !ok {
+		f = &minimapFrameTime{}
+		t[mode] = f
+	}
+	f.frames++
+	f.total += d
+}
+
+// summary returns the average frame time of each mode measured so far,
+// and the ratio of the blocks to the glyphs when both are measured.
+func (t minimapFrameTimes) summary() string {
+	var parts []string
+	averages := make(map[string]time.Duration)
+	for _, mode := range []string{"glyphs", "blocks"} {
+		f, ok := t[mode]
+		if !ok || f.frames == 0 {
+			continue
+		}
+		averages[mode] = f.total / time.Duration(f.frames)
+		parts = append(parts, fmt.Sprintf("%s %v/frame over %d frames", mode, averages[mode], f.frames))
+	}
+	if len(averages) == 2 && averages["glyphs"] > 0 {
+		parts = append(parts, fmt.Sprintf("blocks/glyphs %.2f", float64(averages["blocks"])/float64(averages["glyphs"])))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// isBlocksMode reports whether the minimap is drawn with the blocks.
+// In "auto", the blocks are used for the buffers with more lines than
+// BlocksThreshold.
+func (m *MiniMap) isBlocksMode() bool {
+	switch editor.config.MiniMap.Render {
+	case "blocks":
+		return true
+	case "glyphs":
+		return false
+	}
+	if m.ws == nil {
+		return false
+	}
+
+	return m.ws.maxLine >= editor.config.MiniMap.BlocksThreshold
+}
+
+// invalidateAllBlocks discards all the cached images.
+func (m *MiniMap) invalidateAllBlocks() {
+	for _, chunk := range m.blockChunks {
+		chunk.image.DestroyQImage()
+	}
+	m.blockChunks = make(map[int]*minimapBlockChunk)
+	m.blockRowLines = nil
+}
+
+// invalidateBlocksForRedraw marks the rows of the grid changed by the redraw
+// event, whose lines are drawn into the cached images again. blockRowLines
+// holds the buffer line drawn from each row, or 0 if the row has changed.
+// The images are discarded when the colors change.
+func (m *MiniMap) invalidateBlocksForRedraw(event string, args []interface{}) {
+	switch event {
+	case "hl_attr_define":
+		// The primary mode sends it without definitions on every update.
+		if len(args) > 0 {
+			m.invalidateAllBlocks()
+		}
+	case "default_colors_set":
+		m.invalidateAllBlocks()
+	case "grid_resize", "grid_clear":
+		m.blockRowLines = nil
+	case "grid_line":
+		for _, arg := range args {
+			a := arg.([]interface{})
+			if util.ReflectToInt(a[0]) != 1 {
+				continue
+			}
+			if row := util.ReflectToInt(a[1]); row < len(m.blockRowLines) {
+				m.blockRowLines[row] = 0
+			}
+		}
+	case "grid_scroll":
+		for _, arg := range args {
+			a := arg.([]interface{})
+			if util.ReflectToInt(a[0]) != 1 {
+				continue
+			}
+			scrollMinimapBlockRows(
+				m.blockRowLines,
+				util.ReflectToInt(a[1]),
+				util.ReflectToInt(a[2]),
+				util.ReflectToInt(a[5]),
+			)
+		}
+	}
+}
+
+// blockChunk returns the cached image of the chunk, which is created
+// empty if it is not cached. The chunks far from it are discarded if
+// too many chunks are cached.
+func (w *Window) blockChunk(m *MiniMap, chunk int) *minimapBlockChunk {
+	width := m.widget.Width()
+	if m.blockImageWidth != width || m.blockImageRatio != w.devicePixelRatio {
+		m.invalidateAllBlocks()
+		m.blockImageWidth = width
+		m.blockImageRatio = w.devicePixelRatio
+	}
+	if c, ok := m.blockChunks[chunk]; ok {
+		return c
+	}
+	if len(m.blockChunks) >= minimapMaxChunks {
+		for n, c := range m.blockChunks {
+			if n < chunk-minimapMaxChunks/2 || n > chunk+minimapMaxChunks/2 {
+				c.image.DestroyQImage()
+				delete(m.blockChunks, n)
+			}
+		}
+	}
+
+	font := w.getFont()
+	ratio := w.devicePixelRatio
+	if ratio == 0 {
+		ratio = 1
+	}
+	image := gui.NewQImage3(
+		int(ratio*float64(width)),
+		int(ratio*float64(minimapChunkRows*font.lineHeight)),
+		gui.QImage__Format_ARGB32_Premultiplied,
+	)
+	image.SetDevicePixelRatio(ratio)
+	image.Fill3(core.Qt__transparent)
+
+	c := &minimapBlockChunk{image: image}
+	m.blockChunks[chunk] = c
+
+	return c
+}
+
+// drawBlockLine draws the blocks of the line at index into the image
+// of the chunk, replacing what is drawn there.
+func (w *Window) drawBlockLine(c *minimapBlockChunk, index int, blocks []minimapBlock) {
+	font := w.getFont()
+	top := float64(index * font.lineHeight)
+	// The blocks are centered vertically in the rows.
+	offset := float64(font.lineHeight) * (1 - minimapScaleValue) / 2
+
+	pi := gui.NewQPainter2(c.image)
+	pi.SetCompositionMode(gui.QPainter__CompositionMode_Clear)
+	pi.FillRect4(
+		core.NewQRectF4(0, top, float64(c.image.Width())/c.image.DevicePixelRatio(), float64(font.lineHeight)),
+		gui.NewQColor2(core.Qt__transparent),
+	)
+	pi.SetCompositionMode(gui.QPainter__CompositionMode_SourceOver)
+	for _, block := range blocks {
+		fg := block.highlight.fg().HSV().Colorless().RGB()
+		if fg == nil {
+			continue
+		}
+		pi.FillRect4(
+			core.NewQRectF4(
+				float64(block.col)*font.cellwidth*minimapScaleValue,
+				top+offset,
+				float64(block.width)*font.cellwidth*minimapScaleValue,
+				float64(font.lineHeight)*minimapScaleValue,
+			),
+			fg.QColor(),
+		)
+	}
+	pi.DestroyQPainter()
+
+	c.drawn[index] = true
+}
+
+// drawMinimapBlocks draws the row y from the cached image of the chunk
+// of the buffer line shown in it. The row is drawn into the image only if
+// grid_line has changed it, or grid_scroll has moved another line into it,
+// and otherwise the cached image is used as it is.
+func (w *Window) drawMinimapBlocks(p *gui.QPainter, m *MiniMap, y int) {
+	if y >= len(w.content) {
+		return
+	}
+	line := m.viewport[0] + y
+	chunk, index := minimapLineChunk(line)
+	c := w.blockChunk(m, chunk)
+	for len(m.blockRowLines) < len(w.content) {
+		m.blockRowLines = append(m.blockRowLines, 0)
+	}
+	if !c.drawn[index] || m.blockRowLines[y] != line {
+		w.drawBlockLine(c, index, minimapBlocks(w.content[y]))
+		m.blockRowLines[y] = line
+	}
+
+	font := w.getFont()
+	ratio := c.image.DevicePixelRatio()
+	p.DrawImage9(
+		0,
+		y*font.lineHeight,
+		c.image,
+		0, int(ratio*float64(index*font.lineHeight)),
+		-1, int(ratio*float64(font.lineHeight)),
+		core.Qt__AutoColor,
+	)
+}
+
+// minimapFrameLogInterval is the number of the frames
+// between the logs of the frame times.
+const minimapFrameLogInterval = 100
+
+// logFrameTime records the time to paint the minimap in the current
+// rendering mode, and logs the average frame time of both modes
+// to compare them with --debug.
+func (m *MiniMap) logFrameTime(start time.Time) {
+	mode := "glyphs"
+	if m.isBlocksMode() {
+		mode = "blocks"
+	}
+	if m.frameTimes == nil {
+		m.frameTimes = make(minimapFrameTimes)
+	}
+	m.frameTimes.add(mode, time.Since(start))
+	m.frameCount++
+	if m.frameCount%minimapFrameLogInterval == 0 {
+		editor.putLog("[minimap] frame time:", m.frameTimes.summary())
+	}
+}
@@ -0,0 +1,96 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMinimapBlocks(t *testing.T) {
+	keyword := &Highlight{}
+	normal := &Highlight{}
+	cells := func(text string, hl *Highlight) []*Cell {
+		var line []*Cell
+		for _, r := range text {
+			line = append(line, &Cell{char: string(r), highlight: hl})
+		}
+		return line
+	}
+
+	var line []*Cell
+	line = append(line, cells("  ", normal)...)
+	line = append(line, cells("func", keyword)...)
+	line = append(line, cells(" main()", normal)...)
+	line = append(line, nil, &Cell{char: "", highlight: normal})
+
+	want := []minimapBlock{
+		{col: 2, width: 4, highlight: keyword},
+		{col: 7, width: 6, highlight: normal},
+	}
+	if got := minimapBlocks(line); !reflect.DeepEqual(got, want) {
+		t.Errorf("minimapBlocks() = %v, want %v", got, want)
+	}
+	if got := minimapBlocks(nil); got != nil {
+		t.Errorf("minimapBlocks() of an empty line = %v", got)
+	}
+}
+
+func TestMinimapLineChunk(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      int
+		wantChunk int
+		wantIndex int
+	}{
+		{"minimapLineChunk() of the first line", 1, 0, 0},
+		{"minimapLineChunk() of the last line in a chunk", minimapChunkRows, 0, minimapChunkRows - 1},
+		{"minimapLineChunk() of the first line in the next chunk", minimapChunkRows + 1, 1, 0},
+		{"minimapLineChunk() without the line", 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunk, index := minimapLineChunk(tt.line)
+			if chunk != tt.wantChunk || index != tt.wantIndex {
+				t.Errorf("minimapLineChunk() = %v, %v, want %v, %v", chunk, index, tt.wantChunk, tt.wantIndex)
+			}
+		})
+	}
+}
+
+func TestScrollMinimapBlockRows(t *testing.T) {
+	tests := []struct {
+		name           string
+		top, bot, rows int
+		want           []int
+	}{
+		{"scrollMinimapBlockRows() scrolls up", 0, 5, 2, []int{3, 4, 5, 0, 0}},
+		{"scrollMinimapBlockRows() scrolls down", 0, 5, -2, []int{0, 0, 1, 2, 3}},
+		{"scrollMinimapBlockRows() scrolls the region", 1, 4, 1, []int{1, 3, 4, 0, 5}},
+		{"scrollMinimapBlockRows() scrolls beyond the region", 0, 5, 7, []int{0, 0, 0, 0, 0}},
+		{"scrollMinimapBlockRows() clamps the region to the rows", 0, 8, 1, []int{2, 3, 4, 5, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := []int{1, 2, 3, 4, 5}
+			scrollMinimapBlockRows(lines, tt.top, tt.bot, tt.rows)
+			if !reflect.DeepEqual(lines, tt.want) {
+				t.Errorf("scrollMinimapBlockRows() = %v, want %v", lines, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinimapFrameTimes(t *testing.T) {
+	times := make(minimapFrameTimes)
+	times.add("glyphs", 4*time.Millisecond)
+	times.add("glyphs", 6*time.Millisecond)
+	if got, want := times.summary(), "glyphs 5ms/frame over 2 frames"; got != want {
+		t.Errorf("summary() = %v, want %v", got, want)
+	}
+
+	times.add("blocks", time.Millisecond)
+	want := "glyphs 5ms/frame over 2 frames, blocks 1ms/frame over 1 frames, blocks/glyphs 0.20"
+	if got := times.summary(); got != want {
+		t.Errorf("summary() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,189 @@
+package editor
+
+import (
+	"github.com/akiyosi/goneovim/util"
+)
+
+// minimapRun is a run of cells with the same foreground color.
+// fg is -1 for the default foreground.
+type minimapRun struct {
+	text string
+	fg   int
+}
+
+// minimapChunks is the result of goneovim.minimap_chunks(), the lines of
+// the current buffer with their highlight colors read in the primary nvim.
+type minimapChunks struct {
+	lines [][]minimapRun
+	top   int
+	bot   int
+	cur   int
+	count int
+}
+
+// isMinimapPrimary reports whether the minimap is rendered from the buffer
+// of the primary nvim instead of a second nvim process.
+func isMinimapPrimary() bool {
+	return editor.config.MiniMap.Backend == "primary"
+}
+
+func parseMinimapChunks(result map[string]interface{}) (*minimapChunks, bool) {
+	if result == nil {
+		return nil, false
+	}
+	chunks := &minimapChunks{
+		top:   util.ReflectToInt(result["top"]),
+		bot:   util.ReflectToInt(result["bot"]),
+		cur:   util.ReflectToInt(result["cur"]),
+		count: util.ReflectToInt(result["count"]),
+	}
+	lines, ok := result["lines"].([]interface{})
+	if !ok {
+		return chunks, true
+	}
+	for _, l := range lines {
+		// A line is a flat list of text and color pairs.
+		flat, _ := l.([]interface{})
+		var runs []minimapRun
+		for i := 0; i+1 < len(flat); i += 2 {
+			text, ok := flat[i].(string)
+			if !ok {
+				continue
+			}
+			fg := -1
+			switch flat[i+1].(type) {
+			case int64, uint64, int, uint:
+				fg = util.ReflectToInt(flat[i+1])
+			}
+			runs = append(runs, minimapRun{text: text, fg: fg})
+		}
+		chunks.lines = append(chunks.lines, runs)
+	}
+
+	return chunks, true
+}
+
+// minimapRedrawEvents converts the chunks into the redraw events of a grid of
+// cols and rows, so that they are drawn just like the grid of the minimap
+// process. hlIDs keeps the highlight id of each color across the calls.
+func minimapRedrawEvents(chunks *minimapChunks, cols, rows int, hlIDs map[int]int) [][]interface{} {
+	var hlDefines []interface{}
+	hlID := func(fg int) int {
+		if fg < 0 {
+			return 0
+		}
+		id, ok := hlIDs[fg]
+		if !ok {
+			id = len(hlIDs) + 1
+			hlIDs[fg] = id
+			hlDefines = append(hlDefines, []interface{}{
+				id,
+				map[string]interface{}{"foreground": fg},
+				map[string]interface{}{},
+				[]interface{}{},
+			})
+		}
+		return id
+	}
+
+	var gridLines []interface{}
+	for row := 0; row < rows; row++ {
+		var cells []interface{}
+		col := 0
+		if row < len(chunks.lines) {
+			for _, run := range chunks.lines[row] {
+				id := hlID(run.fg)
+				for _, r := range run.text {
+					if col >= cols {
+						break
+					}
+					cells = append(cells, []interface{}{string(r), id})
+					col++
+				}
+			}
+		}
+		if col < cols {
+			cells = append(cells, []interface{}{" ", 0, cols - col})
+		}
+		gridLines = append(gridLines, []interface{}{1, row, 0, cells})
+	}
+
+	// hl_attr_define is sent even without new colors, since it also
+	// refreshes the default colors of the grid.
+	events := [][]interface{}{append([]interface{}{"hl_attr_define"}, hlDefines...)}
+	events = append(events, append([]interface{}{"grid_line"}, gridLines...))
+	events = append(events, []interface{}{
+		"win_viewport",
+		[]interface{}{1, 0, chunks.top - 1, chunks.bot - 1, chunks.cur - 1, 0, chunks.count, 0},
+	})
+	events = append(events, []interface{}{"flush"})
+
+	return events
+}
+
+// minimapTopline returns the first buffer line shown in the minimap of the
+// given rows. It keeps topline while the viewport of the current window
+// fits in the minimap, and otherwise centers the cursor line.
+func minimapTopline(topline, rows, viewTop, viewBot, curLine int) int {
+	if topline > 0 && viewTop >= topline && viewBot <= topline+rows {
+		return topline
+	}
+	top := curLine - rows/2
+	if top < 1 {
+		top = 1
+	}
+
+	return top
+}
+
+// requestChunks reads the lines around the viewport of the current window
+// from the primary nvim, and draws them through the redraw events.
+func (m *MiniMap) requestChunks() {
+	m.mu.Lock()
+	if !m.visible || m.ws == nil || m.ws.nvim == nil || m.rows <= 0 || m.cols <= 0 {
+		m.mu.Unlock()
+		return
+	}
+	if m.isRequesting {
+		m.isRequestPending = true
+		m.mu.Unlock()
+		return
+	}
+	m.isRequesting = true
+	top, rows, cols := m.topline, m.rows, m.cols
+	wsNvim := m.ws.nvim
+	m.mu.Unlock()
+
+	go func() {
+		var result map[string]interface{}
+		err := wsNvim.ExecLua("return goneovim.minimap_chunks(...)", &result, top, rows, cols)
+
+		m.mu.Lock()
+		m.isRequesting = false
+		pending := m.isRequestPending
+		m.isRequestPending = false
+		chunks, ok := parseMinimapChunks(result)
+		if err != nil || !ok {
+			m.mu.Unlock()
+			if pending {
+				m.requestChunks()
+			}
+			return
+		}
+
+		events := minimapRedrawEvents(chunks, cols, rows, m.hlIDs)
+		if m.gridSize != [2]int{cols, rows} {
+			m.gridSize = [2]int{cols, rows}
+			events = append([][]interface{}{{"grid_resize", []interface{}{1, cols, rows}}}, events...)
+		}
+		m.topline = chunks.top
+		m.mu.Unlock()
+
+		m.redrawUpdates <- events
+		m.signal.RedrawSignal()
+
+		if pending {
+			m.requestChunks()
+		}
+	}()
+}
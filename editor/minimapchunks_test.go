@@ -0,0 +1,109 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMinimapChunks(t *testing.T) {
+	result := map[string]interface{}{
+		"top":   int64(3),
+		"bot":   int64(5),
+		"cur":   int64(4),
+		"count": int64(40),
+		"lines": []interface{}{
+			[]interface{}{"func", int64(0xc678dd), " main", int64(-1)},
+			[]interface{}{},
+		},
+	}
+	chunks, ok := parseMinimapChunks(result)
+	if !ok {
+		t.Fatalf("parseMinimapChunks() failed")
+	}
+	want := &minimapChunks{
+		lines: [][]minimapRun{
+			{{text: "func", fg: 0xc678dd}, {text: " main", fg: -1}},
+			nil,
+		},
+		top:   3,
+		bot:   5,
+		cur:   4,
+		count: 40,
+	}
+	if !reflect.DeepEqual(chunks, want) {
+		t.Errorf("parseMinimapChunks() = %v, want %v", chunks, want)
+	}
+
+	if _, ok := parseMinimapChunks(nil); ok {
+		t.Errorf("parseMinimapChunks() of a float window should fail")
+	}
+}
+
+func TestMinimapRedrawEvents(t *testing.T) {
+	chunks := &minimapChunks{
+		lines: [][]minimapRun{
+			{{text: "if", fg: 0xff0000}, {text: " x", fg: -1}},
+		},
+		top:   1,
+		bot:   2,
+		cur:   1,
+		count: 1,
+	}
+	hlIDs := make(map[int]int)
+	events := minimapRedrawEvents(chunks, 6, 2, hlIDs)
+
+	var names []string
+	for _, e := range events {
+		names = append(names, e[0].(string))
+	}
+	wantNames := []string{"hl_attr_define", "grid_line", "win_viewport", "flush"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Fatalf("minimapRedrawEvents() = %v, want %v", names, wantNames)
+	}
+	if hlIDs[0xff0000] != 1 || len(hlIDs) != 1 {
+		t.Errorf("minimapRedrawEvents() hlIDs = %v", hlIDs)
+	}
+
+	wantLines := []interface{}{
+		"grid_line",
+		[]interface{}{1, 0, 0, []interface{}{
+			[]interface{}{"i", 1},
+			[]interface{}{"f", 1},
+			[]interface{}{" ", 0},
+			[]interface{}{"x", 0},
+			[]interface{}{" ", 0, 2},
+		}},
+		[]interface{}{1, 1, 0, []interface{}{
+			[]interface{}{" ", 0, 6},
+		}},
+	}
+	if !reflect.DeepEqual(events[1], wantLines) {
+		t.Errorf("minimapRedrawEvents() grid_line = %v, want %v", events[1], wantLines)
+	}
+
+	// The known colors are not defined again.
+	events = minimapRedrawEvents(chunks, 6, 2, hlIDs)
+	if len(events[0]) != 1 {
+		t.Errorf("minimapRedrawEvents() defines %v again", events[0][1:])
+	}
+}
+
+func TestMinimapTopline(t *testing.T) {
+	tests := []struct {
+		name                                     string
+		topline, rows, viewTop, viewBot, curLine int
+		want                                     int
+	}{
+		{"minimapTopline() keeps the viewport inside", 10, 100, 20, 60, 30, 10},
+		{"minimapTopline() centers the cursor below", 10, 100, 100, 140, 120, 70},
+		{"minimapTopline() centers the cursor above", 50, 100, 20, 60, 30, 1},
+		{"minimapTopline() without topline", 0, 100, 1, 40, 10, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := minimapTopline(tt.topline, tt.rows, tt.viewTop, tt.viewBot, tt.curLine); got != tt.want {
+				t.Errorf("minimapTopline() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+	"github.com/akiyosi/qt/widgets"
+	"github.com/neovim/go-client/nvim"
+)
+
+const (
+	// minimapPreviewLines is the number of lines in the magnified preview
+	// shown while hovering the minimap.
+	minimapPreviewLines = 9
+	minimapPreviewCols  = 80
+	minimapMarkWidth    = 3
+)
+
+// MiniMapPreview is the magnified code preview shown beside the minimap
+// while the mouse hovers it.
+type MiniMapPreview struct {
+	m      *MiniMap
+	widget *widgets.QWidget
+	// lines are the rows of the minimap grid to be drawn.
+	lines [][]*Cell
+	// row is the index of the hovered row in lines.
+	row int
+}
+
+// minimapMarkRow returns the row of the minimap grid where the line is drawn.
+func minimapMarkRow(line, topline, rows int) (int, bool) {
+	row := line - topline
+	if row < 0 || row >= rows {
+		return 0, false
+	}
+
+	return row, true
+}
+
+// minimapPreviewRange returns the rows of the minimap grid from start
+// to end, exclusive, which the preview of the hovered row shows.
+func minimapPreviewRange(row, rows int) (int, int) {
+	start := row - minimapPreviewLines/2
+	if start+minimapPreviewLines > rows {
+		start = rows - minimapPreviewLines
+	}
+	if start < 0 {
+		start = 0
+	}
+	end := start + minimapPreviewLines
+	if end > rows {
+		end = rows
+	}
+
+	return start, end
+}
+
+// minimapJumpCommand returns the command to jump to the clicked line.
+// The window scrolls only when the line is out of the viewport,
+// so that the viewport region does not move on every click.
+func minimapJumpCommand(line, viewTop, viewBot int) string {
+	if line >= viewTop && line < viewBot {
+		return fmt.Sprintf("call cursor(%d, 0)", line)
+	}
+
+	return fmt.Sprintf("normal! %dGzz", line)
+}
+
+// setMarks handles the marks of the current buffer, which are shared with
+// the overview ruler of the scrollbar.
+func (m *MiniMap) setMarks(marksITF, colorsITF interface{}) {
+	if !editor.config.MiniMap.Marks {
+		return
+	}
+
+	m.mu.Lock()
+	m.marks = parseScrollBarMarks(marksITF)
+	m.markColors = parseScrollBarMarkColors(colorsITF)
+	m.mu.Unlock()
+
+	if mmWin, ok := m.getWindow(1); ok {
+		mmWin.Update()
+	}
+}
+
+// drawMarks draws the marks of the lines in the minimap. Diff changes are
+// drawn on the left edge, diagnostics on the right edge, and search matches
+// across the line.
+func (m *MiniMap) drawMarks(p *gui.QPainter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	width := float64(m.widget.Width())
+	lineHeight := float64(m.font.lineHeight)
+
+	for _, mark := range m.marks {
+		row, ok := minimapMarkRow(mark.line, m.viewport[0], m.rows)
+		if !ok {
+			continue
+		}
+		color, ok := m.markColors[mark.kind]
+		if !ok || color == nil {
+			continue
+		}
+
+		y := float64(row) * lineHeight
+		switch scrollBarMarkLane(mark.kind) {
+		case 0:
+			p.FillRect4(core.NewQRectF4(0, y, minimapMarkWidth, lineHeight), color.QColor())
+		case 1:
+			c := color.copy()
+			c.A = 0.4
+			p.FillRect4(core.NewQRectF4(0, y, width, lineHeight), c.QColor())
+		default:
+			p.FillRect4(core.NewQRectF4(width-minimapMarkWidth, y, minimapMarkWidth, lineHeight), color.QColor())
+		}
+	}
+}
+
+// rowAt returns the row of the minimap grid at y of the widget.
+func (m *MiniMap) rowAt(y int) int {
+	if m.font.lineHeight == 0 {
+		return 0
+	}
+
+	return y / m.font.lineHeight
+}
+
+func (m *MiniMap) mouseEvent(event *gui.QMouseEvent) {
+	if event.Button() != core.Qt__LeftButton {
+		return
+	}
+
+	m.mu.Lock()
+	line := m.viewport[0] + m.rowAt(event.Y())
+	m.mu.Unlock()
+
+	m.ws.viewportMutex.RLock()
+	viewTop := m.ws.viewport[0]
+	viewBot := m.ws.viewport[1]
+	m.ws.viewportMutex.RUnlock()
+
+	command := minimapJumpCommand(line, viewTop, viewBot)
+	m.asyncWSNvim(func(n *nvim.Nvim) {
+		_ = n.Command(command)
+	})
+}
+
+func (m *MiniMap) hoverEvent(event *gui.QMouseEvent) {
+	if m.preview == nil {
+		m.preview = newMiniMapPreview(m)
+	}
+	m.preview.show(m.rowAt(event.Y()))
+}
+
+func (m *MiniMap) leaveEvent(event *core.QEvent) {
+	if m.preview != nil {
+		m.preview.widget.Hide()
+	}
+}
+
+func newMiniMapPreview(m *MiniMap) *MiniMapPreview {
+	widget := widgets.NewQWidget(m.ws.widget, 0)
+	widget.SetAttribute(core.Qt__WA_TransparentForMouseEvents, true)
+	widget.SetGraphicsEffect(util.DropShadow(-2, 4, 40, 200))
+	widget.Hide()
+
+	preview := &MiniMapPreview{
+		m:      m,
+		widget: widget,
+	}
+	widget.ConnectPaintEvent(preview.paint)
+
+	return preview
+}
+
+// show copies the rows around the hovered row from the minimap grid,
+// and places the preview to the left of the minimap at the row.
+func (v *MiniMapPreview) show(row int) {
+	mmWin, ok := v.m.getWindow(1)
+	if !ok {
+		return
+	}
+	start, end := minimapPreviewRange(row, len(mmWin.content))
+	if start >= end {
+		v.widget.Hide()
+		return
+	}
+	v.lines = mmWin.content[start:end]
+	v.row = row - start
+
+	font := v.m.ws.font
+	width := int(float64(minimapPreviewCols) * font.cellwidth)
+	height := (end - start) * font.lineHeight
+	v.widget.Resize2(width, height)
+
+	pos := v.m.widget.MapTo(v.m.ws.widget, core.NewQPoint2(0, row*v.m.font.lineHeight))
+	x := pos.X() - width
+	y := pos.Y() - height/2
+	if y < 0 {
+		y = 0
+	}
+	if y+height > v.m.ws.widget.Height() {
+		y = v.m.ws.widget.Height() - height
+	}
+	v.widget.Move2(x, y)
+	v.widget.Raise()
+	v.widget.Show()
+	v.widget.Update()
+}
+
+func (v *MiniMapPreview) paint(event *gui.QPaintEvent) {
+	p := gui.NewQPainter2(v.widget)
+	defer p.DestroyQPainter()
+
+	ws := v.m.ws
+	font := ws.font
+	p.FillRect4(core.NewQRectF4(0, 0, float64(v.widget.Width()), float64(v.widget.Height())), ws.background.QColor())
+
+	// The hovered row is highlighted like the viewport region.
+	if col := v.m.currentRegionColor(); col != nil {
+		p.FillRect4(
+			core.NewQRectF4(0, float64(v.row*font.lineHeight), float64(v.widget.Width()), float64(font.lineHeight)),
+			col.QColor(),
+		)
+	}
+
+	p.SetFont(font.qfont)
+	for y, line := range v.lines {
+		for x, cell := range line {
+			if x >= minimapPreviewCols {
+				break
+			}
+			if cell == nil || cell.highlight == nil || strings.TrimSpace(cell.char) == "" {
+				continue
+			}
+			p.SetPen2(cell.highlight.fg().QColor())
+			p.DrawText3(
+				int(float64(x)*font.cellwidth),
+				y*font.lineHeight+font.baselineOffset,
+				cell.char,
+			)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package editor
+
+import "testing"
+
+func TestMinimapMarkRow(t *testing.T) {
+	tests := []struct {
+		name                string
+		line, topline, rows int
+		wantRow             int
+		wantOk              bool
+	}{
+		{"minimapMarkRow() at the topline", 10, 10, 50, 0, true},
+		{"minimapMarkRow() inside the minimap", 30, 10, 50, 20, true},
+		{"minimapMarkRow() above the minimap", 9, 10, 50, 0, false},
+		{"minimapMarkRow() below the minimap", 60, 10, 50, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row, ok := minimapMarkRow(tt.line, tt.topline, tt.rows)
+			if row != tt.wantRow || ok != tt.wantOk {
+				t.Errorf("minimapMarkRow() = %v, %v, want %v, %v", row, ok, tt.wantRow, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestMinimapPreviewRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		row, rows int
+		wantStart int
+		wantEnd   int
+	}{
+		{"minimapPreviewRange() centers the row", 20, 100, 16, 25},
+		{"minimapPreviewRange() at the top", 1, 100, 0, 9},
+		{"minimapPreviewRange() at the bottom", 99, 100, 91, 100},
+		{"minimapPreviewRange() with fewer rows", 2, 5, 0, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := minimapPreviewRange(tt.row, tt.rows)
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("minimapPreviewRange() = %v, %v, want %v, %v", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestMinimapJumpCommand(t *testing.T) {
+	tests := []struct {
+		name                   string
+		line, viewTop, viewBot int
+		want                   string
+	}{
+		{"minimapJumpCommand() inside the viewport", 15, 10, 40, "call cursor(15, 0)"},
+		{"minimapJumpCommand() below the viewport", 40, 10, 40, "normal! 40Gzz"},
+		{"minimapJumpCommand() above the viewport", 3, 10, 40, "normal! 3Gzz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := minimapJumpCommand(tt.line, tt.viewTop, tt.viewBot); got != tt.want {
+				t.Errorf("minimapJumpCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
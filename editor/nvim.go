@@ -55,6 +55,7 @@ func newNvim(cols, rows int, ctx context.Context) (signal *neovimSignal, redrawU
 		setVar(neovim)
 		setGoneovim(neovim)
 		setGoneovimCommands(neovim)
+		setGoneovimLuaAPI(neovim)
 		registerHandler(neovim, signal, redrawUpdates, guiUpdates)
 		attachUI(neovim, cols, rows)
 
@@ -130,6 +131,7 @@ func startNvim(signal *neovimSignal, ctx context.Context) (neovim *nvim.Nvim, ui
 
 func registerHandler(neovim *nvim.Nvim, signal *neovimSignal, redrawUpdates chan [][]interface{}, guiUpdates chan []interface{}) {
 	handleRequest(neovim)
+	handleUIRequest(neovim, signal, guiUpdates)
 	handleNotification(neovim, signal, redrawUpdates, guiUpdates)
 }
 
@@ -214,6 +216,24 @@ func handleRequest(neovim *nvim.Nvim) {
 	})
 }
 
+// handleUIRequest handles the requests of the shims of vim.ui.select and
+// vim.ui.input, which are shown in the GUI thread. The requests return at
+// once without waiting for the user, and return false until the UI is
+// prepared, in which case the shims fall back to the original ones.
+func handleUIRequest(neovim *nvim.Nvim, signal *neovimSignal, guiUpdates chan []interface{}) {
+	for _, method := range []string{"select", "input"} {
+		event := "gonvim_ui_" + method
+		neovim.RegisterHandler("goneovim.ui_"+method, func(args ...interface{}) (bool, error) {
+			if !editor.isUiPrepared {
+				return false, nil
+			}
+			guiUpdates <- append([]interface{}{event}, args...)
+			signal.GuiSignal()
+			return true, nil
+		})
+	}
+}
+
 func handleNotification(neovim *nvim.Nvim, signal *neovimSignal, redrawUpdates chan [][]interface{}, guiUpdates chan []interface{}) {
 	neovim.RegisterHandler("redraw", func(updates ...[]interface{}) {
 		if !editor.isUiPrepared {
@@ -409,7 +429,7 @@ func attachUIOption(nvim *nvim.Nvim) (int, map[string]interface{}) {
 func setGoneovim(neovim *nvim.Nvim) {
 	var gonvimAutoCmds string
 
-	if (editor.opts.Server == "" && !editor.config.MiniMap.Disable) || (editor.config.Editor.IndentGuide) {
+	if isMinimapEnabled() || (editor.config.Editor.IndentGuide) || (editor.config.ScrollBar.Horizontal) || isScrollBarMarksEnabled() || editor.config.SideBar.Badges || editor.config.SideBar.GitStatus || editor.recent != nil {
 		gonvimAutoCmds = gonvimAutoCmds + `
 		aug Goneovim | au! | aug END
 		`
@@ -418,10 +438,11 @@ func setGoneovim(neovim *nvim.Nvim) {
 	if editor.config.Editor.IndentGuide {
 		gonvimAutoCmds = gonvimAutoCmds + `
 		au Goneovim OptionSet * if &ro != 1 | silent! call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_optionset", expand("<amatch>"), v:option_new, v:option_old, win_getid()) | endif
+		au Goneovim UIEnter,ColorScheme * silent! lua goneovim.notify_indent_guide_colors()
 		`
 	}
 
-	if editor.opts.Server == "" && !editor.config.MiniMap.Disable {
+	if isMinimapEnabled() {
 		gonvimAutoCmds = gonvimAutoCmds + `
 		au Goneovim BufEnter,TabEnter,TermOpen,TermClose * silent call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_workspace_filepath", expand("%:p"))
 		au Goneovim BufEnter,BufWrite * silent call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_minimap_update")
@@ -430,6 +451,43 @@ func setGoneovim(neovim *nvim.Nvim) {
 		`
 	}
 
+	if editor.config.ScrollBar.Horizontal {
+		gonvimAutoCmds = gonvimAutoCmds + `
+		au Goneovim WinScrolled,BufWinEnter * silent! lua goneovim.notify_horizontal_scroll()
+		au Goneovim TextChanged,TextChangedI * silent! lua goneovim.notify_horizontal_scroll_later()
+		au Goneovim OptionSet wrap silent! lua goneovim.notify_horizontal_scroll()
+		`
+	}
+
+	if isScrollBarMarksEnabled() || isMinimapMarksEnabled() {
+		gonvimAutoCmds = gonvimAutoCmds + `
+		au Goneovim BufWinEnter,WinEnter,TextChanged,InsertLeave,DiagnosticChanged * silent! lua goneovim.notify_scrollbar_marks_later()
+		au Goneovim OptionSet diff,hlsearch,ignorecase,smartcase silent! lua goneovim.notify_scrollbar_marks_later()
+		au Goneovim CmdlineLeave * silent! lua vim.schedule(goneovim.notify_scrollbar_marks_later)
+		au Goneovim CursorMoved * silent! lua goneovim.check_search_marks()
+		au Goneovim User GitSignsUpdate silent! lua goneovim.notify_scrollbar_marks_later()
+		`
+	}
+
+	if editor.config.SideBar.Badges {
+		gonvimAutoCmds = gonvimAutoCmds + `
+		au Goneovim UIEnter,BufModifiedSet,BufWritePost,BufDelete * silent! lua vim.schedule(goneovim.notify_modified_buffers)
+		`
+	}
+
+	if editor.config.SideBar.GitStatus {
+		gonvimAutoCmds = gonvimAutoCmds + `
+		au Goneovim UIEnter,DirChanged,BufWritePost,FocusGained,ShellCmdPost,TermLeave * silent! lua vim.schedule(goneovim.notify_git_status)
+		au Goneovim User GonvimFilerIgnored silent! lua goneovim.notify_git_status()
+		`
+	}
+
+	if editor.recent != nil {
+		gonvimAutoCmds = gonvimAutoCmds + `
+		au Goneovim BufEnter * if &buftype ==# "" && expand("%:p") !=# "" | silent! call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_recent_file", expand("%:p")) | endif
+		`
+	}
+
 	if gonvimAutoCmds == "" {
 		return
 	}
@@ -437,6 +495,29 @@ func setGoneovim(neovim *nvim.Nvim) {
 	neovim.Command(registerScripts)
 }
 
+// isMinimapEnabled reports whether the minimap is available. The minimap
+// process reads the files from the local disk, so it is not available
+// when attached to a remote nvim.
+func isMinimapEnabled() bool {
+	if editor.config.MiniMap.Disable {
+		return false
+	}
+
+	return editor.opts.Server == "" || isMinimapPrimary()
+}
+
+// isMinimapMarksEnabled reports whether the minimap overlays the marks,
+// which are notified in the same way as for the scrollbar.
+func isMinimapMarksEnabled() bool {
+	return isMinimapEnabled() && editor.config.MiniMap.Marks
+}
+
+// isScrollBarMarksEnabled reports whether the overview ruler
+// of the scrollbar needs the marks from Neovim.
+func isScrollBarMarksEnabled() bool {
+	return editor.config.ScrollBar.Visible && editor.config.ScrollBar.Marks
+}
+
 func setGoneovimCommands(neovim *nvim.Nvim) {
 	// Definition of the commands that goneovim provides
 	gonvimCommands := fmt.Sprintf(`
@@ -444,22 +525,42 @@ func setGoneovimCommands(neovim *nvim.Nvim) {
 	command! GonvimSidebarShow call rpcnotify(g:goneovim_channel_id, "Gui", "side_open")
 	command! GonvimSidebarToggle call rpcnotify(g:goneovim_channel_id, "Gui", "side_toggle")
 	command! GonvimVersion echo "%s"`, editor.version)
-	if editor.opts.Server == "" {
-		if !editor.config.MiniMap.Disable {
-			gonvimCommands = gonvimCommands + `
-			command! GonvimMiniMap call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_minimap_toggle")
+	if isMinimapEnabled() {
+		gonvimCommands = gonvimCommands + `
+		command! GonvimMiniMap call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_minimap_toggle")
 		`
-		}
+	}
+	if editor.opts.Server == "" {
 		gonvimCommands = gonvimCommands + `
 		command! GonvimWorkspaceNew call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_workspace_new")
 		command! GonvimWorkspaceNext call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_workspace_next")
 		command! GonvimWorkspacePrevious call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_workspace_previous")
 		command! -nargs=1 GonvimWorkspaceSwitch call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_workspace_switch", <args>)
+		command! GonvimWorkspaceClose call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_workspace_close")
+		command! -nargs=? GonvimWorkspaceRename call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_workspace_rename", <q-args>)
+		command! GonvimWorkspaceMoveUp call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_workspace_move", -1)
+		command! GonvimWorkspaceMoveDown call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_workspace_move", 1)
+		command! GonvimWorkspaceDuplicate call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_workspace_duplicate")
+		command! GonvimWorkspaceDetach call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_workspace_detach")
+		command! GonvimWorkspaceAttachBack call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_workspace_attach_back")
+		`
+	}
+	if editor.recent != nil {
+		gonvimCommands = gonvimCommands + `
+		command! GonvimStartPage call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_start_page")
+		command! -nargs=1 GonvimSessionSave call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_session_save", <q-args>)
+		command! -nargs=1 GonvimSessionLoad call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_session_load", <q-args>)
 		`
 	}
 	gonvimCommands = gonvimCommands + `
+	command! GonvimFind call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_find")
+	command! -nargs=? GonvimGrep call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_grep", <q-args>)
+	command! GonvimCommandPalette call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_command_palette")
 	command! -nargs=1 GonvimGridFont call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_grid_font", <args>)
 	command! -nargs=1 GonvimLetterSpacing call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_letter_spacing", <args>)
+	command! GonvimZoomIn call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_zoom_in")
+	command! GonvimZoomOut call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_zoom_out")
+	command! GonvimZoomReset call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_zoom_reset")
 	command! -nargs=1 GuiMacmeta call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_macmeta", <args>)
 	command! -nargs=? GonvimMaximize call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_maximize", <args>)
 	command! -nargs=? GonvimFullscreen call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_fullscreen", <args>)
@@ -476,6 +577,681 @@ func setGoneovimCommands(neovim *nvim.Nvim) {
 	neovim.Command(registerScripts)
 }
 
+func setGoneovimLuaAPI(neovim *nvim.Nvim) {
+	// Definition of the Lua functions that goneovim provides
+	code := `
+    _G.goneovim = _G.goneovim or {}
+
+    -- goneovim.set_highlight_font("Comment", { family = "Victor Mono", style = "italic", weight = "light", scale = 1.0 })
+    -- Calling with no options removes the font override of the group.
+    function goneovim.set_highlight_font(group, opts)
+        vim.rpcnotify(vim.g.goneovim_channel_id, 'Gui', 'gonvim_highlight_font', group, opts or vim.empty_dict())
+    end
+
+    -- Handles the zoom shortcut of the GUI. The key is passed to nvim as usual
+    -- if it is mapped in the current mode, otherwise the workspace is zoomed.
+    function goneovim.zoom_key(key, event)
+        local mode = vim.api.nvim_get_mode().mode
+        local m = mode:sub(1, 1)
+        if mode:sub(1, 2) == 'no' then
+            m = 'o'
+        else
+            m = ({ V = 'x', ['\22'] = 'x', S = 's', ['\19'] = 's', R = 'i' })[m] or m
+        end
+        if vim.fn.maparg(key, m) ~= '' then
+            vim.api.nvim_input(key)
+            return
+        end
+        vim.rpcnotify(vim.g.goneovim_channel_id, 'Gui', event)
+    end
+
+    -- Notifies leftcol and the width of the longest visible line of the windows
+    -- in the current tabpage, for the horizontal scrollbar. The lines are
+    -- walked only in the 'nowrap' windows, which have the scrollbar.
+    function goneovim.notify_horizontal_scroll()
+        for _, win in ipairs(vim.api.nvim_tabpage_list_wins(0)) do
+            local info = vim.fn.getwininfo(win)[1]
+            local wrap = vim.wo[win].wrap
+            local view = { 0, 0 }
+            if not wrap then
+                view = vim.api.nvim_win_call(win, function()
+                    local widest = 0
+                    for lnum = info.topline, info.botline do
+                        widest = math.max(widest, vim.fn.virtcol({ lnum, '$' }) - 1)
+                    end
+                    return { vim.fn.winsaveview().leftcol, widest }
+                end)
+            end
+            vim.rpcnotify(vim.g.goneovim_channel_id, 'Gui', 'gonvim_horizontal_scroll',
+                win, view[1], view[2], info.width - info.textoff, wrap)
+        end
+    end
+
+    -- Calls notify_horizontal_scroll after the text stops changing for a
+    -- while, so that typing does not walk the lines for every key.
+    function goneovim.notify_horizontal_scroll_later()
+        if not goneovim.hscroll_timer then
+            goneovim.hscroll_timer = vim.loop.new_timer()
+        end
+        goneovim.hscroll_timer:stop()
+        goneovim.hscroll_timer:start(100, 0, vim.schedule_wrap(goneovim.notify_horizontal_scroll))
+    end
+
+    local function hl_color(name, attr)
+        local ok, hl = pcall(vim.api.nvim_get_hl, 0, { name = name, link = false })
+        if not ok or not hl[attr] then
+            return ''
+        end
+        return string.format('#%06x', hl[attr])
+    end
+
+    -- Notifies the colors of the IndentGuideLevel1, IndentGuideLevel2, ...
+    -- and IndentGuideScope highlight groups for the indent guides.
+    function goneovim.notify_indent_guide_colors()
+        local levels = {}
+        for level = 1, 16 do
+            local color = hl_color('IndentGuideLevel' .. level, 'fg')
+            if color == '' then
+                break
+            end
+            levels[level] = color
+        end
+        vim.rpcnotify(vim.g.goneovim_channel_id, 'Gui', 'gonvim_indent_guide_colors', levels, hl_color('IndentGuideScope', 'fg'))
+    end
+
+    -- Notifies the number of the modified buffers
+    -- for the badge of the workspace in the sidebar.
+    function goneovim.notify_modified_buffers()
+        local count = 0
+        for _, buf in ipairs(vim.api.nvim_list_bufs()) do
+            if vim.bo[buf].buflisted and vim.bo[buf].modified then
+                count = count + 1
+            end
+        end
+        vim.rpcnotify(vim.g.goneovim_channel_id, 'Gui', 'gonvim_modified_buffers', count)
+    end
+
+    -- Notifies the git status of the repository of the cwd
+    -- for the sidebar. The root is empty out of a repository.
+    -- git runs in jobs so that nvim is not blocked in a large repository,
+    -- and only the status of the latest call is notified.
+    function goneovim.notify_git_status()
+        goneovim.git_status_seq = (goneovim.git_status_seq or 0) + 1
+        local seq = goneovim.git_status_seq
+        local function notify(root, lines)
+            if seq == goneovim.git_status_seq then
+                vim.rpcnotify(vim.g.goneovim_channel_id, 'Gui', 'gonvim_git_status', root, lines)
+            end
+        end
+        local function run(args, on_done)
+            local out = {}
+            local job = vim.fn.jobstart(args, {
+                stdin = 'null',
+                stdout_buffered = true,
+                on_stdout = function(_, data)
+                    out = data
+                end,
+                on_exit = function(_, code)
+                    if out[#out] == '' then
+                        table.remove(out)
+                    end
+                    on_done(code == 0 and out or nil)
+                end,
+            })
+            if job <= 0 then
+                on_done(nil)
+            end
+        end
+        if vim.fn.executable('git') ~= 1 then
+            notify('', {})
+            return
+        end
+        run({ 'git', '-C', vim.fn.getcwd(), 'rev-parse', '--show-toplevel' }, function(out)
+            if not out or #out == 0 then
+                notify('', {})
+                return
+            end
+            local root = out[1]
+            local args = { 'git', '-C', root, '-c', 'core.quotePath=false', 'status', '--porcelain=v1', '--branch' }
+            -- The ignored files are marked only when the filer shows them.
+            if _G.goneovim_filer and goneovim_filer.show_ignored then
+                table.insert(args, '--ignored=matching')
+            end
+            run(args, function(lines)
+                if lines then
+                    notify(root, lines)
+                else
+                    notify('', {})
+                end
+            end)
+        end)
+    end
+
+    -- Runs git with args in the repository at root without blocking nvim.
+    -- When git exits, the status is notified again, and the error message
+    -- is shown if it fails.
+    function goneovim.git(root, args)
+        local out = {}
+        local function collect(_, data)
+            vim.list_extend(out, data)
+        end
+        local job = vim.fn.jobstart(vim.list_extend({ 'git', '-C', root }, args), {
+            stdin = 'null',
+            stdout_buffered = true,
+            stderr_buffered = true,
+            on_stdout = collect,
+            on_stderr = collect,
+            on_exit = function(_, code)
+                goneovim.notify_git_status()
+                if code ~= 0 then
+                    vim.notify('goneovim: ' .. vim.trim(table.concat(out, '\n')), vim.log.levels.ERROR)
+                end
+            end,
+        })
+        if job <= 0 then
+            vim.notify('goneovim: failed to run git', vim.log.levels.ERROR)
+        end
+    end
+
+    -- Opens the file at path in the repository at root in a vertical
+    -- diffsplit with the version in HEAD.
+    function goneovim.git_diff(root, path)
+        vim.cmd('tab drop ' .. vim.fn.fnameescape(root .. '/' .. path))
+        local lines = vim.fn.systemlist({ 'git', '-C', root, 'show', 'HEAD:' .. path })
+        if vim.v.shell_error ~= 0 then
+            return
+        end
+        local tmp = vim.fn.tempname() .. '-HEAD-' .. vim.fn.fnamemodify(path, ':t')
+        vim.fn.writefile(lines, tmp)
+        vim.cmd('vertical diffsplit ' .. vim.fn.fnameescape(tmp))
+        vim.bo.readonly = true
+        vim.bo.bufhidden = 'wipe'
+    end
+
+    -- Lists the files under the cwd for GonvimFind, and notifies them in
+    -- batches so that the finder ranks them while they are listed.
+    -- The files which git ignores are skipped.
+    function goneovim.find_files(id)
+        goneovim.find_stop()
+        local cwd = vim.fn.getcwd()
+        local function notify(files, done)
+            vim.rpcnotify(vim.g.goneovim_channel_id, 'Gui', 'gonvim_find_files', id, cwd, files, done)
+        end
+
+        local cmd
+        if vim.fn.executable('git') == 1 then
+            vim.fn.system({ 'git', '-C', cwd, 'rev-parse', '--is-inside-work-tree' })
+            if vim.v.shell_error == 0 then
+                cmd = { 'git', '-c', 'core.quotePath=false', 'ls-files', '--cached', '--others', '--exclude-standard' }
+            end
+        end
+        if not cmd and vim.fn.executable('rg') == 1 then
+            cmd = { 'rg', '--files' }
+        end
+
+        local files = {}
+        if not cmd then
+            local iter = vim.fs.dir(cwd, { depth = 64, skip = function(name) return name ~= '.git' end })
+            local function step()
+                for name, typ in iter do
+                    if typ == 'file' or typ == 'link' then
+                        files[#files + 1] = name
+                        if #files >= 5000 then
+                            notify(files, false)
+                            files = {}
+                            goneovim.find_timer = vim.defer_fn(step, 0)
+                            return
+                        end
+                    end
+                end
+                goneovim.find_timer = nil
+                notify(files, true)
+            end
+            step()
+            return
+        end
+
+        local rest = ''
+        goneovim.find_job = vim.fn.jobstart(cmd, {
+            cwd = cwd,
+            on_stdout = function(_, data)
+                data[1] = rest .. data[1]
+                rest = table.remove(data)
+                for _, line in ipairs(data) do
+                    if line ~= '' then
+                        files[#files + 1] = line
+                    end
+                end
+                if #files >= 5000 then
+                    notify(files, false)
+                    files = {}
+                end
+            end,
+            on_exit = function(job)
+                if goneovim.find_job == job then
+                    goneovim.find_job = nil
+                end
+                if rest ~= '' then
+                    files[#files + 1] = rest
+                end
+                notify(files, true)
+            end,
+        })
+    end
+
+    -- Stops listing the files for GonvimFind.
+    function goneovim.find_stop()
+        if goneovim.find_job then
+            vim.fn.jobstop(goneovim.find_job)
+            goneovim.find_job = nil
+        end
+        if goneovim.find_timer then
+            goneovim.find_timer:stop()
+            goneovim.find_timer = nil
+        end
+    end
+
+    -- Returns the head of the file for the preview of GonvimFind.
+    function goneovim.find_preview(path, max)
+        local ok, lines = pcall(vim.fn.readfile, path, '', max)
+        if not ok then
+            return {}
+        end
+        return lines
+    end
+
+    -- Runs ripgrep in the cwd for GonvimGrep, and notifies the JSON lines
+    -- which it prints in batches. Returns false if ripgrep is not found.
+    function goneovim.grep(id, args)
+        goneovim.grep_stop()
+        if vim.fn.executable('rg') ~= 1 then
+            return false
+        end
+        local cwd = vim.fn.getcwd()
+        local lines = {}
+        local rest = ''
+        local function notify(done)
+            vim.rpcnotify(vim.g.goneovim_channel_id, 'Gui', 'gonvim_grep_results', id, cwd, lines, done)
+            lines = {}
+        end
+        goneovim.grep_job = vim.fn.jobstart(args, {
+            cwd = cwd,
+            stdin = 'null',
+            on_stdout = function(_, data)
+                data[1] = rest .. data[1]
+                rest = table.remove(data)
+                for _, line in ipairs(data) do
+                    if line ~= '' then
+                        lines[#lines + 1] = line
+                    end
+                end
+                if #lines >= 500 then
+                    notify(false)
+                end
+            end,
+            on_exit = function(job)
+                if goneovim.grep_job == job then
+                    goneovim.grep_job = nil
+                end
+                if rest ~= '' then
+                    lines[#lines + 1] = rest
+                end
+                notify(true)
+            end,
+        })
+        return true
+    end
+
+    -- Stops the search of GonvimGrep.
+    function goneovim.grep_stop()
+        if goneovim.grep_job then
+            vim.fn.jobstop(goneovim.grep_job)
+            goneovim.grep_job = nil
+        end
+    end
+
+    -- Replaces the lines of the files for GonvimGrep. changes maps the path
+    -- to the list of { lnum, old, new }. The lines are replaced in the
+    -- buffers, which are left modified so that the replacement can be undone,
+    -- and are skipped if they have been changed since the search.
+    -- Returns the number of the files and the lines replaced.
+    function goneovim.grep_replace(root, changes)
+        local files, count = 0, 0
+        for path, lines in pairs(changes) do
+            local bufnr = vim.fn.bufadd(root .. '/' .. path)
+            vim.fn.bufload(bufnr)
+            vim.bo[bufnr].buflisted = true
+            local replaced = 0
+            for _, change in ipairs(lines) do
+                local lnum, old, new = change[1], change[2], change[3]
+                local current = vim.api.nvim_buf_get_lines(bufnr, lnum - 1, lnum, false)[1]
+                if current == old then
+                    vim.api.nvim_buf_set_lines(bufnr, lnum - 1, lnum, false, { new })
+                    replaced = replaced + 1
+                end
+            end
+            if replaced > 0 then
+                files = files + 1
+                count = count + replaced
+            end
+        end
+        return { files, count }
+    end
+
+    -- Returns the user commands as { name, nargs, definition } and the normal
+    -- mode mappings which have the description as { lhs, desc } for the
+    -- command palette. The buffer-local ones come first.
+    function goneovim.palette_entries()
+        local commands = {}
+        for _, cmds in ipairs({ vim.api.nvim_buf_get_commands(0, {}), vim.api.nvim_get_commands({}) }) do
+            for name, cmd in pairs(cmds) do
+                commands[#commands + 1] = { name, cmd.nargs or '0', cmd.definition or '' }
+            end
+        end
+        local mappings = {}
+        for _, maps in ipairs({ vim.api.nvim_buf_get_keymap(0, 'n'), vim.api.nvim_get_keymap('n') }) do
+            for _, map in ipairs(maps) do
+                if map.desc and map.desc ~= '' then
+                    mappings[#mappings + 1] = { map.lhs, map.desc }
+                end
+            end
+        end
+        return { commands, mappings }
+    end
+
+    -- Runs the mapping chosen in the command palette.
+    function goneovim.palette_feedkeys(lhs)
+        vim.api.nvim_feedkeys(vim.api.nvim_replace_termcodes(lhs, true, false, true), 'm', false)
+    end
+
+    -- Calls the callback of vim.ui.select or vim.ui.input shown in the GUI
+    -- with the result, which is nil if it is canceled.
+    function goneovim.ui_done(id, result)
+        local callback = goneovim.ui_callbacks and goneovim.ui_callbacks[id]
+        if not callback then
+            return
+        end
+        goneovim.ui_callbacks[id] = nil
+        if result == vim.NIL then
+            result = nil
+        end
+        vim.schedule(function()
+            callback(result)
+        end)
+    end
+
+    -- The search pattern and whether it is highlighted, which the search
+    -- marks are updated for.
+    local function search_state()
+        return vim.fn.getreg('/') .. '\n' .. vim.v.hlsearch
+    end
+
+    -- Calls notify_scrollbar_marks after the changes stop for a while,
+    -- since the marks are searched in the whole buffer.
+    function goneovim.notify_scrollbar_marks_later()
+        if not goneovim.marks_timer then
+            goneovim.marks_timer = vim.loop.new_timer()
+        end
+        goneovim.marks_timer:stop()
+        goneovim.marks_timer:start(150, 0, vim.schedule_wrap(goneovim.notify_scrollbar_marks))
+    end
+
+    -- Updates the marks if the search pattern or the highlight of it has
+    -- changed, which n, * and so on do without an autocmd.
+    function goneovim.check_search_marks()
+        if goneovim.search_marks_state ~= search_state() then
+            goneovim.search_marks_state = search_state()
+            goneovim.notify_scrollbar_marks_later()
+        end
+    end
+
+    -- Notifies the lines of the current buffer to be marked on the overview
+    -- ruler of the scrollbar and on the minimap: search matches, diagnostics
+    -- and diff changes.
+    function goneovim.notify_scrollbar_marks()
+        local bufnr = vim.api.nvim_get_current_buf()
+        local line_count = vim.api.nvim_buf_line_count(bufnr)
+        local limit = 5000
+        local marks = {}
+        local function add(lnum, kind)
+            if #marks < limit then
+                marks[#marks + 1] = { lnum, kind }
+            end
+        end
+
+        goneovim.search_marks_state = search_state()
+        local pattern = vim.fn.getreg('/')
+        if vim.o.hlsearch and vim.v.hlsearch == 1 and pattern ~= '' then
+            if vim.o.ignorecase and not (vim.o.smartcase and pattern:find('%u')) then
+                pattern = '\\c' .. pattern
+            end
+            if vim.fn.exists('*matchbufline') == 1 then
+                local ok, matches = pcall(vim.fn.matchbufline, bufnr, pattern, 1, '$')
+                local last = 0
+                for _, m in ipairs(ok and matches or {}) do
+                    if #marks >= limit then
+                        break
+                    end
+                    if m.lnum ~= last then
+                        add(m.lnum, 'search')
+                        last = m.lnum
+                    end
+                end
+            else
+                -- Without matchbufline, the lines are matched one by one,
+                -- so only the first lines of a large buffer are scanned.
+                local ok, re = pcall(vim.regex, pattern)
+                if ok then
+                    for lnum = 0, math.min(line_count, 20000) - 1 do
+                        if #marks >= limit then
+                            break
+                        end
+                        if re:match_line(bufnr, lnum) then
+                            add(lnum + 1, 'search')
+                        end
+                    end
+                end
+            end
+        end
+
+        local severities = { 'error', 'warn', 'info', 'hint' }
+        for _, d in ipairs(vim.diagnostic.get(bufnr)) do
+            add(d.lnum + 1, severities[d.severity] or 'hint')
+        end
+
+        if vim.wo.diff then
+            for lnum = 1, line_count do
+                local name = vim.fn.synIDattr(vim.fn.diff_hlID(lnum, 1), 'name')
+                if name == 'DiffAdd' then
+                    add(lnum, 'add')
+                elseif name == 'DiffChange' or name == 'DiffText' then
+                    add(lnum, 'change')
+                end
+                if vim.fn.diff_filler(lnum) > 0 then
+                    add(lnum, 'delete')
+                end
+            end
+        else
+            local ok, gitsigns = pcall(require, 'gitsigns')
+            local hunks = ok and gitsigns.get_hunks and gitsigns.get_hunks(bufnr) or {}
+            for _, hunk in ipairs(hunks) do
+                if hunk.type == 'delete' then
+                    add(math.max(hunk.added.start, 1), 'delete')
+                else
+                    for lnum = hunk.added.start, hunk.added.start + hunk.added.count - 1 do
+                        add(lnum, hunk.type)
+                    end
+                end
+            end
+        end
+
+        local colors = {
+            search = hl_color('Search', 'bg'),
+            error = hl_color('DiagnosticError', 'fg'),
+            warn = hl_color('DiagnosticWarn', 'fg'),
+            info = hl_color('DiagnosticInfo', 'fg'),
+            hint = hl_color('DiagnosticHint', 'fg'),
+            add = hl_color('DiffAdd', 'bg'),
+            change = hl_color('DiffChange', 'bg'),
+            delete = hl_color('DiffDelete', 'bg'),
+            cursor = hl_color('CursorLineNr', 'fg'),
+        }
+        vim.rpcnotify(vim.g.goneovim_channel_id, 'Gui', 'gonvim_scrollbar_marks', line_count, marks, colors)
+    end
+
+    -- The foreground colors of the highlight groups used by the minimap.
+    -- A capture like @keyword.function.lua falls back to @keyword.function
+    -- and @keyword when it has no color of its own.
+    local minimap_colors = {}
+    local minimap_colors_name = nil
+    local function minimap_color(group)
+        if vim.g.colors_name ~= minimap_colors_name then
+            minimap_colors = {}
+            minimap_colors_name = vim.g.colors_name
+        end
+        local color = minimap_colors[group]
+        if color == nil then
+            color = -1
+            local name = group
+            while name do
+                local ok, hl = pcall(vim.api.nvim_get_hl, 0, { name = name, link = false })
+                if ok and hl.fg then
+                    color = hl.fg
+                    break
+                end
+                name = name:match('^(.+)%.[^.]+$')
+            end
+            minimap_colors[group] = color
+        end
+        return color
+    end
+
+    -- Returns the lines from topline of the current buffer which fit in the
+    -- minimap of rows and cols, split into runs of the same color.
+    -- A topline less than 1 centers the cursor line.
+    function goneovim.minimap_chunks(topline, rows, cols)
+        local win = vim.api.nvim_get_current_win()
+        if vim.api.nvim_win_get_config(win).relative ~= '' then
+            return vim.NIL
+        end
+        local bufnr = vim.api.nvim_get_current_buf()
+        local count = vim.api.nvim_buf_line_count(bufnr)
+        local cur = vim.api.nvim_win_get_cursor(win)[1]
+        local top = topline
+        if top < 1 then
+            top = cur - math.floor(rows / 2)
+        end
+        top = math.max(1, math.min(top, count - rows + 1))
+        local bot = math.min(count + 1, top + rows)
+        local texts = vim.api.nvim_buf_get_lines(bufnr, top - 1, bot - 1, false)
+
+        -- The group of every byte, painted in the order of priority.
+        local groups = {}
+        for i = 1, #texts do
+            groups[i] = {}
+        end
+        local function paint(row, scol, ecol, group)
+            local line = groups[row - top + 2]
+            if not line then
+                return
+            end
+            for col = scol + 1, math.min(ecol, cols * 4) do
+                line[col] = group
+            end
+        end
+
+        local highlighter = vim.treesitter.highlighter
+        if highlighter and highlighter.active[bufnr] then
+            local ok, parser = pcall(vim.treesitter.get_parser, bufnr)
+            if ok and parser then
+                parser:for_each_tree(function(tstree, tree)
+                    local lang = tree:lang()
+                    local query = vim.treesitter.query.get(lang, 'highlights')
+                    if not query then
+                        return
+                    end
+                    for id, node in query:iter_captures(tstree:root(), bufnr, top - 1, bot - 1) do
+                        local group = '@' .. query.captures[id] .. '.' .. lang
+                        local srow, scol, erow, ecol = node:range()
+                        for row = math.max(srow, top - 1), math.min(erow, bot - 2) do
+                            paint(row, row == srow and scol or 0, row == erow and ecol or cols * 4, group)
+                        end
+                    end
+                end)
+            end
+        elseif vim.bo[bufnr].syntax ~= '' then
+            for i, text in ipairs(texts) do
+                for scol, ecol in text:gmatch('()%S+()') do
+                    if scol > cols * 4 then
+                        break
+                    end
+                    local id = vim.fn.synIDtrans(vim.fn.synID(top + i - 1, scol, 1))
+                    if id > 0 then
+                        paint(top + i - 2, scol - 1, ecol - 1, vim.fn.synIDattr(id, 'name'))
+                    end
+                end
+            end
+        end
+
+        local extmarks = vim.api.nvim_buf_get_extmarks(bufnr, -1, { top - 1, 0 }, { bot - 2, -1 },
+            { details = true, type = 'highlight' })
+        for _, mark in ipairs(extmarks) do
+            local row, col, details = mark[2], mark[3], mark[4]
+            if details.hl_group and details.end_row then
+                for r = math.max(row, top - 1), math.min(details.end_row, bot - 2) do
+                    paint(r, r == row and col or 0, r == details.end_row and details.end_col or cols * 4, details.hl_group)
+                end
+            end
+        end
+
+        local lines = {}
+        for i, text in ipairs(texts) do
+            local flat = {}
+            local run, run_group, width = {}, nil, 0
+            local function flush()
+                if #run > 0 then
+                    flat[#flat + 1] = table.concat(run)
+                    flat[#flat + 1] = run_group and minimap_color(run_group) or -1
+                end
+                run = {}
+            end
+            local col = 1
+            while col <= #text and width < cols do
+                local byte = text:byte(col)
+                local len, cell = 1, nil
+                if byte == 9 then
+                    cell = string.rep(' ', vim.bo[bufnr].tabstop - width % vim.bo[bufnr].tabstop)
+                elseif byte < 32 then
+                    cell = '^'
+                elseif byte < 128 then
+                    cell = string.char(byte)
+                else
+                    len = byte >= 240 and 4 or byte >= 224 and 3 or byte >= 192 and 2 or 1
+                    cell = string.rep('#', vim.fn.strdisplaywidth(text:sub(col, col + len - 1)))
+                end
+                local group = groups[i][col]
+                if group ~= run_group then
+                    flush()
+                    run_group = group
+                end
+                run[#run + 1] = cell
+                width = width + #cell
+                col = col + len
+            end
+            flush()
+            lines[i] = flat
+        end
+
+        return { top = top, bot = bot, cur = cur, count = count, lines = lines }
+    end`
+	var result, args interface{}
+	neovim.ExecLua(
+		code,
+		&result,
+		args,
+	)
+}
+
 func setGoneovimClipBoard(neovim *nvim.Nvim) {
 	editor.putLog("set clipboard")
 	code := `
@@ -513,6 +1289,74 @@ func setGoneovimClipBoard(neovim *nvim.Nvim) {
 	}
 }
 
+// setGoneovimUI replaces vim.ui.select and vim.ui.input with the shims which
+// show them in the GUI. The request returns at once, and the GUI returns the
+// result to the callback with goneovim.ui_done later, so that nvim is not
+// blocked while the user chooses. The shims fall back to the original ones
+// if the GUI does not show them.
+func setGoneovimUI(neovim *nvim.Nvim) {
+	if !editor.config.Editor.UISelect && !editor.config.Editor.UIInput {
+		return
+	}
+	editor.putLog("set vim.ui")
+	code := `
+    local use_select, use_input = ...
+    _G.goneovim = _G.goneovim or {}
+    goneovim.ui_callbacks = goneovim.ui_callbacks or {}
+    goneovim.ui_id = goneovim.ui_id or 0
+    goneovim.ui_fallback = goneovim.ui_fallback or { select = vim.ui.select, input = vim.ui.input }
+
+    local function request(method, callback, ...)
+        goneovim.ui_id = goneovim.ui_id + 1
+        local id = goneovim.ui_id
+        goneovim.ui_callbacks[id] = callback
+        local ok, shown = pcall(vim.rpcrequest, vim.g.goneovim_channel_id, method, id, ...)
+        if ok and shown then
+            return true
+        end
+        goneovim.ui_callbacks[id] = nil
+        return false
+    end
+
+    if use_select then
+        vim.ui.select = function(items, opts, on_choice)
+            opts = opts or {}
+            local format_item = opts.format_item or tostring
+            local texts = {}
+            for i, item in ipairs(items) do
+                texts[i] = tostring(format_item(item))
+            end
+            local shown = request('goneovim.ui_select', function(index)
+                if index then
+                    on_choice(items[index], index)
+                else
+                    on_choice(nil, nil)
+                end
+            end, texts, opts.prompt or 'Select one of:')
+            if not shown then
+                goneovim.ui_fallback.select(items, opts, on_choice)
+            end
+        end
+    end
+
+    if use_input then
+        vim.ui.input = function(opts, on_confirm)
+            opts = opts or {}
+            local shown = request('goneovim.ui_input', on_confirm, opts.prompt or '', opts.default or '')
+            if not shown then
+                goneovim.ui_fallback.input(opts, on_confirm)
+            end
+        end
+    end`
+	var result interface{}
+	neovim.ExecLua(
+		code,
+		&result,
+		editor.config.Editor.UISelect,
+		editor.config.Editor.UIInput,
+	)
+}
+
 func setVar(neovim *nvim.Nvim) {
 	go neovim.SetVar("goneovim_channel_id", neovim.ChannelID())
 }
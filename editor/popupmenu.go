@@ -690,7 +690,7 @@ func (p *PopupItem) setItem(item []interface{}, selected bool) {
 }
 
 func (p *PopupMenu) detectVimCompleteMode() (string, error) {
-	ws := editor.workspaces[editor.active]
+	ws := p.ws
 
 	var hasCompleteMode int
 	err := ws.nvim.Eval("exists('*complete_info')", &hasCompleteMode)
@@ -716,14 +716,15 @@ func (p *PopupItem) setKind(kind string, selected bool) {
 	if p == nil {
 		return
 	}
-	if p.kind == kind && kind != "" {
+	// The icon of the color kind shows the color of each item.
+	if p.kind == kind && kind != "" && normalizeKind(kind) != "color" {
 		return
 	}
 	p.kind = kind
 
 	var colorOfFunc, colorOfStatement, colorOfType, colorOfKeyword *RGBA
 
-	hiAttrDef := editor.workspaces[editor.active].screen.hlAttrDef
+	hiAttrDef := p.p.ws.screen.hlAttrDef
 	var keys []int
 	for k := range hiAttrDef {
 		keys = append(keys, k)
@@ -772,7 +773,11 @@ func (p *PopupItem) setKind(kind string, selected bool) {
 	case "snippet":
 		icon = editor.getSvg("lsp_"+formattedKind, colorOfKeyword)
 	case "color":
-		icon = editor.getSvg("lsp_"+formattedKind, colorOfKeyword)
+		if color := p.completionColor(); color != nil {
+			icon = colorSwatchSvg(color)
+		} else {
+			icon = editor.getSvg("lsp_"+formattedKind, colorOfKeyword)
+		}
 	case "file":
 		icon = editor.getSvg("lsp_"+formattedKind, colorOfType)
 	case "reference":
@@ -818,6 +823,21 @@ func (p *PopupItem) setKind(kind string, selected bool) {
 
 }
 
+// completionColor returns the color of the completion item of the color kind,
+// which is found in the word, the menu or the info of the item.
+func (p *PopupItem) completionColor() *RGBA {
+	if !editor.config.Editor.ColorSwatch {
+		return nil
+	}
+	for _, text := range []string{p.wordRequest, p.menuRequest, p.infoRequest} {
+		if literals := findColorLiterals(text, true); len(literals) > 0 {
+			return literals[0].color
+		}
+	}
+
+	return nil
+}
+
 func normalizeKind(kind string) string {
 	// Completion kinds is
 	//   Text
@@ -0,0 +1,239 @@
+package editor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The kinds of the recently used entries.
+const (
+	recentProject = "project"
+	recentSession = "session"
+	recentFile    = "file"
+)
+
+// recentEntry is a recently used project directory, named session or file.
+type recentEntry struct {
+	Kind string `json:"kind"`
+	Path string `json:"path"`
+	Time int64  `json:"time"`
+}
+
+// recentList is the MRU list of the entries which is stored in the config dir
+// and shown in the start page.
+type recentList struct {
+	mu   sync.Mutex
+	path string
+	max  int
+	// remote is true when nvim runs over --server, --ssh or WSL, where
+	// the paths are of the remote host and are not checked locally.
+	remote  bool
+	entries []recentEntry
+}
+
+// recentMatch is an entry which matches the pattern of the start page.
+type recentMatch struct {
+	entry recentEntry
+	match []int
+	score int
+}
+
+func newRecentList(configDir string, max int, remote bool) *recentList {
+	r := &recentList{
+		path:   filepath.Join(configDir, "recent.json"),
+		max:    max,
+		remote: remote,
+	}
+	r.entries = loadRecentEntries(r.path)
+
+	return r
+}
+
+func loadRecentEntries(path string) []recentEntry {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var entries []recentEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil
+	}
+
+	return entries
+}
+
+func saveRecentEntries(path string, entries []recentEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// addRecentEntry moves the entry to the head of the entries, and drops
+// the oldest entries of the kind over max.
+func addRecentEntry(entries []recentEntry, entry recentEntry, max int) []recentEntry {
+	result := []recentEntry{entry}
+	count := 1
+	for _, e := range entries {
+		if e.Kind == entry.Kind && e.Path == entry.Path {
+			continue
+		}
+		if e.Kind == entry.Kind {
+			if count >= max {
+				continue
+			}
+			count++
+		}
+		result = append(result, e)
+	}
+
+	return result
+}
+
+// add records the path as the most recently used entry of the kind.
+func (r *recentList) add(kind, path string) {
+	if r == nil || path == "" {
+		return
+	}
+	if !r.remote {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.entries {
+		if e.Kind != kind {
+			continue
+		}
+		// It is already the most recent one.
+		if e.Path == path {
+			return
+		}
+		break
+	}
+	r.entries = addRecentEntry(r.entries, recentEntry{kind, path, time.Now().Unix()}, r.max)
+	if err := saveRecentEntries(r.path, r.entries); err != nil {
+		editor.putLog("failed to save the recent entries:", err)
+	}
+}
+
+// list returns the entries of the kind which still exist.
+// The remote entries are returned without the check.
+func (r *recentList) list(kind string) []recentEntry {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var entries []recentEntry
+	for _, e := range r.entries {
+		if e.Kind != kind {
+			continue
+		}
+		if !r.remote {
+			if _, err := os.Stat(e.Path); err != nil {
+				continue
+			}
+		}
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+// fuzzyMatch reports whether the runes of pattern appear in text in order,
+// ignoring case. It returns the byte offsets of the matched runes in text,
+// which formatText highlights, and the score, which is higher for the
+// consecutive matches and the matches at the start of the words.
+func fuzzyMatch(pattern, text string) ([]int, int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	if len(p) == 0 {
+		return nil, 0, true
+	}
+
+	var match []int
+	score := 0
+	j := 0
+	prev := ' '
+	next := -1
+	for i, char := range text {
+		if j < len(p) && unicode.ToLower(char) == p[j] {
+			score++
+			if i == next {
+				score += 3
+			}
+			if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+				score += 2
+			}
+			match = append(match, i)
+			next = i + utf8.RuneLen(char)
+			j++
+		}
+		prev = char
+	}
+	if j < len(p) {
+		return nil, 0, false
+	}
+
+	return match, score, true
+}
+
+// filterRecentEntries returns the entries which match the pattern, in the
+// order of the score. The entries of the same score keep the MRU order.
+func filterRecentEntries(entries []recentEntry, pattern string) []recentMatch {
+	var matches []recentMatch
+	for _, e := range entries {
+		match, score, ok := fuzzyMatch(pattern, e.Path)
+		if !ok {
+			continue
+		}
+		matches = append(matches, recentMatch{e, match, score})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	return matches
+}
+
+// hasFileArgs reports whether the nvim arguments have the files to edit,
+// other than the options and their values. The options which open a session,
+// a tag or a quickfix list count as the files.
+func hasFileArgs(args []string) bool {
+	// The options which take the next argument as the value.
+	withValue := map[string]bool{
+		"-c": true, "--cmd": true, "-u": true, "-i": true, "-s": true,
+		"-w": true, "-W": true, "-T": true, "--listen": true, "--startuptime": true,
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return i+1 < len(args)
+		case arg == "-" || arg == "-S" || arg == "-t" || arg == "-q":
+			return true
+		case withValue[arg]:
+			i++
+		case strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "+"):
+		default:
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,162 @@
+package editor
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAddRecentEntry(t *testing.T) {
+	a := recentEntry{Kind: recentFile, Path: "/a"}
+	b := recentEntry{Kind: recentFile, Path: "/b"}
+	c := recentEntry{Kind: recentFile, Path: "/c"}
+	p := recentEntry{Kind: recentProject, Path: "/a"}
+
+	tests := []struct {
+		name    string
+		entries []recentEntry
+		entry   recentEntry
+		max     int
+		want    []recentEntry
+	}{
+		{
+			"addRecentEntry() adds the entry to the head",
+			[]recentEntry{a, b},
+			c,
+			10,
+			[]recentEntry{c, a, b},
+		},
+		{
+			"addRecentEntry() moves the existing entry to the head",
+			[]recentEntry{a, b, c},
+			c,
+			10,
+			[]recentEntry{c, a, b},
+		},
+		{
+			"addRecentEntry() drops the oldest entry of the kind over max",
+			[]recentEntry{b, p, a},
+			c,
+			2,
+			[]recentEntry{c, b, p},
+		},
+		{
+			"addRecentEntry() distinguishes the kinds of the same path",
+			[]recentEntry{p},
+			a,
+			1,
+			[]recentEntry{a, p},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := addRecentEntry(tt.entries, tt.entry, tt.max); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("addRecentEntry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveAndLoadRecentEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "goneovim", "recent.json")
+	entries := []recentEntry{
+		{Kind: recentProject, Path: "/path/to/project", Time: 1},
+		{Kind: recentFile, Path: "/path/to/project/main.go", Time: 2},
+	}
+	if err := saveRecentEntries(path, entries); err != nil {
+		t.Fatal(err)
+	}
+	if got := loadRecentEntries(path); !reflect.DeepEqual(got, entries) {
+		t.Errorf("loadRecentEntries() = %v, want %v", got, entries)
+	}
+	if got := loadRecentEntries(filepath.Join(t.TempDir(), "missing.json")); got != nil {
+		t.Errorf("loadRecentEntries() = %v, want nil for the missing file", got)
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		pattern   string
+		text      string
+		wantMatch []int
+		wantOk    bool
+	}{
+		{"fuzzyMatch() matches everything with the empty pattern", "", "/a/b", nil, true},
+		{"fuzzyMatch() matches the runes in order", "ab", "/a/b", []int{1, 3}, true},
+		{"fuzzyMatch() ignores case", "MAIN", "main.go", []int{0, 1, 2, 3}, true},
+		{"fuzzyMatch() returns the byte offsets", "ab", "/ä/a/b", []int{4, 6}, true},
+		{"fuzzyMatch() does not match the runes out of order", "ba", "/a/b", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, _, ok := fuzzyMatch(tt.pattern, tt.text)
+			if ok != tt.wantOk {
+				t.Errorf("fuzzyMatch() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !reflect.DeepEqual(match, tt.wantMatch) {
+				t.Errorf("fuzzyMatch() match = %v, want %v", match, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestFilterRecentEntries(t *testing.T) {
+	entries := []recentEntry{
+		{Kind: recentFile, Path: "/src/xmain/x.go"},
+		{Kind: recentFile, Path: "/src/main.go"},
+		{Kind: recentFile, Path: "/src/util.go"},
+		{Kind: recentFile, Path: "/src/m/a/i/n.go"},
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+	}{
+		{
+			"filterRecentEntries() keeps the order without the pattern",
+			"",
+			[]string{"/src/xmain/x.go", "/src/main.go", "/src/util.go", "/src/m/a/i/n.go"},
+		},
+		{
+			"filterRecentEntries() puts the consecutive matches at the start of the words first",
+			"main",
+			[]string{"/src/main.go", "/src/xmain/x.go", "/src/m/a/i/n.go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, m := range filterRecentEntries(entries, tt.pattern) {
+				got = append(got, m.entry.Path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterRecentEntries() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasFileArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want bool
+	}{
+		{"hasFileArgs() returns false for no arguments", nil, false},
+		{"hasFileArgs() returns true for a file", []string{"main.go"}, true},
+		{"hasFileArgs() skips the values of the options", []string{"-u", "init.vim", "--cmd", "set lines=40"}, false},
+		{"hasFileArgs() skips the flags and the commands", []string{"-R", "+10", "--clean"}, false},
+		{"hasFileArgs() returns true for a file after the options", []string{"-c", "set nu", "main.go"}, true},
+		{"hasFileArgs() returns true for a file after --", []string{"--", "-file"}, true},
+		{"hasFileArgs() returns true for a session", []string{"-S"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasFileArgs(tt.args); got != tt.want {
+				t.Errorf("hasFileArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -30,6 +30,8 @@ type Screen struct {
 	fontwide          *Font
 	fallbackfontwides []*Font
 	hlAttrDef         map[int]*Highlight
+	hlFontSpecs       map[string]highlightFontConfig
+	hlFonts           map[hlFontKey]*Font
 	widget            *widgets.QWidget
 	ws                *Workspace
 	highlightGroup    map[string]int
@@ -41,6 +43,7 @@ type Screen struct {
 	resizeCount       uint
 	topLevelGrid      int
 	lastGridLineGrid  int
+	hlFontMutex       sync.RWMutex
 }
 
 type Cache struct {
@@ -59,6 +62,11 @@ func newScreen() *Screen {
 		highlightGroup: make(map[string]int),
 		cache:          newCache(editor.config.Editor.CacheSize),
 		bgcache:        newBrushCache(),
+		hlFontSpecs:    make(map[string]highlightFontConfig),
+		hlFonts:        make(map[hlFontKey]*Font),
+	}
+	for name, spec := range editor.highlightFontSpecs() {
+		screen.hlFontSpecs[name] = spec
 	}
 
 	widget.ConnectMousePressEvent(screen.mousePressEvent)
@@ -77,12 +85,14 @@ func (s *Screen) initInputMethodWidget() {
 	s.tooltip = tooltip
 }
 
-func fileOpenInBuf(file string) {
-	isModified, _ := editor.workspaces[editor.active].nvim.CommandOutput("echo &modified")
+// fileOpenInBuf opens the file dropped on the screen in nvim of the
+// workspace of the screen, which may be in a detached window.
+func (s *Screen) fileOpenInBuf(file string) {
+	isModified, _ := s.ws.nvim.CommandOutput("echo &modified")
 	if isModified == "1" {
-		editor.workspaces[editor.active].nvim.Command(fmt.Sprintf(":tabnew %s", file))
+		s.ws.nvim.Command(fmt.Sprintf(":tabnew %s", file))
 	} else {
-		editor.workspaces[editor.active].nvim.Command(fmt.Sprintf(":e %s", file))
+		s.ws.nvim.Command(fmt.Sprintf(":e %s", file))
 	}
 }
 
@@ -91,7 +101,7 @@ func (s *Screen) howToOpen(file string) {
 	opts := []*NotifyButton{}
 	opt1 := &NotifyButton{
 		action: func() {
-			editor.workspaces[editor.active].nvim.Command(fmt.Sprintf(":vertical diffsplit %s", file))
+			s.ws.nvim.Command(fmt.Sprintf(":vertical diffsplit %s", file))
 		},
 		text: "Yes",
 	}
@@ -99,7 +109,7 @@ func (s *Screen) howToOpen(file string) {
 
 	opt2 := &NotifyButton{
 		action: func() {
-			fileOpenInBuf(file)
+			s.fileOpenInBuf(file)
 		},
 		text: "No, I want to open with a new buffer",
 	}
@@ -324,36 +334,43 @@ func (s *Screen) gridFont(update interface{}) {
 	if win.font == nil {
 		return
 	}
+	win.font, win.fallbackfonts = scaleFonts(win.font, win.fallbackfonts, zoomFactor(s.ws.zoomLevel))
+
+	win.resizeToGridFont(oldWidth, oldHeight)
+
+	s.ws.cursor.font = win.getFont()
+	s.ws.cursor.fallbackfonts = win.fallbackfonts
+}
 
+// resizeToGridFont resizes the grid to fit its previous size in pixels
+// with the font set with GonvimGridFont.
+func (w *Window) resizeToGridFont(oldWidth float64, oldHeight int) {
 	// Calculate new cols, rows of current grid
-	newCols := int(oldWidth / win.font.cellwidth)
-	newRows := oldHeight / win.font.lineHeight
+	newCols := int(oldWidth / w.font.cellwidth)
+	newRows := oldHeight / w.font.lineHeight
 
 	// Cache
-	if win.cache == nil {
-		win.cache = newCache(editor.config.Editor.CacheSize)
+	if w.cache == nil {
+		w.cache = newCache(editor.config.Editor.CacheSize)
 	} else {
-		win.paintMutex.Lock()
-		win.cache.purge()
-		win.paintMutex.Unlock()
+		w.paintMutex.Lock()
+		w.cache.purge()
+		w.paintMutex.Unlock()
 	}
-	if win.cursorCache == nil {
-		win.cursorCache = newCache(editor.config.Editor.CacheSize)
+	if w.cursorCache == nil {
+		w.cursorCache = newCache(editor.config.Editor.CacheSize)
 	} else {
-		win.s.ws.cursor.paintMutex.Lock()
-		win.cursorCache.purge()
-		win.s.ws.cursor.paintMutex.Unlock()
+		w.s.ws.cursor.paintMutex.Lock()
+		w.cursorCache.purge()
+		w.s.ws.cursor.paintMutex.Unlock()
 	}
 
-	_ = s.ws.nvim.TryResizeUIGrid(win.grid, newCols, newRows)
-
-	s.ws.cursor.font = win.getFont()
-	s.ws.cursor.fallbackfonts = win.fallbackfonts
+	_ = w.s.ws.nvim.TryResizeUIGrid(w.grid, newCols, newRows)
 
-	if win.isExternal {
-		width := int(float64(newCols)*win.font.cellwidth) + EXTWINBORDERSIZE*2
-		height := newRows*win.font.lineHeight + EXTWINBORDERSIZE*2
-		win.extwin.Resize2(width, height)
+	if w.isExternal {
+		width := int(float64(newCols)*w.font.cellwidth) + EXTWINBORDERSIZE*2
+		height := newRows*w.font.lineHeight + EXTWINBORDERSIZE*2
+		w.extwin.Resize2(width, height)
 	}
 }
 
@@ -884,6 +901,7 @@ func (s *Screen) newWindowGrid(gridid int) (win *Window) {
 	// set scroll
 	if s.name != "minimap" {
 		win.ConnectWheelEvent(win.wheelEvent)
+		win.connectGestures()
 	}
 
 	return
@@ -1820,6 +1838,7 @@ func (s *Screen) windowExternalPosition(args []interface{}) {
 					editor.isExtWinNowActivated = false
 					editor.isExtWinNowInactivated = true
 				}
+				s.ws.cursor.updateFocus()
 			default:
 			}
 			return extwin.EventFilterDefault(watched, event)
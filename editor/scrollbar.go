@@ -11,12 +11,50 @@ import (
 	"github.com/akiyosi/qt/widgets"
 )
 
+const scrollBarMinThumbSize = 20
+
+// scrollBarBase holds the widgets of a scrollbar and the color of its thumb,
+// which are styled the same way in all the scrollbars.
+type scrollBarBase struct {
+	fg     *RGBA
+	widget *widgets.QWidget
+	thumb  *widgets.QWidget
+}
+
+func (b *scrollBarBase) setColor() {
+	b.fg = editor.colors.scrollBarFg
+	if editor.config.ScrollBar.Color != "" {
+		b.fg = hexToRGBA(editor.config.ScrollBar.Color)
+	}
+	if b.fg == nil {
+		return
+	}
+	b.thumb.SetStyleSheet(fmt.Sprintf(" * { background: %s;}", b.fg.String()))
+	b.widget.SetStyleSheet(" * { background: rgba(0, 0, 0, 0);}")
+}
+
+// highlightThumb shifts the color of the thumb toward the foreground
+// of the screenbg, while the mouse is over the thumb.
+func (b *scrollBarBase) highlightThumb(screenbg string) {
+	shift := -40
+	if screenbg == "light" {
+		shift = 40
+	}
+	color := warpColor(b.fg, shift)
+	b.thumb.SetStyleSheet(fmt.Sprintf(" * { background: %s;}", color))
+}
+
+func (b *scrollBarBase) unhighlightThumb() {
+	b.thumb.SetStyleSheet(fmt.Sprintf(" * { background: %s;}", b.fg))
+}
+
 // ScrollBar is
 type ScrollBar struct {
-	fg        *RGBA
+	scrollBarBase
 	ws        *Workspace
-	widget    *widgets.QWidget
-	thumb     *widgets.QWidget
+	marks     []scrollBarMark
+	colors    map[string]*RGBA
+	lineCount int
 	pos       int
 	height    int
 	beginPosY int
@@ -32,8 +70,10 @@ func newScrollBar() *ScrollBar {
 	thumb.SetFixedWidth(8)
 
 	scrollBar := &ScrollBar{
-		widget: widget,
-		thumb:  thumb,
+		scrollBarBase: scrollBarBase{
+			widget: widget,
+			thumb:  thumb,
+		},
 	}
 
 	scrollBar.thumb.ConnectMousePressEvent(scrollBar.thumbPress)
@@ -41,23 +81,19 @@ func newScrollBar() *ScrollBar {
 	scrollBar.thumb.ConnectMouseReleaseEvent(scrollBar.thumbRelease)
 	scrollBar.thumb.ConnectEnterEvent(scrollBar.thumbEnter)
 	scrollBar.thumb.ConnectLeaveEvent(scrollBar.thumbLeave)
+	scrollBar.widget.ConnectPaintEvent(scrollBar.paint)
+	scrollBar.widget.ConnectMousePressEvent(scrollBar.press)
 
 	scrollBar.widget.Hide()
 	return scrollBar
 }
 
 func (s *ScrollBar) thumbEnter(e *core.QEvent) {
-	shift := -40
-	if s.ws.screenbg == "light" {
-		shift = 40
-	}
-	color := warpColor(s.fg, shift)
-	s.thumb.SetStyleSheet(fmt.Sprintf(" * { background: %s;}", color))
+	s.highlightThumb(s.ws.screenbg)
 }
 
 func (s *ScrollBar) thumbLeave(e *core.QEvent) {
-	color := s.fg
-	s.thumb.SetStyleSheet(fmt.Sprintf(" * { background: %s;}", color))
+	s.unhighlightThumb()
 }
 
 func (s *ScrollBar) thumbPress(e *gui.QMouseEvent) {
@@ -151,15 +187,6 @@ func (s *ScrollBar) scroll(v, h int) {
 	}
 }
 
-func (s *ScrollBar) setColor() {
-	s.fg = editor.colors.scrollBarFg
-	if editor.config.ScrollBar.Color != "" {
-		s.fg = hexToRGBA(editor.config.ScrollBar.Color)
-	}
-	s.thumb.SetStyleSheet(fmt.Sprintf(" * { background: %s;}", s.fg.String()))
-	s.widget.SetStyleSheet(" * { background: rgba(0, 0, 0, 0);}")
-}
-
 func (s *ScrollBar) update() {
 	win, ok := s.ws.screen.getWindow(s.ws.cursor.gridid)
 	if !ok {
@@ -188,7 +215,59 @@ func (s *ScrollBar) update() {
 		s.pos = int(float64(top) / float64(s.ws.maxLine) * float64(s.ws.screen.widget.Height()))
 		s.thumb.Move2(0, s.pos)
 		s.widget.Show()
+		s.widget.Update()
 	} else {
 		s.widget.Hide()
 	}
 }
+
+// scrollThumb returns the position and the size of the thumb in a bar of
+// length pixels, for the view of visible units at offset in the content of
+// total units, and whether the bar is needed at all.
+func scrollThumb(length, offset, total, visible int) (pos, size int, ok bool) {
+	if length <= 0 || visible <= 0 {
+		return 0, 0, false
+	}
+	if total <= visible && offset == 0 {
+		return 0, 0, false
+	}
+
+	if offset+visible > total {
+		total = offset + visible
+	}
+
+	size = length * visible / total
+	if size < scrollBarMinThumbSize {
+		size = scrollBarMinThumbSize
+	}
+	if size > length {
+		size = length
+	}
+	pos = (length - size) * offset / (total - visible)
+
+	return pos, size, true
+}
+
+// scrollOffset returns the offset of the view after dragging the thumb
+// by delta pixels from the offset at the beginning of the drag.
+func scrollOffset(length, beginOffset, delta, total, visible int) int {
+	maxOffset := total - visible
+	if maxOffset < beginOffset {
+		maxOffset = beginOffset
+	}
+	_, size, ok := scrollThumb(length, beginOffset, total, visible)
+	if !ok || length <= size {
+		return beginOffset
+	}
+
+	ratio := float64(maxOffset) / float64(length-size)
+	offset := beginOffset + int(math.Round(float64(delta)*ratio))
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > maxOffset {
+		offset = maxOffset
+	}
+
+	return offset
+}
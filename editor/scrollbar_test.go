@@ -0,0 +1,45 @@
+package editor
+
+import "testing"
+
+func TestScrollThumb(t *testing.T) {
+	tests := []struct {
+		name                           string
+		length, offset, total, visible int
+		wantPos, wantSize              int
+		wantOk                         bool
+	}{
+		{"scrollThumb() hides the bar for the content within the view", 800, 0, 60, 80, 0, 0, false},
+		{"scrollThumb() at the start", 800, 0, 160, 80, 0, 400, true},
+		{"scrollThumb() at the end", 800, 80, 160, 80, 400, 400, true},
+		{"scrollThumb() keeps the minimum size", 800, 0, 8000, 80, 0, scrollBarMinThumbSize, true},
+		{"scrollThumb() scrolled past the end of the content", 800, 40, 60, 80, 267, 533, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos, size, ok := scrollThumb(tt.length, tt.offset, tt.total, tt.visible)
+			if pos != tt.wantPos || size != tt.wantSize || ok != tt.wantOk {
+				t.Errorf("scrollThumb() = %v, %v, %v, want %v, %v, %v", pos, size, ok, tt.wantPos, tt.wantSize, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestScrollOffset(t *testing.T) {
+	tests := []struct {
+		name                              string
+		length, beginOffset, delta, total int
+		want                              int
+	}{
+		{"scrollOffset() follows the drag", 800, 0, 200, 160, 40},
+		{"scrollOffset() stops at the start", 800, 10, -400, 160, 0},
+		{"scrollOffset() stops at the end", 800, 40, 800, 160, 80},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scrollOffset(tt.length, tt.beginOffset, tt.delta, tt.total, 80); got != tt.want {
+				t.Errorf("scrollOffset() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package editor
+
+import (
+	"math"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+)
+
+const (
+	scrollBarMarkHeight = 2
+	// scrollBarMarkTolerance is how far in pixels from a mark
+	// a click on the scrollbar still jumps to the mark.
+	scrollBarMarkTolerance = 3
+)
+
+// scrollBarMark is a line marked on the overview ruler of the scrollbar.
+// kind is one of "search", "error", "warn", "info", "hint", "add", "change"
+// and "delete", as the Lua helper goneovim.notify_scrollbar_marks() sends.
+type scrollBarMark struct {
+	kind string
+	line int
+}
+
+// scrollBarMarkLane returns the horizontal lane of the mark kind
+// in thirds of the scrollbar width: diff, search, diagnostics.
+func scrollBarMarkLane(kind string) int {
+	switch kind {
+	case "add", "change", "delete":
+		return 0
+	case "search":
+		return 1
+	}
+
+	return 2
+}
+
+// scrollBarMarkY returns the vertical position of the line
+// in the scrollbar of the given height.
+func scrollBarMarkY(line, lineCount, height int) int {
+	if lineCount <= 0 {
+		return 0
+	}
+	y := int(float64(line-1) / float64(lineCount) * float64(height))
+	if y > height-scrollBarMarkHeight {
+		y = height - scrollBarMarkHeight
+	}
+	if y < 0 {
+		y = 0
+	}
+
+	return y
+}
+
+// nearestScrollBarMark returns the line of the mark closest to y,
+// if it is within scrollBarMarkTolerance pixels.
+func nearestScrollBarMark(marks []scrollBarMark, y, lineCount, height int) (int, bool) {
+	line := 0
+	distance := scrollBarMarkTolerance + 1
+	for _, mark := range marks {
+		d := int(math.Abs(float64(scrollBarMarkY(mark.line, lineCount, height) - y)))
+		if d < distance {
+			distance = d
+			line = mark.line
+		}
+	}
+
+	return line, line > 0
+}
+
+// parseScrollBarMarks parses the marks which goneovim.notify_scrollbar_marks()
+// sends as a list of [line, kind].
+func parseScrollBarMarks(marksITF interface{}) []scrollBarMark {
+	var marks []scrollBarMark
+	markList, ok := marksITF.([]interface{})
+	if !ok {
+		return marks
+	}
+	for _, m := range markList {
+		mark, ok := m.([]interface{})
+		if !ok || len(mark) < 2 {
+			continue
+		}
+		kind, ok := mark[1].(string)
+		if !ok {
+			continue
+		}
+		marks = append(marks, scrollBarMark{
+			line: util.ReflectToInt(mark[0]),
+			kind: kind,
+		})
+	}
+
+	return marks
+}
+
+// parseScrollBarMarkColors parses the colors of the mark kinds,
+// which are read from the highlight groups.
+func parseScrollBarMarkColors(colorsITF interface{}) map[string]*RGBA {
+	colors := make(map[string]*RGBA)
+	colorMap, ok := colorsITF.(map[string]interface{})
+	if !ok {
+		return colors
+	}
+	for kind, c := range colorMap {
+		hex, ok := c.(string)
+		if !ok || hex == "" {
+			continue
+		}
+		colors[kind] = hexToRGBA(hex)
+	}
+
+	return colors
+}
+
+// setMarks handles the marks of the current window which the autocmds notify.
+func (s *ScrollBar) setMarks(lineCount int, marksITF, colorsITF interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lineCount = lineCount
+	s.marks = parseScrollBarMarks(marksITF)
+	s.colors = parseScrollBarMarkColors(colorsITF)
+
+	s.widget.Update()
+}
+
+func (s *ScrollBar) markColor(kind string) *RGBA {
+	if color, ok := s.colors[kind]; ok && color != nil {
+		return color
+	}
+
+	return s.fg
+}
+
+func (s *ScrollBar) paint(event *gui.QPaintEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := gui.NewQPainter2(s.widget)
+	defer p.DestroyQPainter()
+
+	width := float64(s.widget.Width())
+	height := s.widget.Height()
+	lane := width / 3.0
+
+	for _, mark := range s.marks {
+		y := scrollBarMarkY(mark.line, s.lineCount, height)
+		x := float64(scrollBarMarkLane(mark.kind)) * lane
+		p.FillRect4(
+			core.NewQRectF4(x, float64(y), lane, scrollBarMarkHeight),
+			s.markColor(mark.kind).QColor(),
+		)
+	}
+
+	// The cursor line is drawn across all the lanes.
+	curLine := s.ws.viewport[2]
+	if curLine > 0 && s.ws.maxLine > 0 {
+		y := scrollBarMarkY(curLine, s.ws.maxLine, height)
+		p.FillRect4(
+			core.NewQRectF4(0, float64(y), width, scrollBarMarkHeight),
+			s.markColor("cursor").QColor(),
+		)
+	}
+}
+
+// press jumps to the mark clicked on the overview ruler,
+// or to the line at the clicked position if there is no mark nearby.
+func (s *ScrollBar) press(e *gui.QMouseEvent) {
+	if e.Button() != core.Qt__LeftButton {
+		return
+	}
+
+	s.mu.Lock()
+	lineCount := s.lineCount
+	if lineCount <= 0 {
+		lineCount = s.ws.maxLine
+	}
+	line, ok := nearestScrollBarMark(s.marks, e.Y(), lineCount, s.widget.Height())
+	s.mu.Unlock()
+
+	if !ok {
+		line = int(float64(e.Y())/float64(s.widget.Height())*float64(lineCount)) + 1
+	}
+	if line <= 0 {
+		return
+	}
+
+	command := scrollBarJumpCommand(line, s.ws.viewport[0], s.ws.viewport[1])
+	go s.ws.nvim.Command(command)
+}
+
+// scrollBarJumpCommand returns the command to jump to the clicked line.
+// As on the minimap, the window scrolls only when the line is out of
+// the viewport, and the jump is not added to the jumplist.
+func scrollBarJumpCommand(line, viewTop, viewBot int) string {
+	return "keepjumps " + minimapJumpCommand(line, viewTop, viewBot)
+}
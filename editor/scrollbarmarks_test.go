@@ -0,0 +1,88 @@
+package editor
+
+import "testing"
+
+func TestScrollBarMarkY(t *testing.T) {
+	tests := []struct {
+		name                    string
+		line, lineCount, height int
+		want                    int
+	}{
+		{"scrollBarMarkY() at the first line", 1, 100, 500, 0},
+		{"scrollBarMarkY() in the middle", 51, 100, 500, 250},
+		{"scrollBarMarkY() keeps the last line inside", 100, 100, 500, 495},
+		{"scrollBarMarkY() clamps the line beyond the end", 200, 100, 500, 500 - scrollBarMarkHeight},
+		{"scrollBarMarkY() with an empty buffer", 1, 0, 500, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scrollBarMarkY(tt.line, tt.lineCount, tt.height); got != tt.want {
+				t.Errorf("scrollBarMarkY() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNearestScrollBarMark(t *testing.T) {
+	marks := []scrollBarMark{
+		{kind: "search", line: 11},
+		{kind: "error", line: 13},
+		{kind: "add", line: 81},
+	}
+	tests := []struct {
+		name     string
+		y        int
+		wantLine int
+		wantOk   bool
+	}{
+		{"nearestScrollBarMark() on a mark", 50, 11, true},
+		{"nearestScrollBarMark() picks the closest mark", 59, 13, true},
+		{"nearestScrollBarMark() away from the marks", 200, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, ok := nearestScrollBarMark(marks, tt.y, 100, 500)
+			if line != tt.wantLine || ok != tt.wantOk {
+				t.Errorf("nearestScrollBarMark() = %v, %v, want %v, %v", line, ok, tt.wantLine, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestParseScrollBarMarks(t *testing.T) {
+	marks := parseScrollBarMarks([]interface{}{
+		[]interface{}{int64(3), "search"},
+		[]interface{}{int64(5)},
+		[]interface{}{int64(8), "error"},
+	})
+	want := []scrollBarMark{
+		{kind: "search", line: 3},
+		{kind: "error", line: 8},
+	}
+	if len(marks) != len(want) {
+		t.Fatalf("parseScrollBarMarks() = %v, want %v", marks, want)
+	}
+	for i := range want {
+		if marks[i] != want[i] {
+			t.Errorf("parseScrollBarMarks()[%d] = %v, want %v", i, marks[i], want[i])
+		}
+	}
+}
+
+func TestScrollBarJumpCommand(t *testing.T) {
+	tests := []struct {
+		name                   string
+		line, viewTop, viewBot int
+		want                   string
+	}{
+		{"scrollBarJumpCommand() inside the viewport", 15, 10, 40, "keepjumps call cursor(15, 0)"},
+		{"scrollBarJumpCommand() below the viewport", 40, 10, 40, "keepjumps normal! 40Gzz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scrollBarJumpCommand(tt.line, tt.viewTop, tt.viewBot); got != tt.want {
+				t.Errorf("scrollBarJumpCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
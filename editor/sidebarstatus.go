@@ -0,0 +1,272 @@
+package editor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+	"github.com/akiyosi/qt/widgets"
+)
+
+// workspaceBadge is a status of the workspace shown under its label
+// in the sidebar.
+type workspaceBadge struct {
+	kind string
+	text string
+}
+
+// workspaceBadges returns the badges for the number of the modified buffers,
+// the running job and the number of the unread error messages.
+func workspaceBadges(modified, errors int, busy bool) []workspaceBadge {
+	var badges []workspaceBadge
+	if modified > 0 {
+		badges = append(badges, workspaceBadge{"modified", fmt.Sprintf("● %d", modified)})
+	}
+	if busy {
+		badges = append(badges, workspaceBadge{"busy", "⟳"})
+	}
+	if errors > 0 {
+		badges = append(badges, workspaceBadge{"error", fmt.Sprintf("✕ %d", errors)})
+	}
+
+	return badges
+}
+
+func workspaceBadgeColor(kind string) *RGBA {
+	switch kind {
+	case "modified":
+		return newRGBA(255, 205, 0, 1)
+	case "busy":
+		return newRGBA(27, 161, 226, 1)
+	case "branch":
+		return newRGBA(156, 204, 101, 1)
+	case "changes":
+		return newRGBA(255, 152, 0, 1)
+	default:
+		return newRGBA(229, 57, 53, 1)
+	}
+}
+
+// isErrorMessage reports whether the kind of msg_show is an error message.
+func isErrorMessage(kind string) bool {
+	return kind == "emsg" || kind == "echoerr"
+}
+
+// countUnreadErrors counts the error messages shown while the workspace
+// is in the background, until it is switched to.
+func (ws *Workspace) countUnreadErrors(args []interface{}) {
+	if !editor.config.SideBar.Badges {
+		return
+	}
+	if ws.getNum() == editor.active {
+		return
+	}
+	for _, arg := range args {
+		msg, ok := arg.([]interface{})
+		if !ok || len(msg) == 0 {
+			continue
+		}
+		kind, _ := msg[0].(string)
+		if isErrorMessage(kind) {
+			ws.unreadErrors++
+		}
+	}
+	ws.updateSideBadges()
+}
+
+// setModifiedBuffers handles the number of the modified buffers
+// which the autocmds notify.
+func (ws *Workspace) setModifiedBuffers(countITF interface{}) {
+	ws.modifiedBuffers = util.ReflectToInt(countITF)
+	ws.updateSideBadges()
+}
+
+// updateSideBadges shows the badges of the workspace in the sidebar,
+// following the branch and the number of the changes in git.
+func (ws *Workspace) updateSideBadges() {
+	if editor.side == nil {
+		return
+	}
+	index := ws.getNum()
+	if index >= len(editor.side.items) || index >= len(editor.workspaces) || editor.workspaces[index] != ws {
+		return
+	}
+	badges := gitBadges(ws.git)
+	if editor.config.SideBar.Badges {
+		busy := ws.cursor != nil && ws.cursor.isBusy
+		badges = append(badges, workspaceBadges(ws.modifiedBuffers, ws.unreadErrors, busy)...)
+	}
+	editor.side.items[index].setBadges(badges)
+}
+
+// grabThumbnail draws the grids of the workspace grabbed with grabScreen
+// into a pixmap scaled to the width. The floating windows are drawn
+// over the others.
+func (ws *Workspace) grabThumbnail(width int) *gui.QPixmap {
+	if ws.screen == nil || ws.screen.widget == nil || width <= 0 {
+		return nil
+	}
+	size := ws.screen.widget.Size()
+	if size.Width() <= 0 || size.Height() <= 0 {
+		return nil
+	}
+
+	var wins []*Window
+	ws.screen.windows.Range(func(_, winITF interface{}) bool {
+		win := winITF.(*Window)
+		if win == nil || win.grid == 1 || win.isMsgGrid || win.isExternal || win.IsHidden() {
+			return true
+		}
+		wins = append(wins, win)
+		return true
+	})
+	sort.SliceStable(wins, func(i, j int) bool {
+		return !wins[i].isFloatWin && wins[j].isFloatWin
+	})
+
+	pixmap := gui.NewQPixmap2(size)
+	bg := editor.colors.bg
+	if bg == nil {
+		bg = newRGBA(0, 0, 0, 1)
+	}
+	pixmap.Fill(bg.QColor())
+
+	p := gui.NewQPainter2(pixmap)
+	for _, win := range wins {
+		font := win.getFont()
+		snapshot := win.grabScreen()
+		pos := win.MapTo(
+			ws.screen.widget,
+			core.NewQPoint2(
+				win.viewportMargins[2]*int(font.cellwidth),
+				win.viewportMargins[0]*font.lineHeight,
+			),
+		)
+		p.DrawPixmap9(pos.X(), pos.Y(), snapshot)
+		snapshot.DestroyQPixmap()
+	}
+	p.DestroyQPainter()
+
+	thumbnail := pixmap.ScaledToWidth(width, core.Qt__SmoothTransformation)
+	pixmap.DestroyQPixmap()
+
+	return thumbnail
+}
+
+// initStatus adds the widgets of the badges and the thumbnail
+// under the label of the item.
+func (i *WorkspaceSideItem) initStatus() {
+	badges := widgets.NewQLabel(nil, 0)
+	badges.SetContentsMargins(15+editor.iconSize+editor.iconSize/2, 0, 0, 0)
+	badges.SetTextFormat(core.Qt__RichText)
+	badges.Hide()
+
+	thumbnail := widgets.NewQLabel(nil, 0)
+	thumbnail.SetContentsMargins(15, 2, 15, 2)
+	thumbnail.Hide()
+
+	i.layout.InsertWidget(1, badges, 0, core.Qt__AlignLeft)
+	i.layout.InsertWidget(2, thumbnail, 0, core.Qt__AlignLeft)
+	i.badges = badges
+	i.thumbnail = thumbnail
+}
+
+func (i *WorkspaceSideItem) setBadges(badges []workspaceBadge) {
+	var spans []string
+	for _, badge := range badges {
+		spans = append(spans, fmt.Sprintf(
+			`<span style="color: %s;">%s</span>`,
+			workspaceBadgeColor(badge.kind).Hex(),
+			badge.text,
+		))
+	}
+	text := strings.Join(spans, "&nbsp;&nbsp;")
+	if text == i.badgeText {
+		return
+	}
+	i.badgeText = text
+	i.badges.SetText(text)
+	i.showStatus()
+}
+
+func (i *WorkspaceSideItem) setThumbnail(thumbnail *gui.QPixmap) {
+	i.thumbnail.SetPixmap(thumbnail)
+	i.hasThumbnail = true
+	i.showStatus()
+}
+
+// showStatus shows the badges and the thumbnail which the item has.
+func (i *WorkspaceSideItem) showStatus() {
+	if i.hidden {
+		i.badges.Hide()
+		i.thumbnail.Hide()
+		return
+	}
+	i.badges.SetVisible(i.badgeText != "")
+	i.thumbnail.SetVisible(i.hasThumbnail && editor.config.SideBar.Thumbnail)
+}
+
+// clearStatus removes the badges and the thumbnail of the item
+// whose workspace has been closed.
+func (i *WorkspaceSideItem) clearStatus() {
+	i.badgeText = ""
+	i.badges.SetText("")
+	i.thumbnail.Clear()
+	i.hasThumbnail = false
+	i.showStatus()
+}
+
+// startThumbnailTimer refreshes the thumbnails of the workspaces
+// periodically while the sidebar is shown.
+func (side *WorkspaceSide) startThumbnailTimer() {
+	if !editor.config.SideBar.Thumbnail {
+		return
+	}
+	side.thumbTimer = core.NewQTimer(nil)
+	side.thumbTimer.ConnectTimeout(func() {
+		if !side.isShown {
+			return
+		}
+		side.updateThumbnails()
+	})
+	side.thumbTimer.Start(editor.config.SideBar.ThumbnailInterval)
+}
+
+func (side *WorkspaceSide) updateThumbnails() {
+	if side == nil || !editor.config.SideBar.Thumbnail || side.scrollarea == nil {
+		return
+	}
+	width := side.scrollarea.Width() - 30
+	for i, ws := range editor.workspaces {
+		if i >= len(side.items) || side.items[i].hidden {
+			continue
+		}
+		thumbnail := ws.grabThumbnail(width)
+		if thumbnail == nil {
+			continue
+		}
+		side.items[i].setThumbnail(thumbnail)
+		thumbnail.DestroyQPixmap()
+	}
+}
+
+// updateStatus shows the badges and the thumbnails of the workspaces in the
+// items, which follow the workspaces when they are reordered or closed.
+func (side *WorkspaceSide) updateStatus() {
+	if side == nil {
+		return
+	}
+	for i := len(editor.workspaces); i < len(side.items); i++ {
+		side.items[i].clearStatus()
+	}
+	for _, ws := range editor.workspaces {
+		ws.updateSideBadges()
+	}
+	if editor.active < len(editor.workspaces) {
+		side.git.update(editor.workspaces[editor.active])
+	}
+	side.updateThumbnails()
+}
@@ -0,0 +1,65 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWorkspaceBadges(t *testing.T) {
+	tests := []struct {
+		name     string
+		modified int
+		errors   int
+		busy     bool
+		want     []workspaceBadge
+	}{
+		{
+			"workspaceBadges() no badges for the idle workspace",
+			0, 0, false,
+			nil,
+		},
+		{
+			"workspaceBadges() all the badges",
+			2, 3, true,
+			[]workspaceBadge{
+				{"modified", "● 2"},
+				{"busy", "⟳"},
+				{"error", "✕ 3"},
+			},
+		},
+		{
+			"workspaceBadges() only the errors",
+			0, 1, false,
+			[]workspaceBadge{
+				{"error", "✕ 1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := workspaceBadges(tt.modified, tt.errors, tt.busy); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("workspaceBadges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsErrorMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		kind string
+		want bool
+	}{
+		{"isErrorMessage() emsg", "emsg", true},
+		{"isErrorMessage() echoerr", "echoerr", true},
+		{"isErrorMessage() echomsg", "echomsg", false},
+		{"isErrorMessage() wmsg", "wmsg", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isErrorMessage(tt.kind); got != tt.want {
+				t.Errorf("isErrorMessage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,339 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+	"github.com/akiyosi/qt/widgets"
+)
+
+// StartPage is the page layered over the workspace on startup, which lists
+// the recent projects, the named sessions and the recent files.
+type StartPage struct {
+	ws       *Workspace
+	widget   *widgets.QWidget
+	column   *widgets.QWidget
+	input    *widgets.QLineEdit
+	list     *widgets.QWidget
+	labels   []*widgets.QLabel
+	items    []*widgets.QLabel
+	matches  []recentMatch
+	selected int
+	hidden   bool
+}
+
+var startPageSections = []struct {
+	kind  string
+	title string
+}{
+	{recentProject, "Recent Projects"},
+	{recentSession, "Sessions"},
+	{recentFile, "Recent Files"},
+}
+
+func newStartPage(ws *Workspace) *StartPage {
+	widget := widgets.NewQWidget(ws.widget, 0)
+	widget.SetObjectName("startPage")
+
+	layout := widgets.NewQVBoxLayout()
+	layout.SetContentsMargins(20, 40, 20, 20)
+	widget.SetLayout(layout)
+
+	column := widgets.NewQWidget(nil, 0)
+	columnLayout := widgets.NewQVBoxLayout()
+	columnLayout.SetContentsMargins(0, 0, 0, 0)
+	columnLayout.SetSpacing(8)
+	column.SetLayout(columnLayout)
+	layout.AddWidget(column, 0, core.Qt__AlignHCenter|core.Qt__AlignTop)
+
+	input := widgets.NewQLineEdit(nil)
+	input.SetPlaceholderText("Filter recent projects, sessions and files")
+	input.SetFrame(false)
+	columnLayout.AddWidget(input, 0, 0)
+
+	list := widgets.NewQWidget(nil, 0)
+	listLayout := widgets.NewQVBoxLayout()
+	listLayout.SetContentsMargins(0, 0, 0, 0)
+	listLayout.SetSpacing(0)
+	list.SetLayout(listLayout)
+	columnLayout.AddWidget(list, 0, 0)
+	columnLayout.AddStretch(1)
+
+	s := &StartPage{
+		ws:     ws,
+		widget: widget,
+		column: column,
+		input:  input,
+		list:   list,
+		hidden: true,
+	}
+
+	input.ConnectTextChanged(func(text string) {
+		s.refresh()
+	})
+	input.ConnectKeyPressEvent(s.keyPress)
+	widget.Hide()
+
+	return s
+}
+
+// showStartPage shows the start page if goneovim started
+// without the files to edit.
+func (ws *Workspace) showStartPage() {
+	if !editor.config.Workspace.StartPage {
+		return
+	}
+	if editor.doRestoreSessions || editor.opts.Server != "" || hasFileArgs(editor.args) {
+		return
+	}
+	if len(editor.workspaces) != 1 {
+		return
+	}
+	ws.openStartPage()
+}
+
+func (ws *Workspace) openStartPage() {
+	if editor.recent == nil {
+		return
+	}
+	if ws.startPage == nil {
+		ws.startPage = newStartPage(ws)
+	}
+	ws.startPage.show()
+}
+
+func (s *StartPage) show() {
+	s.setColor()
+	s.input.SetText("")
+	s.refresh()
+	s.resize()
+	s.hidden = false
+	s.widget.Show()
+	s.widget.Raise()
+	s.input.SetFocus2()
+}
+
+func (s *StartPage) hide() {
+	if s == nil || s.hidden {
+		return
+	}
+	s.hidden = true
+	s.widget.Hide()
+	s.ws.widget.SetFocus2()
+}
+
+func (s *StartPage) resize() {
+	if s == nil {
+		return
+	}
+	width := s.ws.widget.Width()
+	height := s.ws.widget.Height()
+	s.widget.SetGeometry2(0, 0, width, height)
+
+	columnWidth := width - 40
+	if columnWidth > 720 {
+		columnWidth = 720
+	}
+	s.column.SetFixedWidth(columnWidth)
+}
+
+func (s *StartPage) setColor() {
+	bg := editor.colors.bg
+	fg := editor.colors.fg
+	if bg == nil || fg == nil {
+		return
+	}
+	inputBg := editor.colors.widgetBg
+	if inputBg == nil {
+		inputBg = bg
+	}
+	s.widget.SetStyleSheet(fmt.Sprintf(
+		"#startPage { background-color: %s; } * { color: %s; } QLineEdit { background-color: %s; padding: 8px; }",
+		bg.String(), fg.String(), inputBg.String(),
+	))
+	s.widget.SetFont(s.ws.font.qfont)
+	s.input.SetFont(s.ws.font.qfont)
+}
+
+// refresh lists the entries matching the pattern in the sections.
+func (s *StartPage) refresh() {
+	for _, label := range s.labels {
+		label.DeleteLater()
+	}
+	s.labels = nil
+	s.items = nil
+	s.matches = nil
+	s.selected = 0
+
+	pattern := s.input.Text()
+	max := editor.config.Workspace.RecentItems
+	layout := s.list.Layout()
+	for _, section := range startPageSections {
+		matches := filterRecentEntries(editor.recent.list(section.kind), pattern)
+		if len(matches) == 0 {
+			continue
+		}
+		if len(matches) > max {
+			matches = matches[:max]
+		}
+
+		header := widgets.NewQLabel2(section.title, nil, 0)
+		header.SetContentsMargins(8, 12, 8, 4)
+		if editor.colors.inactiveFg != nil {
+			header.SetStyleSheet(fmt.Sprintf("color: %s;", editor.colors.inactiveFg.String()))
+		}
+		layout.AddWidget(header)
+		s.labels = append(s.labels, header)
+
+		for _, match := range matches {
+			index := len(s.matches)
+			s.matches = append(s.matches, match)
+
+			label := widgets.NewQLabel(nil, 0)
+			label.SetTextFormat(core.Qt__RichText)
+			label.SetContentsMargins(16, 4, 8, 4)
+			label.SetText(formatText(match.entry.Path, match.match, true))
+			label.ConnectMousePressEvent(func(event *gui.QMouseEvent) {
+				s.open(index, event.Modifiers()&(core.Qt__ShiftModifier|core.Qt__ControlModifier) != 0)
+			})
+			layout.AddWidget(label)
+			s.labels = append(s.labels, label)
+			s.items = append(s.items, label)
+		}
+	}
+	if len(s.matches) == 0 {
+		empty := widgets.NewQLabel2("No recent entries", nil, 0)
+		empty.SetContentsMargins(8, 12, 8, 4)
+		layout.AddWidget(empty)
+		s.labels = append(s.labels, empty)
+	}
+	s.updateSelected()
+}
+
+// updateSelected highlights the label of the selected entry.
+func (s *StartPage) updateSelected() {
+	for i, label := range s.items {
+		if i == s.selected && editor.colors.selectedBg != nil {
+			label.SetStyleSheet(fmt.Sprintf("background-color: %s;", editor.colors.selectedBg.String()))
+		} else {
+			label.SetStyleSheet("")
+		}
+	}
+}
+
+func (s *StartPage) keyPress(event *gui.QKeyEvent) {
+	mod := event.Modifiers()
+	switch core.Qt__Key(event.Key()) {
+	case core.Qt__Key_Escape:
+		s.hide()
+	case core.Qt__Key_Up:
+		s.moveSelected(-1)
+	case core.Qt__Key_Down:
+		s.moveSelected(1)
+	case core.Qt__Key_P:
+		if mod&core.Qt__ControlModifier == 0 {
+			s.input.KeyPressEventDefault(event)
+			return
+		}
+		s.moveSelected(-1)
+	case core.Qt__Key_N:
+		if mod&core.Qt__ControlModifier == 0 {
+			s.input.KeyPressEventDefault(event)
+			return
+		}
+		s.moveSelected(1)
+	case core.Qt__Key_Return, core.Qt__Key_Enter:
+		s.open(s.selected, mod&(core.Qt__ShiftModifier|core.Qt__ControlModifier) != 0)
+	default:
+		s.input.KeyPressEventDefault(event)
+	}
+}
+
+func (s *StartPage) moveSelected(delta int) {
+	if len(s.matches) == 0 {
+		return
+	}
+	s.selected = (s.selected + delta + len(s.matches)) % len(s.matches)
+	s.updateSelected()
+}
+
+// open opens the entry at index in the workspace of the page,
+// or in a new workspace if newWorkspace is true.
+func (s *StartPage) open(index int, newWorkspace bool) {
+	if index < 0 || index >= len(s.matches) {
+		return
+	}
+	entry := s.matches[index].entry
+	s.hide()
+
+	if !newWorkspace {
+		s.ws.openRecent(entry)
+		return
+	}
+	if entry.Kind == recentSession {
+		editor.workspaceAddWithSession(entry.Path)
+		editor.recent.add(recentSession, entry.Path)
+		return
+	}
+	editor.workspaceAdd()
+	editor.workspaces[editor.active].openRecent(entry)
+}
+
+// openRecent changes the cwd to the project, sources the session
+// or edits the file of the entry.
+func (ws *Workspace) openRecent(entry recentEntry) {
+	if ws.nvim == nil {
+		return
+	}
+	cmd := "edit"
+	switch entry.Kind {
+	case recentProject:
+		cmd = "cd"
+	case recentSession:
+		cmd = "source"
+		editor.recent.add(recentSession, entry.Path)
+	}
+
+	go ws.nvim.Command(fmt.Sprintf("execute %q fnameescape(%q)", cmd, entry.Path))
+}
+
+// namedSessionPath returns the path of the session file named name.
+func namedSessionPath(configDir, name string) string {
+	return filepath.Join(configDir, "named-sessions", filepath.Base(name)+".vim")
+}
+
+// saveNamedSession saves the session of the workspace with the name,
+// which is listed in the start page.
+func (ws *Workspace) saveNamedSession(name string) {
+	if ws.nvim == nil || name == "" {
+		return
+	}
+	path := namedSessionPath(editor.configDir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		editor.putLog("failed to create the directory of the sessions:", err)
+		return
+	}
+	go func() {
+		if err := ws.nvim.Command(fmt.Sprintf("execute %q fnameescape(%q)", "mksession!", path)); err != nil {
+			editor.putLog("failed to save the session:", err)
+			return
+		}
+		editor.recent.add(recentSession, path)
+	}()
+}
+
+// loadNamedSession sources the session saved with the name.
+func (ws *Workspace) loadNamedSession(name string) {
+	if name == "" {
+		return
+	}
+	path := namedSessionPath(editor.configDir, name)
+	if _, err := os.Stat(path); err != nil {
+		editor.putLog("the session does not exist:", path)
+		return
+	}
+	ws.openRecent(recentEntry{Kind: recentSession, Path: path})
+}
@@ -0,0 +1,129 @@
+package editor
+
+import (
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+)
+
+var (
+	textAlphaTableOnce sync.Once
+	textAlphaColors    []uint
+)
+
+// fontStyleStrategy returns the style strategy for the fonts used to draw
+// the grid text, including the antialiasing setting of settings.toml.
+func fontStyleStrategy() gui.QFont__StyleStrategy {
+	strategy := gui.QFont__PreferDefault | gui.QFont__ForceIntegerMetrics
+	if editor.config.Editor.ManualFontFallback {
+		strategy = gui.QFont__NoFontMerging | gui.QFont__ForceIntegerMetrics
+	}
+
+	switch strings.ToLower(editor.config.Editor.TextAntialiasing) {
+	case "grayscale":
+		strategy |= gui.QFont__PreferAntialias | gui.QFont__NoSubpixelAntialias
+	case "none":
+		strategy |= gui.QFont__NoAntialias
+	}
+
+	return strategy
+}
+
+func fontHintingPreference() gui.QFont__HintingPreference {
+	switch strings.ToLower(editor.config.Editor.TextHinting) {
+	case "none":
+		return gui.QFont__PreferNoHinting
+	case "vertical", "slight":
+		return gui.QFont__PreferVerticalHinting
+	case "full":
+		return gui.QFont__PreferFullHinting
+	}
+
+	return gui.QFont__PreferDefaultHinting
+}
+
+// setTextRenderHints sets the render hints of the painter that rasterizes
+// glyphs into the text cache images.
+func setTextRenderHints(p *gui.QPainter) {
+	if strings.ToLower(editor.config.Editor.TextAntialiasing) == "none" {
+		p.SetRenderHint(gui.QPainter__TextAntialiasing, false)
+	}
+}
+
+// textAlphaTable builds the lookup table which maps the coverage of a glyph
+// pixel to the alpha value after applying gamma and contrast.
+func textAlphaTable(gamma, contrast float64) (table [256]uint8) {
+	if gamma <= 0 {
+		gamma = 1.0
+	}
+	for i := range table {
+		a := math.Pow(float64(i)/255.0, 1.0/gamma)
+		a += contrast * a * (1.0 - a)
+		a = math.Min(math.Max(a, 0), 1.0)
+		table[i] = uint8(math.Round(a * 255.0))
+	}
+
+	return
+}
+
+// textAlphaColorTable returns the color table which maps the coverage of
+// a glyph pixel to the alpha value, and false if TextGamma and TextContrast
+// leave the coverage as it is.
+func textAlphaColorTable() ([]uint, bool) {
+	textAlphaTableOnce.Do(func() {
+		gamma := editor.config.Editor.TextGamma
+		contrast := editor.config.Editor.TextContrast
+		if (gamma == 1.0 || gamma <= 0) && contrast == 0 {
+			return
+		}
+		textAlphaColors = make([]uint, 256)
+		for i, a := range textAlphaTable(gamma, contrast) {
+			textAlphaColors[i] = uint(a) << 24
+		}
+	})
+
+	return textAlphaColors, textAlphaColors != nil
+}
+
+// adjustTextGamma returns the glyph image rendered in premultiplied ARGB
+// with TextGamma and TextContrast applied.
+// The alpha channel is mapped through the color table of an indexed image,
+// and the colors of the glyph are put back with the SourceIn composition,
+// so that Qt converts the pixels without visiting them one by one in Go.
+func adjustTextGamma(image *gui.QImage) *gui.QImage {
+	if image == nil {
+		return nil
+	}
+	colors, ok := textAlphaColorTable()
+	if !ok {
+		return image
+	}
+
+	alpha := image.ConvertToFormat(gui.QImage__Format_Alpha8, core.Qt__AutoColor)
+	if !alpha.ReinterpretAsFormat(gui.QImage__Format_Indexed8) {
+		return image
+	}
+	alpha.SetColorTable(colors)
+	adjusted := alpha.ConvertToFormat(gui.QImage__Format_ARGB32_Premultiplied, core.Qt__AutoColor)
+	adjusted.SetDevicePixelRatio(image.DevicePixelRatio())
+
+	opaque := image.ConvertToFormat(gui.QImage__Format_RGB32, core.Qt__AutoColor)
+	opaque.SetDevicePixelRatio(image.DevicePixelRatio())
+
+	p := gui.NewQPainter2(adjusted)
+	p.SetCompositionMode(gui.QPainter__CompositionMode_SourceIn)
+	p.DrawImage9(0, 0, opaque, 0, 0, -1, -1, core.Qt__AutoColor)
+	p.DestroyQPainter()
+
+	return adjusted
+}
+
+// isTextGammaLinear reports whether TextGamma and TextContrast
+// leave the glyphs as Qt rasterizes them.
+func isTextGammaLinear() bool {
+	_, ok := textAlphaColorTable()
+	return !ok
+}
@@ -0,0 +1,31 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestTextAlphaTable(t *testing.T) {
+	tests := []struct {
+		name     string
+		gamma    float64
+		contrast float64
+		in       int
+		want     uint8
+	}{
+		{"textAlphaTable() linear 1", 1.0, 0, 0, 0},
+		{"textAlphaTable() linear 2", 1.0, 0, 128, 128},
+		{"textAlphaTable() linear 3", 1.0, 0, 255, 255},
+		{"textAlphaTable() invalid gamma is linear", 0, 0, 100, 100},
+		{"textAlphaTable() gamma darkens coverage", 2.0, 0, 64, 128},
+		{"textAlphaTable() contrast boosts coverage", 1.0, 1.0, 128, 192},
+		{"textAlphaTable() contrast keeps full coverage", 1.0, 1.0, 255, 255},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := textAlphaTable(tt.gamma, tt.contrast)
+			if got := table[tt.in]; got != tt.want {
+				t.Errorf("textAlphaTable()[%d] = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
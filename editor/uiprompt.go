@@ -0,0 +1,309 @@
+package editor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+	"github.com/akiyosi/qt/widgets"
+)
+
+// selectMatch is an item of vim.ui.select which matches the filter.
+type selectMatch struct {
+	index int
+	match []int
+	score int
+}
+
+// filterSelectItems returns the items which match the pattern, in the order
+// of the score. The items of the same score keep the order of vim.ui.select.
+func filterSelectItems(items []string, pattern string) []selectMatch {
+	var matches []selectMatch
+	for i, item := range items {
+		match, score, ok := fuzzyMatch(pattern, item)
+		if !ok {
+			continue
+		}
+		matches = append(matches, selectMatch{i, match, score})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	return matches
+}
+
+// UIPrompt shows vim.ui.select as a list and vim.ui.input as a text field
+// near the cursor, instead of the prompts in the cmdline. The shims of them
+// in nvim wait for the result asynchronously, which is returned to the
+// callback with goneovim.ui_done, so that nvim is not blocked meanwhile.
+type UIPrompt struct {
+	ws       *Workspace
+	palette  *Palette
+	prompt   *widgets.QLabel
+	input    *widgets.QLineEdit
+	id       int
+	isSelect bool
+	items    []string
+	matches  []selectMatch
+	selected int
+	offset   int
+}
+
+func newUIPrompt(ws *Workspace) *UIPrompt {
+	p := initPalette()
+	p.ws = ws
+	p.widget.SetParent(ws.widget)
+	p.pattern.Hide()
+
+	prompt := widgets.NewQLabel(nil, 0)
+	input := widgets.NewQLineEdit(nil)
+	input.SetFrame(false)
+	input.SetAttribute(core.Qt__WA_InputMethodEnabled, true)
+	row := widgets.NewQWidget(nil, 0)
+	rowLayout := widgets.NewQHBoxLayout()
+	rowLayout.SetContentsMargins(p.padding, p.padding, p.padding, p.padding)
+	rowLayout.AddWidget(prompt, 0, 0)
+	rowLayout.AddWidget(input, 1, 0)
+	row.SetLayout(rowLayout)
+	p.patternWidget.Layout().AddWidget(row)
+
+	u := &UIPrompt{
+		ws:      ws,
+		palette: p,
+		prompt:  prompt,
+		input:   input,
+	}
+	input.ConnectKeyPressEvent(u.keyPress)
+	input.ConnectTextChanged(func(text string) {
+		if !u.isSelect {
+			return
+		}
+		u.selected = 0
+		u.offset = 0
+		u.filter()
+	})
+	input.ConnectFocusOutEvent(func(event *gui.QFocusEvent) {
+		input.FocusOutEventDefault(event)
+		if event.Reason() != core.Qt__PopupFocusReason {
+			u.done(nil)
+		}
+	})
+	p.hide()
+
+	return u
+}
+
+// showUISelect shows the items of vim.ui.select with the id.
+func (ws *Workspace) showUISelect(id int, items []string, prompt string) {
+	u := ws.openUIPrompt(id, prompt)
+	u.isSelect = true
+	u.items = items
+	u.input.SetPlaceholderText("Filter")
+	u.filter()
+	u.place()
+}
+
+// showUIInput shows the text field of vim.ui.input with the id.
+func (ws *Workspace) showUIInput(id int, prompt, text string) {
+	u := ws.openUIPrompt(id, prompt)
+	u.isSelect = false
+	u.input.SetPlaceholderText("")
+	u.input.SetText(text)
+	u.input.SelectAll()
+	u.update()
+	u.place()
+}
+
+func (ws *Workspace) openUIPrompt(id int, prompt string) *UIPrompt {
+	if ws.uiPrompt == nil {
+		ws.uiPrompt = newUIPrompt(ws)
+	}
+	u := ws.uiPrompt
+	// A new request cancels the one shown.
+	u.done(nil)
+	u.id = id
+	u.items = nil
+	u.matches = nil
+	u.selected = 0
+	u.offset = 0
+	u.prompt.SetText(prompt)
+	u.prompt.SetVisible(prompt != "")
+	u.input.Clear()
+	u.palette.updateFont()
+	u.prompt.SetFont(ws.screen.font.qfont)
+	u.input.SetFont(ws.screen.font.qfont)
+	if editor.colors.widgetFg != nil {
+		u.input.SetStyleSheet(fmt.Sprintf("background-color: rgba(0, 0, 0, 0); color: %s;", editor.colors.widgetFg.String()))
+		u.prompt.SetStyleSheet(fmt.Sprintf("background-color: rgba(0, 0, 0, 0); color: %s;", editor.colors.inactiveFg.String()))
+	}
+	u.palette.show()
+	u.input.SetFocus2()
+
+	return u
+}
+
+// place moves the prompt under the cursor, or over the cursor if the cursor
+// is below the center of the screen.
+func (u *UIPrompt) place() {
+	ws := u.ws
+	width := int(math.Trunc(float64(ws.width) * 0.4))
+	if width < 300 {
+		width = 300
+	}
+	if width > ws.width {
+		width = ws.width
+	}
+	u.palette.width = width
+	u.palette.widget.SetMinimumWidth(width)
+	u.palette.widget.SetMaximumWidth(width)
+	u.palette.widget.AdjustSize()
+
+	x, y, font, isCursorBelowTheCenter := ws.getPointInWidget(ws.cursor.col, ws.cursor.row, ws.cursor.gridid)
+	if x+width >= ws.width {
+		x = ws.width - width - 5
+	}
+	if x < 0 {
+		x = 0
+	}
+	if isCursorBelowTheCenter {
+		y -= u.palette.widget.Height()
+	} else {
+		y += font.lineHeight
+	}
+	if y < 0 {
+		y = 0
+	}
+	u.palette.widget.Move2(x, y)
+}
+
+func (u *UIPrompt) filter() {
+	u.matches = filterSelectItems(u.items, u.input.Text())
+	if u.selected >= len(u.matches) {
+		u.selected = len(u.matches) - 1
+	}
+	if u.selected < 0 {
+		u.selected = 0
+	}
+	u.update()
+}
+
+// visibleItems returns the number of the items shown at once.
+func (u *UIPrompt) visibleItems() int {
+	n := u.palette.showTotal
+	if n > len(u.palette.resultItems) || n <= 0 {
+		n = len(u.palette.resultItems)
+	}
+
+	return n
+}
+
+// update shows the items around the selected one.
+func (u *UIPrompt) update() {
+	visible := u.visibleItems()
+	if u.selected < u.offset {
+		u.offset = u.selected
+	}
+	if u.selected >= u.offset+visible {
+		u.offset = u.selected - visible + 1
+	}
+	for i, item := range u.palette.resultItems {
+		n := u.offset + i
+		if !u.isSelect || i >= visible || n >= len(u.matches) {
+			item.hide()
+			continue
+		}
+		m := u.matches[n]
+		item.setItem(u.items[m.index], "", append([]int(nil), m.match...))
+		item.setSelected(n == u.selected)
+		item.show()
+	}
+}
+
+func (u *UIPrompt) moveSelected(delta int) {
+	if len(u.matches) == 0 {
+		return
+	}
+	u.selected = (u.selected + delta + len(u.matches)) % len(u.matches)
+	u.update()
+}
+
+// confirm returns the selected item, which is 1-based as the index of Lua,
+// or the text to the callback.
+func (u *UIPrompt) confirm() {
+	if !u.isSelect {
+		u.done(u.input.Text())
+		return
+	}
+	if u.selected < 0 || u.selected >= len(u.matches) {
+		return
+	}
+	u.done(u.matches[u.selected].index + 1)
+}
+
+// done hides the prompt and returns the result to the callback in nvim,
+// where nil means that it is canceled.
+func (u *UIPrompt) done(result interface{}) {
+	if u == nil || u.id == 0 {
+		return
+	}
+	id := u.id
+	u.id = 0
+	u.palette.hide()
+	u.ws.widget.SetFocus2()
+	go u.ws.nvim.ExecLua(`goneovim.ui_done(...)`, nil, id, result)
+}
+
+func (u *UIPrompt) keyPress(event *gui.QKeyEvent) {
+	ctrl := event.Modifiers()&core.Qt__ControlModifier != 0
+	switch core.Qt__Key(event.Key()) {
+	case core.Qt__Key_Escape:
+		u.done(nil)
+	case core.Qt__Key_Up:
+		u.moveSelected(-1)
+	case core.Qt__Key_Down:
+		u.moveSelected(1)
+	case core.Qt__Key_Return, core.Qt__Key_Enter:
+		u.confirm()
+	case core.Qt__Key_P, core.Qt__Key_K:
+		if !ctrl || !u.isSelect {
+			u.input.KeyPressEventDefault(event)
+			return
+		}
+		u.moveSelected(-1)
+	case core.Qt__Key_N, core.Qt__Key_J:
+		if !ctrl || !u.isSelect {
+			u.input.KeyPressEventDefault(event)
+			return
+		}
+		u.moveSelected(1)
+	default:
+		u.input.KeyPressEventDefault(event)
+	}
+}
+
+// handleUIPrompt handles the requests of vim.ui.select and vim.ui.input.
+func (ws *Workspace) handleUIPrompt(updates []interface{}) {
+	if len(updates) < 4 {
+		return
+	}
+	id := util.ReflectToInt(updates[1])
+	switch updates[0] {
+	case "gonvim_ui_select":
+		itemsITF, _ := updates[2].([]interface{})
+		items := make([]string, 0, len(itemsITF))
+		for _, item := range itemsITF {
+			text, _ := item.(string)
+			items = append(items, text)
+		}
+		prompt, _ := updates[3].(string)
+		ws.showUISelect(id, items, prompt)
+	case "gonvim_ui_input":
+		prompt, _ := updates[2].(string)
+		text, _ := updates[3].(string)
+		ws.showUIInput(id, prompt, text)
+	}
+}
@@ -0,0 +1,48 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterSelectItems(t *testing.T) {
+	items := []string{"Fix all", "Extract function", "Add import", "Organize imports"}
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []int
+	}{
+		{
+			"filterSelectItems() keeps the order without the pattern",
+			"",
+			[]int{0, 1, 2, 3},
+		},
+		{
+			"filterSelectItems() puts the better match first",
+			"imp",
+			[]int{2, 3},
+		},
+		{
+			"filterSelectItems() ignores case",
+			"EXT",
+			[]int{1},
+		},
+		{
+			"filterSelectItems() returns nothing for no match",
+			"xyz",
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, m := range filterSelectItems(items, tt.pattern) {
+				got = append(got, m.index)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
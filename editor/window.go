@@ -50,6 +50,7 @@ type Highlight struct {
 
 // HlText is used in screen cache
 type HlKey struct {
+	font   *Font
 	fg     RGBA
 	italic bool
 	bold   bool
@@ -57,6 +58,7 @@ type HlKey struct {
 
 // HlText is used in screen cache
 type HlTextKey struct {
+	font   *Font
 	fg     RGBA
 	text   string
 	italic bool
@@ -126,6 +128,11 @@ type Window struct {
 	cursorCache *Cache
 	widgets.QWidget
 	smoothScrollAnimation  *core.QPropertyAnimation
+	kinetic                kineticScroller
+	spring                 scrollSpring
+	hscrollBar             *HScrollBar
+	vscrollBar             *WindowScrollBar
+	hScrollAnimation       *core.QVariantAnimation
 	snapshot               *gui.QPixmap
 	imagePainter           *gui.QPainter
 	font                   *Font
@@ -147,6 +154,7 @@ type Window struct {
 	contentMaskOld         [][]bool
 	contentMask            [][]bool
 	content                [][]*Cell
+	colorLiteralCache      map[string][]colorLiteral
 	extwinAutoLayoutPosY   []int
 	extwinAutoLayoutPosX   []int
 	charsScaledLineHeight  []string
@@ -170,6 +178,7 @@ type Window struct {
 	scrollPixels3          int
 	id                     nvim.Window
 	scrollDelta            float64
+	hScrollOffset          float64
 	rows                   int
 	zindex                 *zindex
 	lastScrollphase        core.Qt__ScrollPhase
@@ -292,6 +301,7 @@ func (w *Window) paint(event *gui.QPaintEvent) {
 
 	w.paintMutex.Lock()
 
+	paintStart := time.Now()
 	p := gui.NewQPainter2(w)
 
 	// clip rect
@@ -399,6 +409,9 @@ func (w *Window) paint(event *gui.QPaintEvent) {
 	// Draw scroll snapshot
 	w.drawScrollSnapshot(p)
 
+	// Erase the area which the content left in the horizontal smooth scroll
+	w.fillHorizontalScrollGap(p)
+
 	// // Draw content outside the viewportMargin in the y-axis direction
 
 	for y := row; y <= row+rows; y++ {
@@ -424,7 +437,12 @@ func (w *Window) paint(event *gui.QPaintEvent) {
 
 	// Draw indent guide
 	if editor.config.Editor.IndentGuide {
-		w.drawIndentguide(p, row, rows)
+		w.drawIndentguide(p)
+	}
+
+	// Draw color swatches
+	if editor.config.Editor.ColorSwatch {
+		w.drawColorSwatches(p)
 	}
 
 	// Draw float window border
@@ -442,6 +460,10 @@ func (w *Window) paint(event *gui.QPaintEvent) {
 		if w.s.ws.minimap != nil {
 			if w.s.ws.minimap.visible && w.s.ws.minimap.widget.IsVisible() {
 				w.s.ws.minimap.updateCurrentRegion(p)
+				w.s.ws.minimap.drawMarks(p)
+				if editor.opts.Debug != "" {
+					w.s.ws.minimap.logFrameTime(paintStart)
+				}
 			}
 		}
 	}
@@ -552,244 +574,6 @@ func (w *Window) getFallbackFonts() []*Font {
 	return w.fallbackfonts
 }
 
-func (w *Window) drawIndentguide(p *gui.QPainter, row, rows int) {
-	if w == nil {
-		return
-	}
-	if w.grid == 1 || w.isMsgGrid {
-		return
-	}
-	if w.s.name == "minimap" {
-		return
-	}
-	if w.isMsgGrid {
-		return
-	}
-	if w.ft == "" {
-		return
-	}
-	for _, v := range editor.config.Editor.IndentGuideIgnoreFtList {
-		if v == w.ft {
-			return
-		}
-	}
-	if !w.isShown() {
-		return
-	}
-	if w.ts == 0 {
-		return
-	}
-
-	ts := w.ts
-
-	headspaceOfRows := make(map[int]int)
-	for y := row; y < rows; y++ {
-		if y+1 >= len(w.content) {
-			break
-		}
-		l, _ := w.countHeadSpaceOfLine(y)
-		headspaceOfRows[y] = l
-	}
-
-	drawIndents := make(map[IntInt]bool)
-	for y := row; y < rows; y++ {
-		if y+1 >= len(w.content) {
-			break
-		}
-		// nextline := w.content[y+1]
-		line := w.content[y]
-		res := 0
-		for x := 0; x < w.maxLenContent; x++ {
-			if x+1 >= len(line) {
-				break
-			}
-			if line[x+1] == nil {
-				continue
-			}
-			c := line[x]
-			if c == nil {
-				continue
-			}
-			if c.highlight.isSignColumn() {
-				res++
-			}
-			if c.char != " " && !c.highlight.isSignColumn() {
-				break
-			}
-			// yylen, _ := w.countHeadSpaceOfLine(y)
-			yylen := headspaceOfRows[y]
-			if x > res && (x+1-res)%ts == 0 {
-				ylen := x + 1
-
-				if ylen > yylen {
-					break
-				}
-
-				doPaintIndent := false
-				for mm := y; mm < len(w.content); mm++ {
-					if drawIndents[[2]int{x + 1, mm}] {
-						continue
-					}
-
-					// mmlen, _ := w.countHeadSpaceOfLine(mm)
-					mmlen := headspaceOfRows[mm]
-
-					if mmlen == ylen {
-						break
-					}
-
-					if mmlen > ylen && w.lenLine[mm] > res {
-						doPaintIndent = true
-					}
-
-					if mmlen == w.cols && !doPaintIndent {
-						for nn := mm + 1; nn < len(w.content); nn++ {
-							// nnlen, _ := w.countHeadSpaceOfLine(nn)
-							nnlen := headspaceOfRows[nn]
-							if nnlen == ylen {
-								break
-							}
-
-							if nnlen < ylen {
-								break
-							}
-
-							if nnlen > ylen && w.lenLine[nn] > res {
-								doPaintIndent = true
-							}
-						}
-					}
-
-					if mmlen < ylen {
-						doBreak := true
-						// If the line to draw an indent-guide has a wrapped line
-						// in the next line, do not skip drawing
-						// TODO: We do not detect the wrapped line when `:set nonu` setting.
-						if mm+1 < len(w.content) {
-							// lllen, _ := w.countHeadSpaceOfLine(mm+1)
-							lllen := headspaceOfRows[mm+1]
-							if mm >= 0 {
-								if lllen > ylen {
-									for xx := 0; xx < w.lenLine[mm]; xx++ {
-										if xx >= len(w.content[mm]) {
-											continue
-										}
-										if w.content[mm][xx] == nil {
-											continue
-										}
-										if w.content[mm][xx].highlight.hlName == "LineNr" {
-											if w.content[mm][xx].char == " " {
-												doBreak = false
-											} else if w.content[mm][xx].char != " " {
-												doBreak = true
-												break
-											}
-										}
-									}
-								}
-							}
-						}
-						if doBreak {
-							break
-						}
-					}
-
-					if w.content[mm][x+1] == nil {
-						break
-					}
-					if w.content[mm][x+1].char != " " {
-						break
-					}
-					if !doPaintIndent {
-						break
-					}
-					if !drawIndents[[2]int{x + 1, mm}] {
-						drawIndents[[2]int{x + 1, mm}] = true
-					}
-				}
-			}
-		}
-	}
-
-	// detect current block
-	currentBlock := make(map[IntInt]bool)
-	for x := w.s.cursor[1]; x >= 0; x-- {
-		if drawIndents[[2]int{x + 1, w.s.cursor[0]}] {
-			for y := w.s.cursor[0]; y >= 0; y-- {
-				if drawIndents[[2]int{x + 1, y}] {
-					currentBlock[[2]int{x + 1, y}] = true
-				}
-				if !drawIndents[[2]int{x + 1, y}] {
-					break
-				}
-			}
-			for y := w.s.cursor[0]; y < len(w.content); y++ {
-				if drawIndents[[2]int{x + 1, y}] {
-					currentBlock[[2]int{x + 1, y}] = true
-				}
-				if !drawIndents[[2]int{x + 1, y}] {
-					break
-				}
-			}
-
-			break
-		}
-	}
-
-	// draw indent guide
-	for y := row; y < len(w.content); y++ {
-		for x := 0; x < w.maxLenContent; x++ {
-			if !drawIndents[[2]int{x + 1, y}] {
-				continue
-			}
-			if currentBlock[[2]int{x + 1, y}] {
-				w.drawIndentline(p, x+1, y, true)
-			} else {
-				w.drawIndentline(p, x+1, y, false)
-			}
-		}
-	}
-}
-
-func (w *Window) drawIndentline(p *gui.QPainter, x int, y int, b bool) {
-	font := w.getFont()
-
-	// Set smooth scroll offset
-	var horScrollPixels, verScrollPixels int
-	if w.lastScrollphase != core.Qt__NoScrollPhase {
-		verScrollPixels = w.scrollPixels2
-	}
-	if editor.config.Editor.LineToScroll == 1 {
-		verScrollPixels += w.scrollPixels[1]
-	}
-
-	if w.s.ws.mouseScroll != "" {
-		horScrollPixels += w.scrollPixels[0]
-	}
-
-	X := float64(x)*font.cellwidth + float64(horScrollPixels)
-	Y := float64(y*font.lineHeight) + float64(verScrollPixels)
-	var color *RGBA = editor.colors.indentGuide
-	var lineWeight float64 = 1
-	if b {
-		color = warpColor(editor.colors.indentGuide, -40)
-		lineWeight = 1.5
-	}
-	p.FillRect4(
-		core.NewQRectF4(
-			X,
-			Y,
-			lineWeight,
-			float64(font.lineHeight),
-		),
-		color.QColor(),
-	)
-
-	if w.lenContent[y] < x {
-		w.lenContent[y] = x
-	}
-}
-
 func (w *Window) drawMsgSeparator(p *gui.QPainter) {
 	highNo, ok := w.s.highlightGroup["MsgSeparator"]
 	if !ok {
@@ -1027,14 +811,20 @@ func (w *Window) drawWindowSeparator(p *gui.QPainter, gwinrows int) {
 }
 
 func (w *Window) wheelEvent(event *gui.QWheelEvent) {
+	if w.s.ws.wheelZoom(event) {
+		return
+	}
 	if !w.s.ws.isMouseEnabled {
 		return
 	}
 
 	w.dropScreenSnapshot()
 
+	if w.kineticWheel(event) {
+		return
+	}
+
 	var v, h, vert, horiz int
-	var action string
 
 	editor.putLog("start wheel event")
 
@@ -1058,6 +848,9 @@ func (w *Window) wheelEvent(event *gui.QWheelEvent) {
 	}
 
 	phase := event.Phase()
+	if w.trackTouchpadScroll(event, v) {
+		return
+	}
 	if phase == core.Qt__ScrollEnd {
 		w.scrollPixels3 = 0
 	}
@@ -1100,7 +893,7 @@ func (w *Window) wheelEvent(event *gui.QWheelEvent) {
 		}
 	}
 
-	if editor.config.Editor.DisableHorizontalScroll {
+	if w.isHorizontalScrollDisabled() {
 		h = 0
 	}
 
@@ -1133,6 +926,19 @@ func (w *Window) wheelEvent(event *gui.QWheelEvent) {
 		return
 	}
 
+	mod := editor.modPrefix(event.Modifiers())
+	col := int(float64(event.X()) / font.cellwidth)
+	row := int(float64(event.Y()) / float64(font.lineHeight))
+
+	w.scrollByWheel(vert, horiz, mod, row, col)
+
+	event.Accept()
+}
+
+// scrollByWheel sends the scroll of vert lines and horiz columns to Neovim,
+// as mouse wheel input or as scroll commands.
+func (w *Window) scrollByWheel(vert, horiz int, mod string, row, col int) {
+	var action string
 	if editor.config.Editor.ReversingScrollDirection {
 		if vert < 0 {
 			action = "up"
@@ -1147,10 +953,6 @@ func (w *Window) wheelEvent(event *gui.QWheelEvent) {
 		}
 	}
 
-	mod := editor.modPrefix(event.Modifiers())
-	col := int(float64(event.X()) / font.cellwidth)
-	row := int(float64(event.Y()) / float64(font.lineHeight))
-
 	if w.s.ws.isMappingScrollKey || w.s.ws.mouseScroll != "" {
 		if vert != 0 {
 			w.s.ws.nvim.InputMouse("wheel", action, mod, w.grid, row, col)
@@ -1177,7 +979,7 @@ func (w *Window) wheelEvent(event *gui.QWheelEvent) {
 		go w.s.ws.nvim.Input(scrollKey)
 	}
 
-	if editor.config.Editor.DisableHorizontalScroll {
+	if w.isHorizontalScrollDisabled() {
 		return
 	}
 
@@ -1192,8 +994,6 @@ func (w *Window) wheelEvent(event *gui.QWheelEvent) {
 	if horiz != 0 {
 		go w.s.ws.nvim.InputMouse("wheel", action, mod, w.grid, row, col)
 	}
-
-	event.Accept()
 }
 
 func (w *Window) applyTemporaryMousescroll(ms string) {
@@ -1288,6 +1088,11 @@ func (win *Window) smoothScroll(delta float64) {
 		return
 	}
 
+	if editor.config.Editor.KineticScroll {
+		win.springScroll(delta)
+		return
+	}
+
 	win.initializeOrReuseSmoothScrollAnimation()
 
 	if win.smoothScrollAnimation.State() == core.QAbstractAnimation__Running {
@@ -1973,9 +1778,7 @@ func (w *Window) drawBackground(p *gui.QPainter, y int, col int, cols int, isDra
 		}
 
 		if !(y < w.viewportMargins[0] || y > w.rows-w.viewportMargins[1]-1) {
-			if w.s.ws.mouseScroll != "" {
-				horScrollPixels = w.scrollPixels[0]
-			}
+			horScrollPixels = w.horizontalScrollPixels()
 			if w.lastScrollphase != core.Qt__NoScrollPhase {
 				verScrollPixels = w.scrollPixels2
 			}
@@ -2325,14 +2128,13 @@ func (w *Window) drawText(p *gui.QPainter, y int, col int, cols int) {
 	line := w.content[y]
 	chars := map[HlKey][]int{}
 	specialChars := []int{}
+	builtinGlyphs := []int{}
 	cellBasedDrawing := editor.config.Editor.DisableLigatures || (editor.config.Editor.Letterspace > 0)
 	wsfontLineHeight := y * wsfont.lineHeight
 
 	// Set smooth scroll offset
-	var horScrollPixels, verScrollPixels int
-	if w.s.ws.mouseScroll != "" {
-		horScrollPixels = w.scrollPixels[0]
-	}
+	horScrollPixels := w.horizontalScrollPixels()
+	var verScrollPixels int
 	if w.lastScrollphase != core.Qt__NoScrollPhase {
 		verScrollPixels = w.scrollPixels2
 	}
@@ -2359,6 +2161,10 @@ func (w *Window) drawText(p *gui.QPainter, y int, col int, cols int) {
 		if line[x].char == " " {
 			continue
 		}
+		if _, ok := builtinGlyph(line[x].char); ok {
+			builtinGlyphs = append(builtinGlyphs, x)
+			continue
+		}
 		if !line[x].normalWidth {
 			specialChars = append(specialChars, x)
 			continue
@@ -2391,6 +2197,7 @@ func (w *Window) drawText(p *gui.QPainter, y int, col int, cols int) {
 				wsfontLineHeight+verScrollPixels,
 				line[x].char,
 				HlKey{
+					font:   w.getHighlightFont(line[x].highlight),
 					fg:     *(line[x].highlight.fg()),
 					bold:   line[x].highlight.bold,
 					italic: line[x].highlight.italic,
@@ -2408,6 +2215,7 @@ func (w *Window) drawText(p *gui.QPainter, y int, col int, cols int) {
 			}
 
 			hlkey := HlKey{
+				font:   w.getHighlightFont(highlight),
 				fg:     *(highlight.fg()),
 				italic: highlight.italic,
 				bold:   highlight.bold,
@@ -2534,6 +2342,7 @@ func (w *Window) drawText(p *gui.QPainter, y int, col int, cols int) {
 				wsfontLineHeight+verScrollPixels,
 				line[x].char,
 				HlKey{
+					font:   w.getHighlightFont(line[x].highlight),
 					fg:     *(line[x].highlight.fg()),
 					bold:   line[x].highlight.bold,
 					italic: line[x].highlight.italic,
@@ -2544,6 +2353,32 @@ func (w *Window) drawText(p *gui.QPainter, y int, col int, cols int) {
 
 		}
 	}
+
+	// Draw box-drawing characters etc. that goneovim renders by itself
+	for _, x := range builtinGlyphs {
+		if line[x].covered && w.grid == 1 {
+			continue
+		}
+		r, _ := builtinGlyph(line[x].char)
+
+		horScrollPixels = origHorSP
+		verScrollPixels = origVerSP
+		if line[x].highlight.isSignColumn() {
+			horScrollPixels = 0
+		}
+		if x < w.viewportMargins[2] || x > w.cols-w.viewportMargins[3]-1 {
+			horScrollPixels = 0
+			verScrollPixels = 0
+		}
+
+		w.drawBuiltinGlyph(
+			p,
+			int(float64(x)*wsfont.cellwidth)+horScrollPixels,
+			wsfontLineHeight+verScrollPixels,
+			r,
+			line[x].highlight.fg(),
+		)
+	}
 }
 
 func (w *Window) drawTextInPos(p *gui.QPainter, x, y int, text string, hlkey HlKey, isNormalWidth bool, scaled bool) {
@@ -2584,17 +2419,24 @@ func (w *Window) drawTextInPosWithNoCache(p *gui.QPainter, x, y int, text string
 		return
 	}
 
-	var fontfallbacked *Font
-	if !isASCII(text) && w.font == nil && w.s.fontwide != nil {
-		fontfallbacked = resolveFontFallback(w.s.fontwide, w.s.fallbackfontwides, text)
-	} else {
-		if w.font == nil {
-			fontfallbacked = resolveFontFallback(w.s.font, w.s.fallbackfonts, text)
-		} else {
-			fontfallbacked = resolveFontFallback(w.font, w.fallbackfonts, text)
+	// TextGamma and TextContrast are applied to the rasterized glyphs,
+	// so draw the text through an image which is not kept in the cache.
+	if !isTextGammaLinear() {
+		image := w.newTextCache(text, hlkey, isNormalWidth)
+		if image != nil {
+			p.DrawImage9(
+				x, y-w.getFont().baselineOffset,
+				image,
+				0, 0,
+				-1, -1,
+				core.Qt__AutoColor,
+			)
 		}
+		return
 	}
 
+	fontfallbacked := w.resolveTextFont(text, hlkey)
+
 	p.SetFont(fontfallbacked.qfont)
 
 	font := p.Font()
@@ -2603,9 +2445,45 @@ func (w *Window) drawTextInPosWithNoCache(p *gui.QPainter, x, y int, text string
 
 	font.SetBold(hlkey.bold)
 	font.SetItalic(hlkey.italic)
+
+	// Fit the text of the highlight font into the cells of the grid font
+	if hlkey.font != nil && isNormalWidth {
+		advance := fontfallbacked.fontMetrics.HorizontalAdvance(text, -1)
+		width := float64(len(text)) * w.getFont().cellwidth
+		if advance > 0 && advance != width {
+			p.Save()
+			p.Translate3(float64(x), 0)
+			p.Scale(width/advance, 1.0)
+			p.DrawText3(0, y, text)
+			p.Restore()
+			return
+		}
+	}
+
 	p.DrawText3(x, y, text)
 }
 
+// resolveTextFont returns the font used to draw text, taking into account
+// the font of the highlight group, guifontwide and the fallback fonts.
+func (w *Window) resolveTextFont(text string, hlkey HlKey) *Font {
+	if hlkey.font != nil {
+		return resolveFontFallback(hlkey.font, w.getFallbackFonts(), text)
+	}
+
+	var fontfallbacked *Font
+	if !isASCII(text) && w.font == nil && w.s.fontwide != nil {
+		fontfallbacked = resolveFontFallback(w.s.fontwide, w.s.fallbackfontwides, text)
+	} else {
+		if w.font == nil {
+			fontfallbacked = resolveFontFallback(w.s.font, w.s.fallbackfonts, text)
+		} else {
+			fontfallbacked = resolveFontFallback(w.font, w.fallbackfonts, text)
+		}
+	}
+
+	return fontfallbacked
+}
+
 func (w *Window) drawTextInPosWithCache(p *gui.QPainter, x, y int, text string, hlkey HlKey, isNormalWidth bool, scaled bool) {
 	if text == "" {
 		return
@@ -2614,6 +2492,7 @@ func (w *Window) drawTextInPosWithCache(p *gui.QPainter, x, y int, text string,
 	cache := w.getCache()
 	var image *gui.QImage
 	imagev, err := cache.get(HlTextKey{
+		font:   hlkey.font,
 		text:   text,
 		fg:     hlkey.fg,
 		italic: hlkey.italic,
@@ -2740,6 +2619,7 @@ func (w *Window) setTextCache(text string, hlkey HlKey, image *gui.QImage) {
 		// If window has own font setting
 		w.cache.set(
 			HlTextKey{
+				font:   hlkey.font,
 				text:   text,
 				fg:     hlkey.fg,
 				italic: hlkey.italic,
@@ -2751,6 +2631,7 @@ func (w *Window) setTextCache(text string, hlkey HlKey, image *gui.QImage) {
 		// screen text cache
 		w.s.cache.set(
 			HlTextKey{
+				font:   hlkey.font,
 				text:   text,
 				fg:     hlkey.fg,
 				italic: hlkey.italic,
@@ -2779,16 +2660,7 @@ func (w *Window) newTextCache(text string, hlkey HlKey, isNormalWidth bool) *gui
 	editor.putLog("start creating word cache:", text)
 
 	font := w.getFont()
-	var fontfallbacked *Font
-	if !isASCII(text) && w.font == nil && w.s.fontwide != nil {
-		fontfallbacked = resolveFontFallback(w.s.fontwide, w.s.fallbackfontwides, text)
-	} else {
-		if w.font == nil {
-			fontfallbacked = resolveFontFallback(w.s.font, w.s.fallbackfonts, text)
-		} else {
-			fontfallbacked = resolveFontFallback(w.font, w.fallbackfonts, text)
-		}
-	}
+	fontfallbacked := w.resolveTextFont(text, hlkey)
 
 	// Put debug log
 	if editor.opts.Debug != "" {
@@ -2807,8 +2679,11 @@ func (w *Window) newTextCache(text string, hlkey HlKey, isNormalWidth bool) *gui
 		width = float64(len(text))*font.italicWidth + 1
 	}
 
+	// The width of the cells that the text occupies in the grid
+	cellsWidth := width
+
 	fg := hlkey.fg
-	if !isNormalWidth {
+	if !isNormalWidth || hlkey.font != nil {
 		advance := fontfallbacked.fontMetrics.HorizontalAdvance(text, -1)
 		if advance > 0 {
 			width = advance
@@ -2848,6 +2723,7 @@ func (w *Window) newTextCache(text string, hlkey HlKey, isNormalWidth bool) *gui
 
 	w.imagePainter.SetPen2(fg.QColor())
 	w.imagePainter.SetFont(fontfallbacked.qfont)
+	setTextRenderHints(w.imagePainter)
 
 	if hlkey.bold {
 		w.imagePainter.Font().SetBold(hlkey.bold)
@@ -2873,6 +2749,8 @@ func (w *Window) newTextCache(text string, hlkey HlKey, isNormalWidth bool) *gui
 	w.imagePainter.End()
 	// pi.DestroyQPainter()
 
+	image = adjustTextGamma(image)
+
 	editor.putLog("finished creating word cache:", text)
 
 	if !isNormalWidth {
@@ -2880,6 +2758,11 @@ func (w *Window) newTextCache(text string, hlkey HlKey, isNormalWidth bool) *gui
 			image,
 			float64(font.cellwidth)*2.0/width,
 		)
+	} else if hlkey.font != nil {
+		image = scaleToTextWidth(
+			image,
+			int(math.Ceil(w.devicePixelRatio*cellsWidth)),
+		)
 	}
 
 	return image
@@ -2900,7 +2783,11 @@ func scaleToGridCell(image *gui.QImage, ratio float64) *gui.QImage {
 
 func (w *Window) drawForeground(p *gui.QPainter, y int, col int, cols int) {
 	if w.s.name == "minimap" {
-		w.drawMinimap(p, y, col, cols)
+		if m := w.s.ws.minimap; m != nil && m.isBlocksMode() {
+			w.drawMinimapBlocks(p, m, y)
+		} else {
+			w.drawMinimap(p, y, col, cols)
+		}
 	} else {
 		// w.drawText(p, y, col, cols)
 		w.drawText(p, y, 0, w.cols)
@@ -2917,10 +2804,8 @@ func (w *Window) drawTextDecoration(p *gui.QPainter, y int, col int, cols int) {
 	cache := w.getCache()
 
 	// Set smooth scroll offset
-	var horScrollPixels, verScrollPixels int
-	if w.s.ws.mouseScroll != "" {
-		horScrollPixels += w.scrollPixels[0]
-	}
+	horScrollPixels := w.horizontalScrollPixels()
+	var verScrollPixels int
 	if w.lastScrollphase != core.Qt__NoScrollPhase {
 		verScrollPixels = w.scrollPixels2
 	}
@@ -3418,7 +3303,7 @@ func newWindow() *Window {
 func (win *Window) initializeOrReuseSmoothScrollAnimation() {
 	if win.smoothScrollAnimation == nil {
 		win.smoothScrollAnimation = core.NewQPropertyAnimation2(win, core.NewQByteArray2("scrollDiff", -1), win)
-		win.smoothScrollAnimation.SetEasingCurve(core.NewQEasingCurve(core.QEasingCurve__OutExpo))
+		win.smoothScrollAnimation.SetEasingCurve(core.NewQEasingCurve(easingCurve(editor.config.Editor.SmoothScrollEasing)))
 		win.smoothScrollAnimation.SetDuration(editor.config.Editor.SmoothScrollDuration)
 
 		win.smoothScrollAnimation.ConnectValueChanged(func(value *core.QVariant) {
@@ -3427,42 +3312,47 @@ func (win *Window) initializeOrReuseSmoothScrollAnimation() {
 			if !ok {
 				return
 			}
-			font := win.getFont()
+			win.applySmoothScrollOffset(v)
+		})
+	}
+}
 
-			win.scrollPixels2 = int(v * float64(font.lineHeight))
+// applySmoothScrollOffset shifts the grid by v lines
+// during the smooth scroll by commands.
+func (win *Window) applySmoothScrollOffset(v float64) {
+	font := win.getFont()
 
-			// var x, y int
-			// win.Update2(
-			// 	x+win.viewportMargins[2]*int(font.cellwidth),
-			// 	y+(win.viewportMargins[0]*font.lineHeight),
-			// 	int(float64(win.cols)*font.cellwidth)-win.viewportMargins[2]*int(font.cellwidth)-win.viewportMargins[3]*int(font.cellwidth),
-			// 	win.rows*font.lineHeight-(win.viewportMargins[0]*font.lineHeight)-(win.viewportMargins[1]*font.lineHeight),
-			// )
+	win.scrollPixels2 = int(v * float64(font.lineHeight))
 
-			var y int
-			win.Update2(
-				0,
-				y+(win.viewportMargins[0]*font.lineHeight),
-				int(float64(win.cols)*font.cellwidth),
-				win.rows*font.lineHeight-(win.viewportMargins[0]*font.lineHeight)-(win.viewportMargins[1]*font.lineHeight),
-			)
+	// var x, y int
+	// win.Update2(
+	// 	x+win.viewportMargins[2]*int(font.cellwidth),
+	// 	y+(win.viewportMargins[0]*font.lineHeight),
+	// 	int(float64(win.cols)*font.cellwidth)-win.viewportMargins[2]*int(font.cellwidth)-win.viewportMargins[3]*int(font.cellwidth),
+	// 	win.rows*font.lineHeight-(win.viewportMargins[0]*font.lineHeight)-(win.viewportMargins[1]*font.lineHeight),
+	// )
 
-			if v == 0 {
-				win.scrollPixels2 = 0
-				win.scrollDelta = 0
-				win.doErase = true
-				win.Update2(
-					0,
-					y+(win.viewportMargins[0]*font.lineHeight),
-					int(float64(win.cols)*font.cellwidth),
-					win.rows*font.lineHeight-(win.viewportMargins[0]*font.lineHeight)-(win.viewportMargins[1]*font.lineHeight),
-				)
-				win.doErase = false
-				win.fill()
+	var y int
+	win.Update2(
+		0,
+		y+(win.viewportMargins[0]*font.lineHeight),
+		int(float64(win.cols)*font.cellwidth),
+		win.rows*font.lineHeight-(win.viewportMargins[0]*font.lineHeight)-(win.viewportMargins[1]*font.lineHeight),
+	)
 
-			}
+	if v == 0 {
+		win.scrollPixels2 = 0
+		win.scrollDelta = 0
+		win.doErase = true
+		win.Update2(
+			0,
+			y+(win.viewportMargins[0]*font.lineHeight),
+			int(float64(win.cols)*font.cellwidth),
+			win.rows*font.lineHeight-(win.viewportMargins[0]*font.lineHeight)-(win.viewportMargins[1]*font.lineHeight),
+		)
+		win.doErase = false
+		win.fill()
 
-		})
 	}
 }
 
@@ -3614,7 +3504,7 @@ func (w *Window) dropEvent(e *gui.QDropEvent) {
 					if editor.config.Editor.ShowDiffDialogOnDrop && bufName != "" {
 						w.s.howToOpen(filepath)
 					} else {
-						fileOpenInBuf(filepath)
+						w.s.fileOpenInBuf(filepath)
 					}
 				}
 			default:
@@ -3799,6 +3689,13 @@ func (w *Window) setGridGeometry(width, height int) {
 	rect := core.NewQRect4(0, 0, width, height)
 	w.SetGeometry(rect)
 	w.fill()
+
+	if w.hscrollBar != nil {
+		w.hscrollBar.layout()
+	}
+	if w.vscrollBar != nil && w.vscrollBar.widget.IsVisible() {
+		w.vscrollBar.layout()
+	}
 }
 
 // refreshUpdateArea:: arg:0 => full, arg:1 => full only text
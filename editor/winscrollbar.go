@@ -0,0 +1,203 @@
+package editor
+
+import (
+	"fmt"
+
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+	"github.com/akiyosi/qt/widgets"
+)
+
+// WindowScrollBar is the thin scrollbar laid over the right edge of a window,
+// drawn for every split when ScrollBar.PerWindow is set.
+type WindowScrollBar struct {
+	scrollBarBase
+	win       *Window
+	hideTimer *core.QTimer
+	// top and bottom are the first and the last line of the window
+	// as the win_viewport event reports, and maxLine is the line count.
+	top          int
+	bottom       int
+	maxLine      int
+	beginPosY    int
+	beginTop     int
+	requestTop   int
+	isPressed    bool
+	isMouseHover bool
+}
+
+func newWindowScrollBar(win *Window) *WindowScrollBar {
+	widget := widgets.NewQWidget(win, 0)
+	widget.SetContentsMargins(0, 0, 0, 0)
+	widget.SetFixedWidth(editor.config.ScrollBar.Width)
+	thumb := widgets.NewQWidget(widget, 0)
+	thumb.SetFixedWidth(8)
+
+	v := &WindowScrollBar{
+		scrollBarBase: scrollBarBase{
+			widget: widget,
+			thumb:  thumb,
+		},
+		win: win,
+	}
+
+	v.thumb.ConnectMousePressEvent(v.thumbPress)
+	v.thumb.ConnectMouseMoveEvent(v.thumbScroll)
+	v.thumb.ConnectMouseReleaseEvent(v.thumbRelease)
+	v.thumb.ConnectEnterEvent(v.thumbEnter)
+	v.thumb.ConnectLeaveEvent(v.thumbLeave)
+
+	if editor.config.ScrollBar.AutoHideDelay > 0 {
+		v.hideTimer = core.NewQTimer(nil)
+		v.hideTimer.SetSingleShot(true)
+		v.hideTimer.ConnectTimeout(func() {
+			if v.isPressed || v.isMouseHover {
+				return
+			}
+			v.widget.Hide()
+		})
+	}
+
+	v.setColor()
+	v.widget.Hide()
+
+	return v
+}
+
+func (v *WindowScrollBar) thumbEnter(e *core.QEvent) {
+	v.isMouseHover = true
+	v.highlightThumb(v.win.s.ws.screenbg)
+}
+
+func (v *WindowScrollBar) thumbLeave(e *core.QEvent) {
+	v.isMouseHover = false
+	v.unhighlightThumb()
+	v.startHideTimer()
+}
+
+func (v *WindowScrollBar) thumbPress(e *gui.QMouseEvent) {
+	if e.Button() != core.Qt__LeftButton {
+		return
+	}
+	v.beginPosY = e.GlobalPos().Y()
+	v.beginTop = v.top
+	v.requestTop = v.top
+	v.isPressed = true
+}
+
+// thumbScroll scrolls the window of the scrollbar, which is not necessarily
+// the current window, by setting its topline.
+func (v *WindowScrollBar) thumbScroll(e *gui.QMouseEvent) {
+	if !v.isPressed {
+		return
+	}
+
+	visible := v.bottom - v.top
+	offset := scrollOffset(
+		v.widget.Height(),
+		v.beginTop-1,
+		e.GlobalPos().Y()-v.beginPosY,
+		v.maxLine,
+		visible,
+	)
+	top := offset + 1
+	if top == v.requestTop {
+		return
+	}
+	v.requestTop = top
+
+	// Move the thumb along with the mouse without waiting for Neovim.
+	if pos, _, ok := scrollThumb(v.widget.Height(), offset, v.maxLine, visible); ok {
+		v.thumb.Move2(v.widget.Width()-v.thumb.Width(), pos)
+	}
+
+	go v.win.s.ws.nvim.Command(
+		fmt.Sprintf(`call win_execute(%d, 'call winrestview({"topline": %d})')`, v.win.id, top),
+	)
+}
+
+func (v *WindowScrollBar) thumbRelease(e *gui.QMouseEvent) {
+	v.isPressed = false
+	v.startHideTimer()
+}
+
+func (v *WindowScrollBar) startHideTimer() {
+	if v.hideTimer == nil {
+		return
+	}
+	v.hideTimer.Start(editor.config.ScrollBar.AutoHideDelay)
+}
+
+// update follows the viewport of the window. The scrollbar shows up
+// when the window scrolls, and hides again after AutoHideDelay.
+func (v *WindowScrollBar) update(top, bottom, maxLine int) {
+	isScrolled := top != v.top || maxLine != v.maxLine
+	v.top = top
+	v.bottom = bottom
+	v.maxLine = maxLine
+
+	if !v.layout() {
+		return
+	}
+	if v.hideTimer == nil {
+		v.widget.Show()
+		return
+	}
+	if isScrolled || v.isPressed {
+		v.widget.Show()
+		v.startHideTimer()
+	}
+}
+
+// layout places the scrollbar along the right edge of the window,
+// and reports whether the window has more lines than it shows.
+func (v *WindowScrollBar) layout() bool {
+	win := v.win
+	font := win.getFont()
+	width := v.widget.Width()
+	height := win.rows * font.lineHeight
+
+	pos, size, ok := scrollThumb(height, v.top-1, v.maxLine, v.bottom-v.top)
+	if !ok {
+		v.widget.Hide()
+		return false
+	}
+
+	v.widget.SetGeometry2(int(float64(win.cols)*font.cellwidth)-width, 0, width, height)
+	v.thumb.SetFixedHeight(size)
+	if !v.isPressed {
+		v.thumb.Move2(width-v.thumb.Width(), pos)
+	}
+	v.widget.Raise()
+
+	return true
+}
+
+// updateWindowScrollBars updates the scrollbar of every window
+// from its viewport.
+func (ws *Workspace) updateWindowScrollBars() {
+	ws.screen.windows.Range(func(_, winITF interface{}) bool {
+		win := winITF.(*Window)
+		if win == nil || win.grid == 1 || win.isMsgGrid || win.isPopupmenu || win.isFloatWin {
+			return true
+		}
+		if !win.isShown() {
+			return true
+		}
+
+		ws.viewportMutex.RLock()
+		viewport, ok := ws.viewportByGrid[int(win.grid)]
+		maxLine := ws.maxLineByGrid[int(win.grid)]
+		ws.viewportMutex.RUnlock()
+		if !ok {
+			return true
+		}
+
+		if win.vscrollBar == nil {
+			win.vscrollBar = newWindowScrollBar(win)
+		}
+		win.vscrollBar.update(viewport[0], viewport[1], maxLine)
+
+		return true
+	})
+}
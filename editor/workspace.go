@@ -60,10 +60,15 @@ type Workspace struct {
 	screen             *Screen
 	scrollBar          *ScrollBar
 	palette            *Palette
+	finder             *Finder
+	grep               *GrepPanel
+	commandPalette     *CommandPalette
+	uiPrompt           *UIPrompt
 	popup              *PopupMenu
 	cmdline            *Cmdline
 	message            *Message
 	minimap            *MiniMap
+	gesture            *gestureRecognizer
 	fontdialog         *widgets.QFontDialog
 	guiUpdates         chan []interface{}
 	redrawUpdates      chan [][]interface{}
@@ -73,8 +78,10 @@ type Workspace struct {
 	widget             *widgets.QWidget
 	special            *RGBA
 	background         *RGBA
+	indentScopeColor   *RGBA
 	colorscheme        string
 	cwdlabel           string
+	name               string
 	escKeyInNormal     string
 	mode               string
 	cwdBase            string
@@ -84,6 +91,7 @@ type Workspace struct {
 	screenbg           string
 	mouseScroll        string
 	mouseScrollTemp    string
+	indentGuideColors  []*RGBA
 	normalMappings     []*nvim.Mapping
 	modeInfo           []map[string]interface{}
 	insertMappings     []*nvim.Mapping
@@ -91,8 +99,15 @@ type Workspace struct {
 	oldViewport        [5]int
 	viewportByGrid     map[int][5]int
 	oldViewportByGrid  map[int][5]int
+	maxLineByGrid      map[int]int
 	height             int
 	maxLine            int
+	detached           *DetachedWindow
+	startPage          *StartPage
+	detachedGeometry   *core.QByteArray
+	modifiedBuffers    int
+	git                *gitStatus
+	unreadErrors       int
 	rows               int
 	cols               int
 	resizeReqs         []resizeRequest
@@ -100,6 +115,8 @@ type Workspace struct {
 	showtabline        int
 	width              int
 	modeIdx            int
+	zoomLevel          int
+	zoomWheelDelta     int
 	pb                 int
 	ts                 int
 	ph                 int
@@ -166,8 +183,10 @@ func newWorkspace() *Workspace {
 		background:        newRGBA(0, 0, 0, 1),
 		special:           newRGBA(255, 255, 255, 1),
 		shouldUpdate:      &ShouldUpdate{},
+		gesture:           &gestureRecognizer{},
 		viewportByGrid:    make(map[int][5]int),
 		oldViewportByGrid: make(map[int][5]int),
+		maxLineByGrid:     make(map[int]int),
 	}
 
 	return ws
@@ -274,11 +293,10 @@ func (ws *Workspace) initUI() {
 }
 
 func (ws *Workspace) initFont() {
-	ws.screen.font = editor.font
-	ws.screen.fallbackfonts = editor.fallbackfonts
+	ws.screen.font, ws.screen.fallbackfonts = scaleFonts(editor.font, editor.fallbackfonts, zoomFactor(ws.zoomLevel))
 	ws.font = ws.screen.font
-	ws.screen.tooltip.setFont(editor.font)
-	ws.screen.tooltip.fallbackfonts = editor.fallbackfonts
+	ws.screen.tooltip.setFont(ws.font)
+	ws.screen.tooltip.fallbackfonts = ws.screen.fallbackfonts
 	ws.font.ws = ws
 	if ws.tabline != nil {
 		ws.tabline.font = ws.font.qfont
@@ -291,6 +309,9 @@ func (ws *Workspace) lazyLoad() {
 	// set GUI clipboard
 	go setGoneovimClipBoard(ws.nvim)
 
+	// replace vim.ui.select and vim.ui.input
+	go setGoneovimUI(ws.nvim)
+
 	editor.putLog("preparing scrollbar")
 	// scrollbar
 	if editor.config.ScrollBar.Visible {
@@ -314,7 +335,12 @@ func (ws *Workspace) lazyLoad() {
 
 	editor.putLog("preparing filer")
 	// Add editor feature
-	go filer.RegisterPlugin(ws.nvim, editor.config.Editor.FileOpenCmd)
+	go filer.RegisterPlugin(ws.nvim, filer.Options{
+		OpenCmd:     editor.config.Editor.FileOpenCmd,
+		ShowHidden:  editor.config.FileExplore.ShowHidden,
+		ShowIgnored: editor.config.FileExplore.ShowIgnored,
+		AutoRefresh: editor.config.FileExplore.AutoRefresh,
+	})
 
 	editor.putLog("preparing minimap buffer")
 	// Asynchronously execute the process for minimap
@@ -387,6 +413,7 @@ func (ws *Workspace) registerSignal(signal *neovimSignal, redrawUpdates chan [][
 		}
 		editor.workspaceUpdate()
 		ws.lazyLoad()
+		ws.showStartPage()
 	})
 
 	ws.signal.ConnectStopSignal(func() {
@@ -416,43 +443,17 @@ func (ws *Workspace) registerSignal(signal *neovimSignal, redrawUpdates chan [][
 		}
 
 		editor.workspaces = workspaces
-
-		for i := 0; i < len(editor.side.items); i++ {
-			if i >= index && i+1 < len(editor.side.items) {
-				editor.side.items[i].copy(editor.side.items[i+1])
-			}
-			if i+1 == len(editor.side.items) {
-				editor.side.items[i].label.SetText("")
-				editor.side.items[i].hidden = false
-				editor.side.items[i].active = false
-				editor.side.items[i].text = ""
-				editor.side.items[i].cwdpath = ""
-				editor.side.items[i].isContentHide = false
-
-				content := widgets.NewQListWidget(nil)
-				content.SetFocusPolicy(core.Qt__NoFocus)
-				content.SetFrameShape(widgets.QFrame__NoFrame)
-				content.SetHorizontalScrollBarPolicy(core.Qt__ScrollBarAlwaysOff)
-				content.SetFont(editor.font.qfont)
-				content.SetIconSize(core.NewQSize2(editor.iconSize*3/4, editor.iconSize*3/4))
-				editor.side.items[i].content = content
-				editor.side.items[i].widget.Layout().AddWidget(content)
-			}
-			if i == maxworkspaceIndex {
-				editor.side.items[i].hidden = true
-				editor.side.items[i].hidden = false
-			}
-			editor.side.items[i].setSideItemLabel(i)
+		editor.side.removeItem(index, maxworkspaceIndex)
+		if ws.detached != nil {
+			ws.detached.close()
+			ws.detached = nil
 		}
 
 		ws.hide()
-		if editor.active == index {
-			if index > 0 {
-				editor.active--
-			}
-			editor.workspaceUpdate()
+		if editor.active > index || (editor.active == index && index > 0) {
+			editor.active--
 		}
-
+		editor.workspaceUpdate()
 	})
 }
 
@@ -756,6 +757,7 @@ func (ws *Workspace) setCwd(cwd string) {
 	}
 	ws.cwdlabel = labelpath
 	ws.cwdBase = filepath.Base(cwd)
+	editor.recent.add(recentProject, cwd)
 	if editor.side == nil {
 		return
 	}
@@ -771,7 +773,7 @@ func (ws *Workspace) setCwd(cwd string) {
 				continue
 			}
 
-			sideItem.label.SetText(wse.cwdlabel)
+			sideItem.label.SetText(wse.sideLabel())
 			sideItem.label.SetFont(editor.font.qfont)
 			sideItem.cwdpath = path
 		}
@@ -848,6 +850,13 @@ func (ws *Workspace) updateSize() (windowWidth, windowHeight, cols, rows int) {
 	}
 	height -= titlebarHeight
 
+	// The detached workspace fills its own window.
+	if ws.detached != nil {
+		marginWidth, marginHeight, sideWidth, titlebarHeight = 0, 0, 0, 0
+		width = ws.detached.window.Width()
+		height = ws.detached.window.Height()
+	}
+
 	tablineHeight := 0
 	if ws.isDrawTabline && ws.tabline != nil {
 		if ws.tabline.showtabline != -1 {
@@ -903,12 +912,22 @@ func (ws *Workspace) updateSize() (windowWidth, windowHeight, cols, rows int) {
 		ws.cursor.resize(ws.cursor.width, ws.cursor.height)
 		ws.cursor.update()
 	}
+	if ws.finder != nil && !ws.finder.palette.hidden {
+		ws.finder.resize()
+	}
 	if ws.palette != nil {
 		ws.palette.resize()
 	}
+	ws.grep.resize()
+	if ws.commandPalette != nil && !ws.commandPalette.palette.hidden {
+		ws.commandPalette.resize()
+	}
 	if ws.message != nil {
 		ws.message.resize()
 	}
+	if ws.startPage != nil {
+		ws.startPage.resize()
+	}
 
 	windowWidth = marginWidth + sideWidth + scrollbarWidth + minimapWidth + ws.screen.width
 	windowHeight = marginHeight + titlebarHeight + tablineHeight + ws.screen.height
@@ -922,6 +941,9 @@ func (ws *Workspace) updateApplicationWindowSize(cols, rows int) {
 	e := editor
 	font := ws.font
 
+	if ws.detached != nil {
+		return
+	}
 	if e.window.WindowState() == core.Qt__WindowFullScreen ||
 		e.window.WindowState() == core.Qt__WindowMaximized {
 		return
@@ -1240,6 +1262,9 @@ func (ws *Workspace) updateScrollbar() {
 			ws.scrollBar.update()
 		}
 	}
+	if editor.config.ScrollBar.PerWindow {
+		ws.updateWindowScrollBars()
+	}
 }
 
 func (ws *Workspace) updateIMETooltip() {
@@ -1441,6 +1466,21 @@ func (ws *Workspace) updateWorkspaceColor() {
 			ws.scrollBar.setColor()
 		}
 	}
+	if ws.screen != nil {
+		ws.screen.windows.Range(func(_, winITF interface{}) bool {
+			win := winITF.(*Window)
+			if win == nil {
+				return true
+			}
+			if win.hscrollBar != nil {
+				win.hscrollBar.setColor()
+			}
+			if win.vscrollBar != nil {
+				win.vscrollBar.setColor()
+			}
+			return true
+		})
+	}
 
 	if editor.side != nil {
 		editor.side.setColor()
@@ -1450,6 +1490,10 @@ func (ws *Workspace) updateWorkspaceColor() {
 
 func (ws *Workspace) setTitle(args []interface{}) {
 	titleStr := (args[0].([]interface{}))[0].(string)
+	if ws.detached != nil {
+		ws.detached.window.SetWindowTitle(titleStr)
+		return
+	}
 	editor.window.SetupTitle(titleStr)
 	if runtime.GOOS == "linux" {
 		editor.window.SetWindowTitle(titleStr)
@@ -1529,6 +1573,7 @@ func (ws *Workspace) msgShow(args []interface{}) {
 	if ws.message != nil {
 		ws.message.msgShow(args)
 	}
+	ws.countUnreadErrors(args)
 }
 
 func (ws *Workspace) cmdlineFunctionHide(args []interface{}) {
@@ -1703,11 +1748,13 @@ func (ws *Workspace) gridResize(args []interface{}) {
 func (ws *Workspace) busyStart() {
 	ws.cursor.isBusy = true
 	ws.shouldUpdate.cursor = true
+	ws.updateSideBadges()
 }
 
 func (ws *Workspace) busyStop() {
 	ws.cursor.isBusy = false
 	ws.shouldUpdate.cursor = true
+	ws.updateSideBadges()
 }
 
 func (ws *Workspace) mouseOn() {
@@ -1731,16 +1778,6 @@ func (ws *Workspace) modeChange(args []interface{}) {
 }
 
 func (ws *Workspace) winViewport(args []interface{}) {
-	// smooth scroll feature disabled
-	if !editor.config.Editor.SmoothScroll {
-		return
-	}
-
-	// Suppress smooth scroll rendering when key auto-repeat is enabled
-	if editor.isKeyAutoRepeating {
-		return
-	}
-
 	for _, e := range args {
 		arg := e.([]interface{})
 
@@ -1776,6 +1813,7 @@ func (ws *Workspace) winViewport(args []interface{}) {
 
 		ws.oldViewportByGrid[grid] = prevViewport
 		ws.viewportByGrid[grid] = viewport
+		ws.maxLineByGrid[grid] = maxLine
 
 		if grid == ws.cursor.gridid {
 			ws.oldViewport = prevViewport
@@ -1785,6 +1823,17 @@ func (ws *Workspace) winViewport(args []interface{}) {
 
 		ws.viewportMutex.Unlock()
 
+		// The viewport is kept above for the scrollbar,
+		// the rest is for the smooth scroll feature.
+		if !editor.config.Editor.SmoothScroll {
+			continue
+		}
+
+		// Suppress smooth scroll rendering when key auto-repeat is enabled
+		if editor.isKeyAutoRepeating {
+			continue
+		}
+
 		if delta == 0 {
 			continue
 		}
@@ -1862,6 +1911,19 @@ func (ws *Workspace) scrollMinimap() {
 	currLine := ws.viewport[2]
 	ws.viewportMutex.RUnlock()
 
+	if isMinimapPrimary() {
+		m := ws.minimap
+		m.mu.Lock()
+		topline := minimapTopline(m.topline, m.rows, topLine, botLine, currLine)
+		changed := topline != m.topline
+		m.topline = topline
+		m.mu.Unlock()
+		if changed {
+			m.requestChunks()
+		}
+		return
+	}
+
 	switch {
 	case botLine > absMapBottom:
 		ws.minimap.nvim.Input(`<ScrollWheelDown>`)
@@ -1891,6 +1953,10 @@ func (ws *Workspace) handleGui(updates []interface{}) {
 		if len(updates) == 2 {
 			arg = util.ReflectToInt(updates[1])
 		}
+		if ws.detached != nil {
+			ws.detached.setFullscreen(arg != 0)
+			return
+		}
 		if arg == 0 {
 			// On MacOS, exiting from fullscreen does not work properly
 			// unless the window is fullscreened again beforehand.
@@ -1912,6 +1978,10 @@ func (ws *Workspace) handleGui(updates []interface{}) {
 		if len(updates) == 2 {
 			arg = util.ReflectToInt(updates[1])
 		}
+		if ws.detached != nil {
+			ws.detached.setMaximized(arg != 0)
+			return
+		}
 		if arg == 0 {
 			editor.window.WindowExitMaximize()
 		} else {
@@ -1939,6 +2009,11 @@ func (ws *Workspace) handleGui(updates []interface{}) {
 		ws.toggleSmoothCursor()
 	case "gonvim_indentguide":
 		ws.toggleIndentguide()
+	case "gonvim_indent_guide_colors":
+		if len(updates) < 3 {
+			return
+		}
+		ws.setIndentGuideColors(updates[1], updates[2])
 	case "gonvim_ligatures":
 		ws.toggleLigatures()
 	case "gonvim_mousescroll_unit":
@@ -1985,13 +2060,25 @@ func (ws *Workspace) handleGui(updates []interface{}) {
 	case "filer_resize":
 		editor.side.items[ws.getNum()].resizeContent()
 	case "filer_item_add":
-		editor.side.items[ws.getNum()].addItem(updates[1:])
+		editor.side.items[ws.getNum()].addItem(updates[1:], ws.git)
 	case "filer_item_select":
 		editor.side.items[ws.getNum()].selectItem(updates[1:])
+	case "filer_focus":
+		editor.side.items[ws.getNum()].content.SetFocus2()
+	case "filer_unfocus":
+		ws.widget.SetFocus2()
 	case "gonvim_letter_spacing":
 		ws.letterSpacing(updates[1])
 	case "gonvim_grid_font":
 		ws.screen.gridFont(updates[1])
+	case "gonvim_highlight_font":
+		ws.screen.setHighlightFont(updates[1:])
+	case "gonvim_zoom_in":
+		ws.zoomIn()
+	case "gonvim_zoom_out":
+		ws.zoomOut()
+	case "gonvim_zoom_reset":
+		ws.zoomReset()
 	case "gonvim_macmeta":
 		ws.handleMacmeta(updates[1])
 	case "gonvim_minimap_update":
@@ -2030,6 +2117,72 @@ func (ws *Workspace) handleGui(updates []interface{}) {
 		editor.workspacePrevious()
 	case "gonvim_workspace_switch":
 		editor.workspaceSwitch(util.ReflectToInt(updates[1]))
+	case "gonvim_workspace_close":
+		editor.workspaceClose(ws.getNum())
+	case "gonvim_workspace_rename":
+		if len(updates) < 2 {
+			return
+		}
+		name, _ := updates[1].(string)
+		editor.workspaceRename(ws.getNum(), name)
+	case "gonvim_workspace_move":
+		if len(updates) < 2 {
+			return
+		}
+		editor.workspaceMove(ws.getNum(), util.ReflectToInt(updates[1]))
+	case "gonvim_workspace_duplicate":
+		editor.workspaceDuplicate(ws.getNum())
+	case "gonvim_workspace_detach":
+		editor.workspaceDetach(ws.getNum(), nil)
+	case "gonvim_workspace_attach_back":
+		editor.workspaceAttachBack(ws.getNum())
+	case "gonvim_modified_buffers":
+		if len(updates) < 2 {
+			return
+		}
+		ws.setModifiedBuffers(updates[1])
+	case "gonvim_git_status":
+		if len(updates) < 3 {
+			return
+		}
+		root, _ := updates[1].(string)
+		var lines []string
+		if items, ok := updates[2].([]interface{}); ok {
+			for _, item := range items {
+				line, _ := item.(string)
+				lines = append(lines, line)
+			}
+		}
+		ws.setGitStatus(root, lines)
+	case "gonvim_find", "gonvim_find_files", "gonvim_find_ranked", "gonvim_find_preview":
+		ws.handleFinder(updates)
+	case "gonvim_grep", "gonvim_grep_results", "gonvim_grep_local", "gonvim_grep_files":
+		ws.handleGrep(updates)
+	case "gonvim_command_palette", "gonvim_command_entries":
+		ws.handleCommandPalette(updates)
+	case "gonvim_ui_select", "gonvim_ui_input":
+		ws.handleUIPrompt(updates)
+	case "gonvim_recent_file":
+		if len(updates) < 2 {
+			return
+		}
+		path, _ := updates[1].(string)
+		editor.recent.add(recentFile, path)
+		ws.startPage.hide()
+	case "gonvim_start_page":
+		ws.openStartPage()
+	case "gonvim_session_save":
+		if len(updates) < 2 {
+			return
+		}
+		name, _ := updates[1].(string)
+		ws.saveNamedSession(name)
+	case "gonvim_session_load":
+		if len(updates) < 2 {
+			return
+		}
+		name, _ := updates[1].(string)
+		ws.loadNamedSession(name)
 	case "gonvim_workspace_filepath":
 		if ws.minimap != nil {
 			ws.minimap.mu.Lock()
@@ -2037,6 +2190,34 @@ func (ws *Workspace) handleGui(updates []interface{}) {
 			ws.minimap.mu.Unlock()
 		}
 
+	case "gonvim_scrollbar_marks":
+		if len(updates) < 4 {
+			return
+		}
+		if ws.scrollBar != nil {
+			ws.scrollBar.setMarks(util.ReflectToInt(updates[1]), updates[2], updates[3])
+		}
+		if ws.minimap != nil {
+			ws.minimap.setMarks(updates[2], updates[3])
+		}
+
+	case "gonvim_horizontal_scroll":
+		if len(updates) < 6 {
+			return
+		}
+		wid := (nvim.Window)(util.ReflectToInt(updates[1]))
+		win, ok := ws.screen.getGrid(wid)
+		if !ok {
+			return
+		}
+		wrap, _ := updates[5].(bool)
+		win.updateHScrollBar(
+			util.ReflectToInt(updates[2]),
+			util.ReflectToInt(updates[3]),
+			util.ReflectToInt(updates[4]),
+			wrap,
+		)
+
 	case "gonvim_optionset":
 		wid := (nvim.Window)(util.ReflectToInt(updates[4]))
 		win, ok := ws.screen.getGrid(wid)
@@ -2159,9 +2340,17 @@ func (ws *Workspace) guiFont(args string) {
 	ws.screen.fallbackfonts = nil
 
 	ws.parseAndApplyFont(args, &ws.screen.font, &ws.screen.fallbackfonts)
+	ws.screen.font, ws.screen.fallbackfonts = scaleFonts(ws.screen.font, ws.screen.fallbackfonts, zoomFactor(ws.zoomLevel))
 	editor.showFontErrors()
+	ws.screen.resetHighlightFonts()
 	ws.screen.purgeTextCacheForWins()
 
+	ws.applyScreenFont()
+}
+
+// applyScreenFont propagates the font of the screen to the workspace
+// and the UI components drawn with it.
+func (ws *Workspace) applyScreenFont() {
 	// When setting up a different font for a workspace other than the neovim drawing screen,
 	// it is necessary to consider handling the fonts on the workspace side independently, etc.
 	ws.font = ws.screen.font
@@ -2217,6 +2406,7 @@ func (ws *Workspace) guiFontWide(args string) {
 	ws.screen.fallbackfontwides = nil
 
 	ws.parseAndApplyFont(args, &ws.screen.fontwide, &ws.screen.fallbackfontwides)
+	ws.screen.fontwide, ws.screen.fallbackfontwides = scaleFonts(ws.screen.fontwide, ws.screen.fallbackfontwides, zoomFactor(ws.zoomLevel))
 	ws.screen.purgeTextCacheForWins()
 
 	ws.updateSize()
@@ -2702,6 +2892,7 @@ endif
 `
 		// autocmd が無ければ定義
 		ws.nvim.Exec(ensureAutocmd, nil)
+		ws.nvim.Command("silent! lua goneovim.notify_indent_guide_colors()")
 
 		// 既存の処理
 		ws.nvim.Command("doautocmd <nomodeline> WinEnter")
@@ -2727,6 +2918,9 @@ type WorkspaceSide struct {
 	fg           *RGBA
 	sfg          *RGBA
 	scrollFg     *RGBA
+	layout       *widgets.QLayout
+	thumbTimer   *core.QTimer
+	git          *GitPanel
 	items        []*WorkspaceSideItem
 	isShown      bool
 	isInitResize bool
@@ -2765,20 +2959,20 @@ func newWorkspaceSide() *WorkspaceSide {
 	side := &WorkspaceSide{
 		widget: widget,
 		header: header,
+		layout: layout,
 	}
 
+	if editor.config.SideBar.GitStatus {
+		side.git = newGitPanel()
+		layout.AddWidget(side.git.widget)
+	}
 	layout.AddWidget(header)
 	side.header.Show()
 
-	items := []*WorkspaceSideItem{}
-	side.items = items
-	for i := 0; i < WORKSPACELEN; i++ {
-		item := newWorkspaceSideItem()
-		side.items = append(side.items, item)
-		side.items[len(side.items)-1].side = side
-		layout.AddWidget(side.items[len(side.items)-1].widget)
-		side.items[len(side.items)-1].hide()
-	}
+	// The items are added as the workspaces increase.
+	side.ensureItems(1)
+	side.ensureItems(len(editor.workspaces))
+	side.startThumbnailTimer()
 
 	return side
 }
@@ -2807,6 +3001,9 @@ func (side *WorkspaceSide) newScrollArea() {
 			item.content.SetMinimumWidth(width)
 			item.content.SetMinimumWidth(width)
 		}
+		if side.git != nil {
+			side.git.content.SetMinimumWidth(width)
+		}
 
 	})
 }
@@ -2840,10 +3037,8 @@ func (side *WorkspaceSide) show() {
 	side.scrollarea.Show()
 	side.isShown = true
 
-	for i := 0; i < WORKSPACELEN; i++ {
-		if i >= len(editor.workspaces) {
-			break
-		}
+	side.ensureItems(len(editor.workspaces))
+	for i := 0; i < len(editor.workspaces); i++ {
 		if side.items[i] == nil {
 			continue
 		}
@@ -2897,11 +3092,16 @@ type WorkspaceSideItem struct {
 	widget        *widgets.QWidget
 	layout        *widgets.QBoxLayout
 	labelWidget   *widgets.QWidget
+	badges        *widgets.QLabel
+	thumbnail     *widgets.QLabel
 	text          string
 	cwdpath       string
+	badgeText     string
 	hidden        bool
 	active        bool
 	isContentHide bool
+	hasThumbnail  bool
+	isDragging    bool
 }
 
 func newWorkspaceSideItem() *WorkspaceSideItem {
@@ -2937,12 +3137,7 @@ func newWorkspaceSideItem() *WorkspaceSideItem {
 	svgContent = editor.getSvg("chevron-right", nil)
 	closeIcon.Load2(core.NewQByteArray2(svgContent, len(svgContent)))
 
-	content := widgets.NewQListWidget(nil)
-	content.SetFocusPolicy(core.Qt__NoFocus)
-	content.SetFrameShape(widgets.QFrame__NoFrame)
-	content.SetHorizontalScrollBarPolicy(core.Qt__ScrollBarAlwaysOff)
-	content.SetFont(editor.font.qfont)
-	content.SetIconSize(core.NewQSize2(editor.iconSize*3/4, editor.iconSize*3/4))
+	content := newSideItemContent()
 
 	labelLayout.AddWidget(openIcon, 0, 0)
 	labelLayout.AddWidget(closeIcon, 0, 0)
@@ -2972,50 +3167,24 @@ func newWorkspaceSideItem() *WorkspaceSideItem {
 		isContentHide: true,
 	}
 
+	sideitem.initStatus()
+
 	sideitem.widget.ConnectMousePressEvent(sideitem.toggleContent)
-	content.ConnectItemDoubleClicked(sideitem.fileDoubleClicked)
+	sideitem.widget.ConnectContextMenuEvent(sideitem.contextMenu)
+	sideitem.widget.ConnectMouseMoveEvent(sideitem.dragMove)
+	sideitem.widget.ConnectMouseReleaseEvent(sideitem.dragRelease)
 
 	return sideitem
 }
 
-func (i *WorkspaceSideItem) fileDoubleClicked(item *widgets.QListWidgetItem) {
-	filename := item.Text()
-	path := i.cwdpath
-	sep := ""
-	if runtime.GOOS == "windows" {
-		sep = `\`
-	} else {
-		sep = `/`
-	}
-	filepath := path + sep + filename
-
-	// exec := ""
-	// switch runtime.GOOS {
-	// case "darwin":
-	// 	exec = ":silent !open "
-	// case "windows":
-	// 	exec = ":silent !explorer "
-	// case "linux":
-	// 	exec = ":silent !xdg-open "
-	// }
-	exec := editor.config.Editor.FileOpenCmd + " "
-
-	execCommand := exec + filepath
-	for j, ws := range editor.workspaces {
-		if editor.side.items[j] == nil {
-			continue
-		}
-		sideItem := editor.side.items[j]
-		if i == sideItem {
-			go ws.nvim.Command(execCommand)
-		}
-	}
-}
-
 func (i *WorkspaceSideItem) toggleContent(event *gui.QMouseEvent) {
 	if i.hidden {
 		return
 	}
+	// The right button opens the context menu.
+	if event.Button() != core.Qt__LeftButton {
+		return
+	}
 	if i.isContentHide {
 		for j, ws := range editor.workspaces {
 			if editor.side.items[j] == nil {
@@ -3076,10 +3245,28 @@ func (i *WorkspaceSideItem) clear() {
 	i.content.Clear()
 }
 
-func (i *WorkspaceSideItem) addItem(args []interface{}) {
-	filename := args[0].(string)
-	filetype := args[1].(string)
+func (i *WorkspaceSideItem) addItem(args []interface{}, git *gitStatus) {
+	if len(args) < 5 {
+		return
+	}
+	filename, _ := args[0].(string)
+	filetype, _ := args[1].(string)
+	depth := util.ReflectToInt(args[2])
+	path, _ := args[4].(string)
 	l := widgets.NewQListWidgetItem(i.content, 1)
+	l.SetIcon(filerIcon(filetype))
+	text := filerItemText(filename, filetype, depth)
+	if mark := git.decoration(path, filetype == "/"); mark != "" {
+		text += "  " + mark
+	}
+	l.SetText(text)
+	l.SetData(filerPathRole, core.NewQVariant15(path))
+	l.SetData(filerFiletypeRole, core.NewQVariant15(filetype))
+	i.content.AddItem2(l)
+}
+
+// filerIcon returns the icon of the entry in the file tree.
+func filerIcon(filetype string) *gui.QIcon {
 	var svg string
 	if filetype == `/` {
 		svg = editor.getSvg("directory", nil)
@@ -3088,11 +3275,8 @@ func (i *WorkspaceSideItem) addItem(args []interface{}) {
 	}
 	pixmap := gui.NewQPixmap()
 	pixmap.LoadFromData2(core.NewQByteArray2(svg, len(svg)), "SVG", core.Qt__ColorOnly)
-	icon := gui.NewQIcon2(pixmap)
 
-	l.SetIcon(icon)
-	l.SetText(filename)
-	i.content.AddItem2(l)
+	return gui.NewQIcon2(pixmap)
 }
 
 func (i *WorkspaceSideItem) resizeContent() {
@@ -3131,6 +3315,7 @@ func (side *WorkspaceSide) setColor() {
 	hover := side.accent.String()
 
 	side.header.SetStyleSheet(fmt.Sprintf(" .QLabel{ color: %s;} ", side.sfg.String()))
+	side.git.setColor()
 	side.widget.SetStyleSheet(
 		fmt.Sprintf(`
 		.QWidget { border: 0px solid #000; padding-top: 5px; background-color: rgba(0, 0, 0, 0); }
@@ -3200,27 +3385,7 @@ func (side *WorkspaceSide) setColorForItems() {
 			if l == nil {
 				break
 			}
-			filename := l.Text()
-			parts := strings.SplitN(filename, ".", -1)
-			filetype := ""
-			if len(parts) > 1 {
-				filetype = parts[len(parts)-1]
-			}
-			// If it is directory
-			if filename[len(filename)-1] == '/' {
-				filetype = string("/")
-			}
-			var svg string
-			if filetype == `/` {
-				svg = editor.getSvg("directory", nil)
-			} else {
-				svg = editor.getSvg(filetype, nil)
-			}
-			pixmap := gui.NewQPixmap()
-			pixmap.LoadFromData2(core.NewQByteArray2(svg, len(svg)), "SVG", core.Qt__ColorOnly)
-			icon := gui.NewQIcon2(pixmap)
-
-			l.SetIcon(icon)
+			l.SetIcon(filerIcon(l.Data(filerFiletypeRole).ToString()))
 		}
 	}
 }
@@ -3287,6 +3452,7 @@ func (i *WorkspaceSideItem) show() {
 		i.openIcon.Hide()
 		i.closeIcon.Show()
 	}
+	i.showStatus()
 }
 
 func (i *WorkspaceSideItem) hide() {
@@ -3299,4 +3465,5 @@ func (i *WorkspaceSideItem) hide() {
 	i.closeIcon.Hide()
 
 	i.content.Hide()
+	i.showStatus()
 }
@@ -0,0 +1,345 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+	"github.com/akiyosi/qt/widgets"
+)
+
+// sessionFiles returns the session files of the workspaces saved in dir,
+// which are named after the number of the workspace, in the order of it.
+func sessionFiles(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var nums []int
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		num, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), ".vim"))
+		if err != nil || !strings.HasSuffix(entry.Name(), ".vim") {
+			continue
+		}
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+
+	files := []string{}
+	for _, num := range nums {
+		files = append(files, filepath.Join(dir, strconv.Itoa(num)+".vim"))
+	}
+
+	return files
+}
+
+// swappedIndex returns where the index is after the workspaces
+// at a and b are swapped.
+func swappedIndex(index, a, b int) int {
+	switch index {
+	case a:
+		return b
+	case b:
+		return a
+	}
+
+	return index
+}
+
+// sideLabel returns the label of the workspace in the sidebar,
+// which is the name if it is renamed, otherwise the cwd.
+func (ws *Workspace) sideLabel() string {
+	if ws.name != "" {
+		return ws.name
+	}
+
+	return ws.cwdlabel
+}
+
+// workspaceClose quits the nvim of the workspace at index. The workspace is
+// made active first, since nvim asks to save the modified buffers in it.
+// The workspace is removed when the nvim exits.
+func (e *Editor) workspaceClose(index int) {
+	if index < 0 || index >= len(e.workspaces) {
+		return
+	}
+	ws := e.workspaces[index]
+	if ws.nvim == nil {
+		return
+	}
+	if e.active != index {
+		e.active = index
+		e.workspaceUpdate()
+	}
+
+	go ws.nvim.Command("confirm qa")
+}
+
+// workspaceRename names the workspace at index.
+// The empty name restores the label of the cwd.
+func (e *Editor) workspaceRename(index int, name string) {
+	if index < 0 || index >= len(e.workspaces) {
+		return
+	}
+	ws := e.workspaces[index]
+	ws.name = strings.TrimSpace(name)
+
+	if e.side == nil || index >= len(e.side.items) {
+		return
+	}
+	e.side.items[index].label.SetText(ws.sideLabel())
+}
+
+// workspaceRenamePrompt asks the name of the workspace at index
+// with input() in the nvim of it.
+func (e *Editor) workspaceRenamePrompt(index int) {
+	if index < 0 || index >= len(e.workspaces) {
+		return
+	}
+	ws := e.workspaces[index]
+	if ws.nvim == nil {
+		return
+	}
+	if e.active != index {
+		e.active = index
+		e.workspaceUpdate()
+	}
+
+	go ws.nvim.Command(
+		fmt.Sprintf(
+			`call rpcnotify(g:goneovim_channel_id, "Gui", "gonvim_workspace_rename", input("Workspace name: ", %q))`,
+			ws.name,
+		),
+	)
+}
+
+// workspaceMove swaps the workspace at index for the one at index+delta.
+func (e *Editor) workspaceMove(index, delta int) {
+	to := index + delta
+	if index < 0 || index >= len(e.workspaces) {
+		return
+	}
+	if to < 0 || to >= len(e.workspaces) || to == index {
+		return
+	}
+
+	e.workspaces[index], e.workspaces[to] = e.workspaces[to], e.workspaces[index]
+	if e.side != nil && index < len(e.side.items) && to < len(e.side.items) {
+		e.side.items[index].swap(e.side.items[to])
+	}
+	e.active = swappedIndex(e.active, index, to)
+	e.workspaceUpdate()
+}
+
+// workspaceDuplicate starts a new workspace from the session
+// of the workspace at index, which has the same cwd. The session is saved
+// in a local temporary file, so the remote nvim cannot be duplicated.
+func (e *Editor) workspaceDuplicate(index int) {
+	if index < 0 || index >= len(e.workspaces) {
+		return
+	}
+	ws := e.workspaces[index]
+	if ws.nvim == nil {
+		return
+	}
+	if ws.uiRemoteAttached || e.opts.Server != "" || e.opts.Ssh != "" || e.opts.Wsl != nil || e.config.Editor.UseWSL {
+		go ws.nvim.WritelnErr("goneovim: the workspace of the remote nvim cannot be duplicated")
+		return
+	}
+
+	file, err := os.CreateTemp("", "goneovim-workspace-*.vim")
+	if err != nil {
+		e.putLog("failed to create the session file to duplicate the workspace:", err)
+		return
+	}
+	file.Close()
+	path := file.Name()
+	escaped := strings.ReplaceAll(path, " ", `\ `)
+	if err := ws.nvim.Command("mksession! " + escaped); err != nil {
+		e.putLog("failed to save the session to duplicate the workspace:", err)
+		os.Remove(path)
+		return
+	}
+
+	e.workspaceAddWithSession("")
+	dup := e.workspaces[e.active]
+	go func() {
+		dup.nvim.Command("so " + escaped)
+		os.Remove(path)
+	}()
+}
+
+// sessionIndex returns the number of the workspace which the session file
+// is saved for, or index if the workspace is started without a session.
+// The names and the zoom levels of the workspaces are saved by the number,
+// since the workspaces of the remote nvim have no session and the restored
+// workspaces are numbered without them.
+func sessionIndex(file string, index int) int {
+	num, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(file), ".vim"))
+	if file == "" || err != nil {
+		return index
+	}
+
+	return num
+}
+
+func workspaceNameSettingsKey(index int) string {
+	return fmt.Sprintf("name/%d", index)
+}
+
+// loadWorkspaceName returns the name of the workspace
+// at index saved at the last exit.
+func (e *Editor) loadWorkspaceName(index int) string {
+	settings := core.NewQSettings("neovim", "goneovim", nil)
+
+	return settings.Value(workspaceNameSettingsKey(index), core.NewQVariant1("")).ToString()
+}
+
+// saveWorkspaceNames saves the names by the index of the workspaces,
+// which is the number of the session files of them.
+func (e *Editor) saveWorkspaceNames() {
+	settings := core.NewQSettings("neovim", "goneovim", nil)
+	settings.Remove("name")
+	for i, ws := range e.workspaces {
+		if ws.name == "" {
+			continue
+		}
+		settings.SetValue(workspaceNameSettingsKey(i), core.NewQVariant1(ws.name))
+	}
+}
+
+// ensureItems adds the items to the sidebar until there are n items.
+func (side *WorkspaceSide) ensureItems(n int) {
+	if side == nil {
+		return
+	}
+	for len(side.items) < n {
+		item := newWorkspaceSideItem()
+		item.side = side
+		if side.scrollarea != nil {
+			width := side.scrollarea.Width()
+			item.label.SetMaximumWidth(width)
+			item.label.SetMinimumWidth(width)
+			item.content.SetMinimumWidth(width)
+		}
+		side.items = append(side.items, item)
+		side.layout.AddWidget(item.widget)
+		item.hide()
+	}
+}
+
+// removeItem shifts the items after index to fill the item of the removed
+// workspace, and clears the item at last, which was the last workspace.
+func (side *WorkspaceSide) removeItem(index, last int) {
+	if side == nil || index >= len(side.items) {
+		return
+	}
+	removed := side.items[index].content
+	for i := index; i < last && i+1 < len(side.items); i++ {
+		side.items[i].copy(side.items[i+1])
+	}
+	if last < len(side.items) {
+		item := side.items[last]
+		item.label.SetText("")
+		item.active = false
+		item.text = ""
+		item.cwdpath = ""
+		item.isContentHide = true
+
+		content := newSideItemContent()
+		item.content = content
+		item.widget.Layout().AddWidget(content)
+		item.hide()
+	}
+	removed.DeleteLater()
+}
+
+// index returns the number of the workspace of the item.
+func (i *WorkspaceSideItem) index() int {
+	for j, item := range i.side.items {
+		if item == i && j < len(editor.workspaces) {
+			return j
+		}
+	}
+
+	return -1
+}
+
+// swap exchanges the contents of the items of the reordered workspaces.
+// The items stay in place, since the layout of the sidebar cannot reorder them.
+func (i *WorkspaceSideItem) swap(ii *WorkspaceSideItem) {
+	text := i.label.Text()
+	i.label.SetText(ii.label.Text())
+	ii.label.SetText(text)
+
+	i.text, ii.text = ii.text, i.text
+	i.cwdpath, ii.cwdpath = ii.cwdpath, i.cwdpath
+	i.isContentHide, ii.isContentHide = ii.isContentHide, i.isContentHide
+	i.content, ii.content = ii.content, i.content
+
+	for _, item := range []*WorkspaceSideItem{i, ii} {
+		item.widget.Layout().AddWidget(item.content)
+		if item.isContentHide {
+			item.closeContent()
+		} else {
+			item.openContent()
+		}
+	}
+}
+
+func (i *WorkspaceSideItem) contextMenu(event *gui.QContextMenuEvent) {
+	index := i.index()
+	if index < 0 {
+		return
+	}
+
+	menu := widgets.NewQMenu(nil)
+	menu.SetAttribute(core.Qt__WA_DeleteOnClose, true)
+
+	menu.AddAction("Rename...").ConnectTriggered(func(checked bool) {
+		editor.workspaceRenamePrompt(index)
+	})
+
+	moveUp := menu.AddAction("Move Up")
+	moveUp.SetEnabled(index > 0)
+	moveUp.ConnectTriggered(func(checked bool) {
+		editor.workspaceMove(index, -1)
+	})
+
+	moveDown := menu.AddAction("Move Down")
+	moveDown.SetEnabled(index < len(editor.workspaces)-1)
+	moveDown.ConnectTriggered(func(checked bool) {
+		editor.workspaceMove(index, 1)
+	})
+
+	menu.AddAction("Duplicate").ConnectTriggered(func(checked bool) {
+		editor.workspaceDuplicate(index)
+	})
+
+	if editor.workspaces[index].detached != nil {
+		menu.AddAction("Attach Back").ConnectTriggered(func(checked bool) {
+			editor.workspaceAttachBack(index)
+		})
+	} else {
+		menu.AddAction("Detach").ConnectTriggered(func(checked bool) {
+			editor.workspaceDetach(index, nil)
+		})
+	}
+
+	menu.AddSeparator()
+
+	menu.AddAction("Close").ConnectTriggered(func(checked bool) {
+		editor.workspaceClose(index)
+	})
+
+	menu.Popup(event.GlobalPos(), nil)
+}
@@ -0,0 +1,88 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSessionFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"10.vim", "2.vim", "0.vim", "foo.vim", "3.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "1.vim"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		dir  string
+		want []string
+	}{
+		{
+			"sessionFiles() sorts the sessions by the number of the workspace",
+			dir,
+			[]string{
+				filepath.Join(dir, "0.vim"),
+				filepath.Join(dir, "2.vim"),
+				filepath.Join(dir, "10.vim"),
+			},
+		},
+		{
+			"sessionFiles() returns nothing for the missing directory",
+			filepath.Join(dir, "missing"),
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sessionFiles(tt.dir); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sessionFiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSwappedIndex(t *testing.T) {
+	tests := []struct {
+		name  string
+		index int
+		a, b  int
+		want  int
+	}{
+		{"swappedIndex() follows the moved workspace", 1, 1, 2, 2},
+		{"swappedIndex() follows the other workspace", 2, 1, 2, 1},
+		{"swappedIndex() keeps the unrelated workspace", 0, 1, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := swappedIndex(tt.index, tt.a, tt.b); got != tt.want {
+				t.Errorf("swappedIndex() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionIndex(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		index int
+		want  int
+	}{
+		{"sessionIndex() returns the number of the session file", filepath.Join("sessions", "3.vim"), 1, 3},
+		{"sessionIndex() returns the index without a session", "", 1, 1},
+		{"sessionIndex() returns the index for the other session", "Session.vim", 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sessionIndex(tt.file, tt.index); got != tt.want {
+				t.Errorf("sessionIndex() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package editor
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+
+	"github.com/akiyosi/qt/core"
+	"github.com/akiyosi/qt/gui"
+)
+
+const (
+	zoomStep     = 1.1
+	zoomLevelMin = -8
+	zoomLevelMax = 12
+)
+
+// zoomFactor returns the scale applied to the font sizes at the zoom level.
+func zoomFactor(level int) float64 {
+	return math.Pow(zoomStep, float64(level))
+}
+
+func clampZoomLevel(level int) int {
+	if level < zoomLevelMin {
+		return zoomLevelMin
+	}
+	if level > zoomLevelMax {
+		return zoomLevelMax
+	}
+
+	return level
+}
+
+// zoomModifier is the modifier of the default zoom shortcuts,
+// Ctrl on Windows and Linux, Cmd on macOS.
+func zoomModifier() core.Qt__KeyboardModifier {
+	if runtime.GOOS == "darwin" {
+		return cmdModifier()
+	}
+
+	return controlModifier()
+}
+
+// isZoomKeyEvent reports the zoom level delta of the key event,
+// 0 for zoom reset, and whether the event is a zoom shortcut at all.
+func isZoomKeyEvent(key int, mod core.Qt__KeyboardModifier) (delta int, ok bool) {
+	if mod & ^core.Qt__ShiftModifier & ^core.Qt__KeypadModifier != zoomModifier() {
+		return 0, false
+	}
+
+	switch core.Qt__Key(key) {
+	case core.Qt__Key_Equal, core.Qt__Key_Plus:
+		return 1, true
+	case core.Qt__Key_Minus:
+		return -1, true
+	case core.Qt__Key_0:
+		return 0, true
+	}
+
+	return 0, false
+}
+
+func (ws *Workspace) zoomIn() {
+	ws.setZoomLevel(ws.zoomLevel + 1)
+}
+
+func (ws *Workspace) zoomOut() {
+	ws.setZoomLevel(ws.zoomLevel - 1)
+}
+
+func (ws *Workspace) zoomReset() {
+	ws.setZoomLevel(0)
+}
+
+// setZoomLevel scales the fonts of the workspace, including the fonts set
+// with GonvimGridFont, while keeping the size of the application window.
+// The number of cols and rows is recomputed from the new font metrics.
+func (ws *Workspace) setZoomLevel(level int) {
+	level = clampZoomLevel(level)
+	if level == ws.zoomLevel || ws.screen == nil || ws.screen.font == nil {
+		return
+	}
+	ratio := zoomFactor(level) / zoomFactor(ws.zoomLevel)
+	ws.zoomLevel = level
+
+	ws.screen.font, ws.screen.fallbackfonts = scaleFonts(ws.screen.font, ws.screen.fallbackfonts, ratio)
+	ws.screen.fontwide, ws.screen.fallbackfontwides = scaleFonts(ws.screen.fontwide, ws.screen.fallbackfontwides, ratio)
+
+	ws.screen.windows.Range(func(_, winITF interface{}) bool {
+		win := winITF.(*Window)
+		if win == nil || win.font == nil {
+			return true
+		}
+		win.zoomGridFont(ratio)
+		return true
+	})
+
+	ws.screen.resetHighlightFonts()
+	ws.screen.purgeTextCacheForWins()
+	ws.applyScreenFont()
+}
+
+// zoomGridFont scales the font set with GonvimGridFont and resizes the grid
+// so that it keeps its size in pixels.
+func (w *Window) zoomGridFont(ratio float64) {
+	oldWidth := float64(w.cols) * w.font.cellwidth
+	oldHeight := w.rows * w.font.lineHeight
+	w.width = oldWidth
+	w.height = oldHeight
+	w.localWindows = &[4]localWindow{}
+
+	w.font, w.fallbackfonts = scaleFonts(w.font, w.fallbackfonts, ratio)
+	w.resizeToGridFont(oldWidth, oldHeight)
+}
+
+// scaleFonts returns copies of the fonts scaled by ratio. The fonts are copied
+// rather than changed in place, since the initial font of a workspace is shared
+// with the application and the other workspaces.
+func scaleFonts(font *Font, fallbackfonts []*Font, ratio float64) (*Font, []*Font) {
+	if font == nil || ratio == 1.0 {
+		return font, fallbackfonts
+	}
+
+	scaled := make([]*Font, len(fallbackfonts))
+	for i, f := range fallbackfonts {
+		scaled[i] = scaleFont(f, ratio)
+	}
+
+	return scaleFont(font, ratio), scaled
+}
+
+func scaleFont(f *Font, ratio float64) *Font {
+	font := initFontNew(
+		f.family,
+		math.Max(f.size*ratio, 1.0),
+		f.weight,
+		f.stretch,
+		f.lineSpace,
+		f.letterSpace,
+	)
+	font.ws = f.ws
+
+	return font
+}
+
+// wheelZoom handles Ctrl+wheel (Cmd+wheel on macOS) and reports whether
+// the wheel event was consumed for zooming.
+func (ws *Workspace) wheelZoom(event *gui.QWheelEvent) bool {
+	if editor.config.Editor.DisableZoomShortcuts {
+		return false
+	}
+	if event.Modifiers()&zoomModifier() == 0 {
+		return false
+	}
+
+	// Touchpads report the angle in small fractions of a notch,
+	// so zoom by one level per accumulated notch.
+	ws.zoomWheelDelta += event.AngleDelta().Y()
+	for ws.zoomWheelDelta >= 120 {
+		ws.zoomWheelDelta -= 120
+		ws.zoomIn()
+	}
+	for ws.zoomWheelDelta <= -120 {
+		ws.zoomWheelDelta += 120
+		ws.zoomOut()
+	}
+
+	return true
+}
+
+func zoomSettingsKey(index int) string {
+	return fmt.Sprintf("zoom/%d", index)
+}
+
+// loadWorkspaceZoom returns the zoom level of the workspace
+// at index saved at the last exit.
+func (e *Editor) loadWorkspaceZoom(index int) int {
+	settings := core.NewQSettings("neovim", "goneovim", nil)
+	var ok bool
+	level := settings.Value(zoomSettingsKey(index), core.NewQVariant10(0)).ToDouble(&ok)
+	if !ok {
+		return 0
+	}
+
+	return clampZoomLevel(int(level))
+}
+
+// saveWorkspaceZoom saves the zoom levels by the index of the workspaces,
+// which is the number of the session files of them.
+func (e *Editor) saveWorkspaceZoom() {
+	settings := core.NewQSettings("neovim", "goneovim", nil)
+	settings.Remove("zoom")
+	for i, ws := range e.workspaces {
+		if ws.zoomLevel == 0 {
+			continue
+		}
+		settings.SetValue(zoomSettingsKey(i), core.NewQVariant10(float64(ws.zoomLevel)))
+	}
+}
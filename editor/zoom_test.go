@@ -0,0 +1,103 @@
+package editor
+
+import (
+	"math"
+	"testing"
+
+	"github.com/akiyosi/qt/core"
+)
+
+func TestZoomFactor(t *testing.T) {
+	tests := []struct {
+		name  string
+		level int
+		want  float64
+	}{
+		{"zoomFactor() keeps the size at level 0", 0, 1.0},
+		{"zoomFactor() enlarges by a step", 1, zoomStep},
+		{"zoomFactor() enlarges by two steps", 2, zoomStep * zoomStep},
+		{"zoomFactor() shrinks by a step", -1, 1.0 / zoomStep},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := zoomFactor(tt.level); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClampZoomLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		level int
+		want  int
+	}{
+		{"clampZoomLevel() keeps the level within the range", 3, 3},
+		{"clampZoomLevel() keeps the minimum", zoomLevelMin, zoomLevelMin},
+		{"clampZoomLevel() clamps below the minimum", zoomLevelMin - 1, zoomLevelMin},
+		{"clampZoomLevel() clamps above the maximum", zoomLevelMax + 5, zoomLevelMax},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clampZoomLevel(tt.level); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsZoomKeyEvent(t *testing.T) {
+	mod := zoomModifier()
+	tests := []struct {
+		name      string
+		key       core.Qt__Key
+		mod       core.Qt__KeyboardModifier
+		wantDelta int
+		wantOk    bool
+	}{
+		{"isZoomKeyEvent() zooms in with =", core.Qt__Key_Equal, mod, 1, true},
+		{"isZoomKeyEvent() zooms in with + typed with Shift", core.Qt__Key_Plus, mod | core.Qt__ShiftModifier, 1, true},
+		{"isZoomKeyEvent() zooms in with + of the keypad", core.Qt__Key_Plus, mod | core.Qt__KeypadModifier, 1, true},
+		{"isZoomKeyEvent() zooms out with -", core.Qt__Key_Minus, mod, -1, true},
+		{"isZoomKeyEvent() resets with 0", core.Qt__Key_0, mod, 0, true},
+		{"isZoomKeyEvent() skips the key without the modifier", core.Qt__Key_Equal, core.Qt__NoModifier, 0, false},
+		{"isZoomKeyEvent() skips the key with another modifier", core.Qt__Key_Equal, mod | core.Qt__AltModifier, 0, false},
+		{"isZoomKeyEvent() skips the other key", core.Qt__Key_A, mod, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta, ok := isZoomKeyEvent(int(tt.key), tt.mod)
+			if delta != tt.wantDelta || ok != tt.wantOk {
+				t.Errorf("got %v, %v, want %v, %v", delta, ok, tt.wantDelta, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestScaleFonts(t *testing.T) {
+	font := &Font{family: "Monospace", size: 12}
+	fallbackfonts := []*Font{{family: "Symbols", size: 12}}
+	tests := []struct {
+		name          string
+		font          *Font
+		fallbackfonts []*Font
+		ratio         float64
+	}{
+		{"scaleFonts() returns no font as it is", nil, nil, 1.5},
+		{"scaleFonts() returns the fonts as they are at the ratio 1.0", font, fallbackfonts, 1.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotFallbacks := scaleFonts(tt.font, tt.fallbackfonts, tt.ratio)
+			if got != tt.font || len(gotFallbacks) != len(tt.fallbackfonts) {
+				t.Errorf("got %v, %v, want %v, %v", got, gotFallbacks, tt.font, tt.fallbackfonts)
+			}
+			for i := range gotFallbacks {
+				if gotFallbacks[i] != tt.fallbackfonts[i] {
+					t.Errorf("got %v, want %v", gotFallbacks[i], tt.fallbackfonts[i])
+				}
+			}
+		})
+	}
+}
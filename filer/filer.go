@@ -3,58 +3,59 @@ package filer
 import (
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/akiyosi/goneovim/util"
 	"github.com/akiyosi/qt/widgets"
 	"github.com/neovim/go-client/nvim"
 )
 
+// Options is the settings of the filer.
+type Options struct {
+	OpenCmd     string
+	ShowHidden  bool
+	ShowIgnored bool
+	AutoRefresh bool
+}
+
 type Filer struct {
-	Widget    *widgets.QListWidget
-	nvim      *nvim.Nvim
-	selectnum int
-	items     [](map[string]string)
-	openCmd   string
+	Widget      *widgets.QListWidget
+	nvim        *nvim.Nvim
+	mu          sync.Mutex
+	selectnum   int
+	items       []*Entry
+	openCmd     string
+	root        string
+	expanded    map[string]bool
+	showHidden  bool
+	showIgnored bool
+	autoRefresh bool
+	clipboard   string
+	isCut       bool
+	history     []operation
 }
 
 // RegisterPlugin registers this remote plugin
-func RegisterPlugin(nvim *nvim.Nvim, openCmd string) {
+func RegisterPlugin(nvim *nvim.Nvim, opts Options) {
 	nvim.Subscribe("GonvimFiler")
 
 	f := &Filer{
-		nvim:    nvim,
-		openCmd: openCmd,
+		nvim:        nvim,
+		openCmd:     opts.OpenCmd,
+		expanded:    make(map[string]bool),
+		showHidden:  opts.ShowHidden,
+		showIgnored: opts.ShowIgnored,
+		autoRefresh: opts.AutoRefresh,
 	}
 	nvim.RegisterHandler("GonvimFiler", func(args ...interface{}) {
 		go f.handle(args...)
 	})
+	if err := nvim.ExecLua(luaScript, nil); err != nil {
+		return
+	}
+	f.setShowIgnored(f.showIgnored)
 	finderFunction := `
-	command! GonvimFilerOpen call Gonvim_filer_run()
-	function! Gonvim_filer_run() abort
-	    call rpcnotify(0, "GonvimFiler", "open")
-	    let l:keymaps = { "\<Esc>": "cancel", "\<C-c>": "cancel", "\<Enter>": "right", "h": "left", "j": "down", "k": "up", "l": "right", "/": "search", }
-	
-	    while v:true
-	        let l:input = getchar()
-	        let l:char = nr2char(l:input)
-	    
-	        let event = get(l:keymaps, l:char, "noevent")
-	        if (l:input is# "\<BS>")
-	            call rpcnotify(0, "GonvimFiler", "up")
-	        elseif (l:input is# "\<DEL>")
-	            call rpcnotify(0, "GonvimFiler", "up")
-	        elseif (event == "noevent")
-	            call rpcnotify(0, "GonvimFiler", "char", l:char)
-	        else
-	            call rpcnotify(0, "GonvimFiler", event)
-	        endif
-	    
-	        if (event == "cancel")
-	            call rpcnotify(0, "GonvimFiler", event)
-	            return
-	        endif
-	    endwhile
-	endfunction
+	command! GonvimFilerOpen call rpcnotify(0, "GonvimFiler", "open")
 	`
 
 	registerFunction := fmt.Sprintf(
@@ -72,90 +73,122 @@ func (f *Filer) handle(args ...interface{}) {
 	if !ok {
 		return
 	}
+	// The entry of the row which the event is for.
+	row := -1
+	if len(args) > 1 {
+		row = util.ReflectToInt(args[1])
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if row >= 0 && row < len(f.items) {
+		f.selectnum = row
+	}
+
 	switch event {
 	case "cancel":
 		f.cancel()
 	case "open":
 		f.open()
-	case "redraw":
+	case "redraw", "changed":
+		f.redraw()
+	case "activate":
+		f.activate(row)
+	case "expand":
+		f.expand(row)
+	case "collapse":
+		f.collapse(row)
+	case "parent":
+		f.parent()
+	case "create":
+		f.create(row, false)
+	case "mkdir":
+		f.create(row, true)
+	case "rename":
+		f.rename(row)
+	case "delete":
+		f.delete(row)
+	case "copy":
+		f.yank(row, false)
+	case "cut":
+		f.yank(row, true)
+	case "paste":
+		f.paste(row)
+	case "undo":
+		f.undo()
+	case "toggle_hidden":
+		f.showHidden = !f.showHidden
+		f.redraw()
+	case "toggle_ignored":
+		f.setShowIgnored(!f.showIgnored)
 		f.redraw()
-	case "left":
-		f.left()
-	case "right":
-		f.right()
-	case "up":
-		f.up()
-	case "down":
-		f.down()
-	case "search":
-		f.search()
 	default:
 		fmt.Println("unhandleld filer event", event)
 	}
 }
 
+// setShowIgnored sets whether the ignored files are shown, and runs the
+// User GonvimFilerIgnored autocmds, so that the git status for the marks
+// of the ignored files is updated.
+func (f *Filer) setShowIgnored(show bool) {
+	f.showIgnored = show
+	f.nvim.ExecLua(`
+		goneovim_filer.show_ignored = ...
+		vim.api.nvim_exec_autocmds('User', { pattern = 'GonvimFilerIgnored', modeline = false })
+	`, nil, show)
+}
+
 func (f *Filer) open() {
 	f.nvim.Call("rpcnotify", nil, 0, "Gui", "side_open")
 	f.nvim.Call("rpcnotify", nil, 0, "Gui", "filer_open")
 	f.redraw()
+	f.nvim.Call("rpcnotify", nil, 0, "Gui", "filer_focus")
 }
 
 func (f *Filer) redraw() {
-	f.nvim.Call("rpcnotify", nil, 0, "Gui", "filer_clear")
-	var files string
-	var err error
-	err = f.nvim.ExecLua(`
-		local uv = vim and vim.loop or require 'luv'
-		local path = vim.fn.expand(vim.fn.getcwd())
-		local h = uv.fs_scandir(path)
-		local result = ""
-		while true do
-		    local name, type = uv.fs_scandir_next(h)
-		    if not name then
-		        break
-		    end
-		    if type == "directory" then
-		        result = result .. "\n" .. name .. "/"
-		    else
-		        result = result .. "\n" .. name
-		    end
-		end
-		return result
-	`, &files)
+	var root string
+	if err := f.nvim.Call("getcwd", &root); err != nil {
+		return
+	}
+	selected := ""
+	if f.selectnum >= 0 && f.selectnum < len(f.items) {
+		selected = f.items[f.selectnum].Path
+	}
+	if root != f.root {
+		f.root = root
+		f.expanded = make(map[string]bool)
+		selected = ""
+	}
 
+	var items []*Entry
+	err := f.nvim.ExecLua(
+		`return goneovim_filer.scan(...)`,
+		&items,
+		root,
+		f.expanded,
+		map[string]bool{
+			"hidden":  f.showHidden,
+			"ignored": f.showIgnored,
+			"watch":   f.autoRefresh,
+		},
+	)
 	if err != nil {
 		return
 	}
+	f.items = items
+	if i := indexOfPath(items, selected); i >= 0 {
+		f.selectnum = i
+	}
 
-	var items []map[string]string
-	for _, file := range strings.Split(files, "\n") {
-		if file == "" {
-			continue
-		}
-		// Skip './' and '../'
-		if file == "./" || file == "../" {
-			continue
-		}
-
-		// Remove current dir string
-		parts := strings.SplitN(file, ".", -1)
-		filetype := ""
-		if len(parts) > 1 {
-			filetype = parts[len(parts)-1]
-		}
-
-		// If it is directory
-		if file[len(file)-1] == '/' {
-			filetype = string("/")
-		}
-
-		f.nvim.Call("rpcnotify", nil, 0, "Gui", "filer_item_add", file, filetype)
-		item := make(map[string]string)
-		item["filename"] = file
-		item["filetype"] = filetype
-		items = append(items, item)
+	f.nvim.Call("rpcnotify", nil, 0, "Gui", "filer_clear")
+	for _, item := range items {
+		f.nvim.Call(
+			"rpcnotify", nil, 0, "Gui", "filer_item_add",
+			item.Name, filetypeOf(item.Name, item.IsDir),
+			item.Depth, item.Expanded, item.Path,
+		)
 	}
-	f.items = items
 	f.nvim.Call("rpcnotify", nil, 0, "Gui", "filer_resize")
 	if f.selectnum >= len(f.items) {
 		f.selectnum = len(f.items) - 1
@@ -163,64 +196,233 @@ func (f *Filer) redraw() {
 	f.nvim.Call("rpcnotify", nil, 0, "Gui", "filer_item_select", f.selectnum)
 }
 
-func (f *Filer) up() {
-	f.selectnum--
-	if f.selectnum < 0 {
-		f.selectnum = 0
+// selectPath shows the entry of path expanding the directories
+// which contain it, and selects it.
+func (f *Filer) selectPath(path string) {
+	for _, dir := range ancestorsUnder(f.root, path) {
+		f.expanded[dir] = true
+	}
+	f.redraw()
+	if i := indexOfPath(f.items, path); i >= 0 {
+		f.selectnum = i
+		f.nvim.Call("rpcnotify", nil, 0, "Gui", "filer_item_select", f.selectnum)
 	}
-	f.nvim.Call("rpcnotify", nil, 0, "Gui", "filer_item_select", f.selectnum)
 }
 
-func (f *Filer) down() {
-	f.selectnum++
-	if f.selectnum >= len(f.items) {
-		f.selectnum = len(f.items) - 1
+func (f *Filer) item(row int) *Entry {
+	if row < 0 || row >= len(f.items) {
+		return nil
 	}
-	f.nvim.Call("rpcnotify", nil, 0, "Gui", "filer_item_select", f.selectnum)
+
+	return f.items[row]
 }
 
-func (f *Filer) left() {
-	go func() {
-		f.nvim.Command("silent :tchdir ..")
-	}()
+// activate opens the file, or expands or collapses the directory.
+func (f *Filer) activate(row int) {
+	item := f.item(row)
+	if item == nil {
+		return
+	}
+	if item.IsDir {
+		f.expanded[item.Path] = !f.expanded[item.Path]
+		f.redraw()
+		return
+	}
+	f.nvim.Command(fmt.Sprintf("execute %q fnameescape(%q)", f.openCmd, item.Path))
+	f.nvim.Call("rpcnotify", nil, 0, "Gui", "filer_unfocus")
 }
 
-func (f *Filer) right() {
-	if f.selectnum >= len(f.items) {
+func (f *Filer) expand(row int) {
+	item := f.item(row)
+	if item == nil {
 		return
 	}
-	item := f.items[f.selectnum]
-	filename, ok := item["filename"]
-	if !ok {
+	if !item.IsDir {
+		f.activate(row)
 		return
 	}
-	filetype, ok := item["filetype"]
-	if !ok {
+	if f.expanded[item.Path] {
 		return
 	}
+	f.expanded[item.Path] = true
+	f.redraw()
+}
 
-	command := ""
-	cdCommand := ":tchdir"
-	editCommand := f.openCmd
-	switch filetype {
-	case "/":
-		command = "silent " + cdCommand + " " + filename + " | <CR><CR> | :redraw!"
-	default:
-		command = "silent " + editCommand + " " + filename + " | <CR><CR> | :redraw!"
+// collapse collapses the directory, or the one which contains the entry.
+func (f *Filer) collapse(row int) {
+	item := f.item(row)
+	if item == nil {
+		return
+	}
+	if item.IsDir && f.expanded[item.Path] {
+		delete(f.expanded, item.Path)
+		f.redraw()
+		return
+	}
+	if item.Depth == 0 {
+		return
+	}
+	dir := parentDir(item.Path)
+	delete(f.expanded, dir)
+	f.redraw()
+	if i := indexOfPath(f.items, dir); i >= 0 {
+		f.selectnum = i
+		f.nvim.Call("rpcnotify", nil, 0, "Gui", "filer_item_select", f.selectnum)
 	}
-	go func() {
-		f.nvim.Command(command)
-		if filetype != "/" {
-			f.nvim.Input("<Esc>")
-			// f.nvim.Command("GonvimFilerOpen")
-		}
-	}()
 }
 
-func (f *Filer) cancel() {
-	f.nvim.Call("rpcnotify", nil, 0, "Gui", "side_close")
+// parent changes the cwd of the tab to the parent directory.
+func (f *Filer) parent() {
+	f.nvim.Command("silent tchdir ..")
 }
 
-// TODO
-func (f *Filer) search() {
+func (f *Filer) input(prompt, text string) string {
+	var result string
+	if err := f.nvim.Call("input", &result, prompt, text, "file"); err != nil {
+		return ""
+	}
+
+	return result
+}
+
+func (f *Filer) error(err string) {
+	f.nvim.WritelnErr("goneovim: " + err)
+}
+
+// create creates a file, or a directory if isDir is true,
+// in the directory of the entry.
+func (f *Filer) create(row int, isDir bool) {
+	prompt := "New file: "
+	if isDir {
+		prompt = "New directory: "
+	}
+	path := f.input(prompt, childPath(targetDir(f.root, f.item(row)), ""))
+	if path == "" || baseName(path) == "" {
+		return
+	}
+	// The path which ends with the separator is a directory.
+	if trimmed := strings.TrimRight(path, `/\`); trimmed != path {
+		path, isDir = trimmed, true
+	}
+	f.apply(operation{kind: opCreate, to: path}, isDir)
+}
+
+func (f *Filer) rename(row int) {
+	item := f.item(row)
+	if item == nil {
+		return
+	}
+	path := f.input("Rename: ", item.Path)
+	if path == "" || path == item.Path {
+		return
+	}
+	f.apply(operation{kind: opRename, from: item.Path, to: path}, false)
+}
+
+// delete moves the entry to the trash.
+func (f *Filer) delete(row int) {
+	item := f.item(row)
+	if item == nil {
+		return
+	}
+	var choice int
+	err := f.nvim.Call("confirm", &choice, fmt.Sprintf("Move %s to the trash?", item.Path), "&Yes\n&No", 2)
+	if err != nil || choice != 1 {
+		return
+	}
+	f.apply(operation{kind: opTrash, from: item.Path}, false)
+}
+
+// yank keeps the entry to paste, which is moved instead of copied if isCut.
+func (f *Filer) yank(row int, isCut bool) {
+	item := f.item(row)
+	if item == nil {
+		return
+	}
+	f.clipboard = item.Path
+	f.isCut = isCut
+}
+
+func (f *Filer) paste(row int) {
+	if f.clipboard == "" {
+		return
+	}
+	path := f.input("Paste to: ", childPath(targetDir(f.root, f.item(row)), baseName(f.clipboard)))
+	if path == "" || path == f.clipboard {
+		return
+	}
+	kind := opCopy
+	if f.isCut {
+		kind = opRename
+	}
+	if f.apply(operation{kind: kind, from: f.clipboard, to: path}, false) && f.isCut {
+		f.clipboard = ""
+	}
+}
+
+func (f *Filer) undo() {
+	if len(f.history) == 0 {
+		f.error("no file operation to undo")
+		return
+	}
+	op := f.history[len(f.history)-1]
+	undone := undoOperation(op)
+	if !f.run(&undone, false) {
+		return
+	}
+	f.history = f.history[:len(f.history)-1]
+	f.redraw()
+}
+
+// apply runs the operation, and records it in the undo history.
+func (f *Filer) apply(op operation, isDir bool) bool {
+	if !f.run(&op, isDir) {
+		return false
+	}
+	f.history = pushOperation(f.history, op)
+	if op.kind == opTrash {
+		f.redraw()
+	} else {
+		f.selectPath(op.to)
+	}
+
+	return true
+}
+
+// run runs the operation in nvim. The destination of the trashed entry
+// is set to op.to, which is needed to undo it.
+func (f *Filer) run(op *operation, isDir bool) bool {
+	var err string
+	var callErr error
+	switch op.kind {
+	case opCreate:
+		callErr = f.nvim.ExecLua(`return goneovim_filer.create(...)`, &err, op.to, isDir)
+	case opRename:
+		callErr = f.nvim.ExecLua(`return goneovim_filer.rename(...)`, &err, op.from, op.to)
+	case opCopy:
+		callErr = f.nvim.ExecLua(`return goneovim_filer.copy(...)`, &err, op.from, op.to)
+	case opTrash:
+		var result []string
+		callErr = f.nvim.ExecLua(`return goneovim_filer.trash(...)`, &result, op.from)
+		if len(result) == 2 {
+			op.to, err = result[0], result[1]
+		}
+	case opUntrash:
+		callErr = f.nvim.ExecLua(`return goneovim_filer.untrash(...)`, &err, op.from, op.to)
+	default:
+		return false
+	}
+	if callErr != nil {
+		err = callErr.Error()
+	}
+	if err != "" {
+		f.error(err)
+		return false
+	}
+
+	return true
+}
+
+func (f *Filer) cancel() {
+	f.nvim.Call("rpcnotify", nil, 0, "Gui", "filer_unfocus")
 }
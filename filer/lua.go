@@ -0,0 +1,294 @@
+package filer
+
+// luaScript defines the functions which access the filesystem through
+// vim.loop in nvim, so that the filer works for the remote nvim as well.
+const luaScript = `
+local uv = vim.loop
+local M = _G.goneovim_filer or { watchers = {} }
+_G.goneovim_filer = M
+
+local function join(dir, name)
+  local last = dir:sub(-1)
+  if last == "/" or last == "\\" then
+    return dir .. name
+  end
+  return dir .. "/" .. name
+end
+
+local function exists(path)
+  return uv.fs_lstat(path) ~= nil
+end
+
+local function mkparent(path)
+  vim.fn.mkdir(vim.fn.fnamemodify(path, ":h"), "p")
+end
+
+-- ignored returns the paths which git ignores in the repository of dir.
+local function ignored(dir, paths)
+  local result = {}
+  if #paths == 0 or vim.fn.executable("git") ~= 1 then
+    return result
+  end
+  local out = vim.fn.systemlist({ "git", "-C", dir, "check-ignore", "--stdin" }, paths)
+  -- git exits with 1 if no path is ignored, and 128 out of a repository.
+  if vim.v.shell_error ~= 0 then
+    return result
+  end
+  for _, path in ipairs(out) do
+    result[path] = true
+  end
+  return result
+end
+
+local function scandir(dir, opts)
+  local handle = uv.fs_scandir(dir)
+  if not handle then
+    return {}
+  end
+  local entries = {}
+  while true do
+    local name, typ = uv.fs_scandir_next(handle)
+    if not name then
+      break
+    end
+    if name ~= ".git" and (opts.hidden or name:sub(1, 1) ~= ".") then
+      local path = join(dir, name)
+      if typ == "link" then
+        local stat = uv.fs_stat(path)
+        typ = stat and stat.type or typ
+      end
+      table.insert(entries, { name = name, path = path, dir = typ == "directory" })
+    end
+  end
+  table.sort(entries, function(a, b)
+    if a.dir ~= b.dir then
+      return a.dir
+    end
+    return a.name:lower() < b.name:lower()
+  end)
+  return entries
+end
+
+local pending = false
+local function changed()
+  if pending then
+    return
+  end
+  pending = true
+  vim.defer_fn(function()
+    pending = false
+    vim.rpcnotify(vim.g.goneovim_channel_id, "GonvimFiler", "changed")
+  end, 200)
+end
+
+-- watch watches the changes in dirs, and stops watching the other dirs.
+function M.watch(dirs)
+  local wanted = {}
+  for _, dir in ipairs(dirs) do
+    wanted[dir] = true
+  end
+  for dir, w in pairs(M.watchers) do
+    if not wanted[dir] then
+      w:stop()
+      w:close()
+      M.watchers[dir] = nil
+    end
+  end
+  for dir in pairs(wanted) do
+    if not M.watchers[dir] then
+      local w = uv.new_fs_event()
+      if w and w:start(dir, {}, vim.schedule_wrap(changed)) then
+        M.watchers[dir] = w
+      elseif w then
+        w:close()
+      end
+    end
+  end
+end
+
+-- scan returns the entries under root, descending into the expanded dirs.
+-- The ignored files are checked with a single git for all the entries.
+function M.scan(root, expanded, opts)
+  local entries = {}
+  local function walk(dir, depth)
+    for _, e in ipairs(scandir(dir, opts)) do
+      e.depth = depth
+      e.expanded = e.dir and expanded[e.path] == true
+      table.insert(entries, e)
+      if e.expanded then
+        walk(e.path, depth + 1)
+      end
+    end
+  end
+  walk(root, 0)
+
+  local ig = {}
+  if not opts.ignored then
+    local paths = {}
+    for _, e in ipairs(entries) do
+      table.insert(paths, e.path)
+    end
+    ig = ignored(root, paths)
+  end
+  local result = {}
+  local dirs = { root }
+  local skip = nil
+  for _, e in ipairs(entries) do
+    -- The entries under an ignored dir are skipped with it.
+    if not (skip and e.depth > skip) then
+      skip = nil
+      if ig[e.path] then
+        skip = e.depth
+      else
+        table.insert(result, e)
+        if e.expanded then
+          table.insert(dirs, e.path)
+        end
+      end
+    end
+  end
+  M.watch(opts.watch and dirs or {})
+  return result
+end
+
+function M.create(path, is_dir)
+  if exists(path) then
+    return "already exists: " .. path
+  end
+  mkparent(path)
+  if is_dir then
+    local ok, err = uv.fs_mkdir(path, 493)
+    return ok and "" or err
+  end
+  local fd, err = uv.fs_open(path, "wx", 420)
+  if not fd then
+    return err
+  end
+  uv.fs_close(fd)
+  return ""
+end
+
+local function copy(from, to)
+  local stat = uv.fs_stat(from)
+  if not stat then
+    return "no such file: " .. from
+  end
+  if stat.type ~= "directory" then
+    local ok, err = uv.fs_copyfile(from, to, { excl = true })
+    return ok and "" or err
+  end
+  local ok, err = uv.fs_mkdir(to, stat.mode)
+  if not ok then
+    return err
+  end
+  local handle = uv.fs_scandir(from)
+  while handle do
+    local name = uv.fs_scandir_next(handle)
+    if not name then
+      break
+    end
+    local e = copy(join(from, name), join(to, name))
+    if e ~= "" then
+      return e
+    end
+  end
+  return ""
+end
+
+-- move renames from to to, or copies and deletes it across filesystems,
+-- where rename fails with EXDEV.
+local function move(from, to)
+  local ok, err, name = uv.fs_rename(from, to)
+  if ok then
+    return ""
+  end
+  if name ~= "EXDEV" then
+    return err
+  end
+  err = copy(from, to)
+  if err ~= "" then
+    vim.fn.delete(to, "rf")
+    return err
+  end
+  if vim.fn.delete(from, "rf") ~= 0 then
+    return "failed to delete: " .. from
+  end
+  return ""
+end
+
+function M.rename(from, to)
+  if exists(to) then
+    return "already exists: " .. to
+  end
+  mkparent(to)
+  return move(from, to)
+end
+
+function M.copy(from, to)
+  if exists(to) then
+    return "already exists: " .. to
+  end
+  mkparent(to)
+  return copy(from, to)
+end
+
+-- trashdirs returns the dirs of the trashed files and of their info,
+-- which follow the freedesktop.org trash spec on Linux. On Windows, the
+-- files are kept in a dir of goneovim, which is never emptied, instead of
+-- the Recycle Bin, whose files cannot be restored by path.
+local function trashdirs()
+  if vim.fn.has("mac") == 1 then
+    return vim.fn.expand("~/.Trash"), nil
+  end
+  if vim.fn.has("win32") == 1 then
+    return vim.fn.stdpath("data") .. "/goneovim-trash", nil
+  end
+  local data = os.getenv("XDG_DATA_HOME") or vim.fn.expand("~/.local/share")
+  return data .. "/Trash/files", data .. "/Trash/info"
+end
+
+local function infopath(info, trashed)
+  return join(info, vim.fn.fnamemodify(trashed, ":t") .. ".trashinfo")
+end
+
+-- trash moves path to the trash, and returns where it is moved.
+function M.trash(path)
+  local files, info = trashdirs()
+  vim.fn.mkdir(files, "p")
+  local name = vim.fn.fnamemodify(path, ":t")
+  local to = join(files, name)
+  local n = 1
+  while exists(to) or (info and exists(infopath(info, to))) do
+    n = n + 1
+    to = join(files, name .. "." .. n)
+  end
+  local err = move(path, to)
+  if err ~= "" then
+    return { "", err }
+  end
+  if info then
+    vim.fn.mkdir(info, "p")
+    local encoded = path:gsub("[^%w%-%._~/]", function(c)
+      return string.format("%%%02X", c:byte())
+    end)
+    vim.fn.writefile({
+      "[Trash Info]",
+      "Path=" .. encoded,
+      "DeletionDate=" .. os.date("%Y-%m-%dT%H:%M:%S"),
+    }, infopath(info, to))
+  end
+  return { to, "" }
+end
+
+-- untrash restores the trashed file to path.
+function M.untrash(trashed, path)
+  local err = M.rename(trashed, path)
+  if err == "" then
+    local _, info = trashdirs()
+    if info then
+      os.remove(infopath(info, trashed))
+    end
+  end
+  return err
+end
+`
@@ -0,0 +1,142 @@
+package filer
+
+import (
+	"strings"
+)
+
+// Entry is a file or a directory shown in the tree.
+type Entry struct {
+	Name     string `msgpack:"name"`
+	Path     string `msgpack:"path"`
+	IsDir    bool   `msgpack:"dir"`
+	Expanded bool   `msgpack:"expanded"`
+	Depth    int    `msgpack:"depth"`
+}
+
+// operation is a file operation which can be undone.
+type operation struct {
+	kind string
+	from string
+	to   string
+}
+
+// The kinds of the operations.
+const (
+	opCreate  = "create"
+	opRename  = "rename"
+	opCopy    = "copy"
+	opTrash   = "trash"
+	opUntrash = "untrash"
+)
+
+// maxUndo is the number of the operations which can be undone.
+const maxUndo = 100
+
+// filetypeOf returns the type of the entry,
+// which is "/" for a directory, otherwise the extension.
+func filetypeOf(name string, isDir bool) string {
+	if isDir {
+		return "/"
+	}
+	parts := strings.Split(name, ".")
+	if len(parts) > 1 {
+		return parts[len(parts)-1]
+	}
+
+	return ""
+}
+
+// childPath returns the path of name in dir.
+func childPath(dir, name string) string {
+	if strings.HasSuffix(dir, "/") || strings.HasSuffix(dir, `\`) {
+		return dir + name
+	}
+
+	return dir + "/" + name
+}
+
+// baseName returns the last element of path,
+// which may be separated by either slash.
+func baseName(path string) string {
+	path = strings.TrimRight(path, `/\`)
+	if i := strings.LastIndexAny(path, `/\`); i >= 0 {
+		return path[i+1:]
+	}
+
+	return path
+}
+
+// parentDir returns the directory which contains path.
+func parentDir(path string) string {
+	path = strings.TrimRight(path, `/\`)
+	i := strings.LastIndexAny(path, `/\`)
+	switch {
+	case i < 0:
+		return ""
+	case i == 0:
+		return path[:1]
+	}
+
+	return path[:i]
+}
+
+// ancestorsUnder returns the directories which contain path under root.
+func ancestorsUnder(root, path string) []string {
+	root = strings.TrimRight(root, `/\`)
+	var dirs []string
+	for dir := parentDir(path); len(dir) > len(root) && strings.HasPrefix(dir, root); dir = parentDir(dir) {
+		dirs = append(dirs, dir)
+	}
+
+	return dirs
+}
+
+// targetDir returns the directory where the new entries are created
+// for the entry, which is the entry itself if it is a directory.
+func targetDir(root string, entry *Entry) string {
+	if entry == nil {
+		return root
+	}
+	if entry.IsDir {
+		return entry.Path
+	}
+
+	return parentDir(entry.Path)
+}
+
+// undoOperation returns the operation which undoes op.
+// The created files are moved to the trash instead of being deleted.
+func undoOperation(op operation) operation {
+	switch op.kind {
+	case opCreate, opCopy:
+		return operation{kind: opTrash, from: op.to}
+	case opRename:
+		return operation{kind: opRename, from: op.to, to: op.from}
+	case opTrash:
+		return operation{kind: opUntrash, from: op.to, to: op.from}
+	}
+
+	return operation{}
+}
+
+// pushOperation adds op to the undo history, which keeps
+// the last maxUndo operations.
+func pushOperation(history []operation, op operation) []operation {
+	history = append(history, op)
+	if len(history) > maxUndo {
+		history = history[len(history)-maxUndo:]
+	}
+
+	return history
+}
+
+// indexOfPath returns the index of the entry of path, or -1.
+func indexOfPath(entries []*Entry, path string) int {
+	for i, e := range entries {
+		if e.Path == path {
+			return i
+		}
+	}
+
+	return -1
+}
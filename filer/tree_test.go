@@ -0,0 +1,144 @@
+package filer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFiletypeOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		isDir    bool
+		want     string
+	}{
+		{"filetypeOf() returns the extension", "main.go", false, "go"},
+		{"filetypeOf() returns the last extension", "archive.tar.gz", false, "gz"},
+		{"filetypeOf() returns nothing without the extension", "Makefile", false, ""},
+		{"filetypeOf() returns the slash for the directory", "src.d", true, "/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filetypeOf(tt.filename, tt.isDir); got != tt.want {
+				t.Errorf("filetypeOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChildPath(t *testing.T) {
+	tests := []struct {
+		name string
+		dir  string
+		file string
+		want string
+	}{
+		{"childPath() joins with the slash", "/src", "main.go", "/src/main.go"},
+		{"childPath() does not double the slash", "/", "src", "/src"},
+		{"childPath() keeps the trailing backslash", `C:\`, "src", `C:\src`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := childPath(tt.dir, tt.file); got != tt.want {
+				t.Errorf("childPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParentDirAndBaseName(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		wantParent string
+		wantBase   string
+	}{
+		{"parentDir() and baseName() split the path", "/src/main.go", "/src", "main.go"},
+		{"parentDir() and baseName() ignore the trailing slash", "/src/pkg/", "/src", "pkg"},
+		{"parentDir() returns the root", "/src", "/", "src"},
+		{"parentDir() and baseName() split the windows path", `C:\src\main.go`, `C:\src`, "main.go"},
+		{"parentDir() returns nothing for the name", "main.go", "", "main.go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parentDir(tt.path); got != tt.wantParent {
+				t.Errorf("parentDir() = %q, want %q", got, tt.wantParent)
+			}
+			if got := baseName(tt.path); got != tt.wantBase {
+				t.Errorf("baseName() = %q, want %q", got, tt.wantBase)
+			}
+		})
+	}
+}
+
+func TestAncestorsUnder(t *testing.T) {
+	tests := []struct {
+		name string
+		root string
+		path string
+		want []string
+	}{
+		{"ancestorsUnder() returns the directories under the root", "/src", "/src/a/b/c.go", []string{"/src/a/b", "/src/a"}},
+		{"ancestorsUnder() returns nothing for the entry in the root", "/src/", "/src/c.go", nil},
+		{"ancestorsUnder() returns nothing out of the root", "/src", "/tmp/a/c.go", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ancestorsUnder(tt.root, tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ancestorsUnder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTargetDir(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry *Entry
+		want  string
+	}{
+		{"targetDir() returns the root without the entry", nil, "/src"},
+		{"targetDir() returns the directory itself", &Entry{Path: "/src/pkg", IsDir: true}, "/src/pkg"},
+		{"targetDir() returns the directory of the file", &Entry{Path: "/src/pkg/a.go"}, "/src/pkg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := targetDir("/src", tt.entry); got != tt.want {
+				t.Errorf("targetDir() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUndoOperation(t *testing.T) {
+	tests := []struct {
+		name string
+		op   operation
+		want operation
+	}{
+		{"undoOperation() trashes the created file", operation{kind: opCreate, to: "/a"}, operation{kind: opTrash, from: "/a"}},
+		{"undoOperation() trashes the copy", operation{kind: opCopy, from: "/a", to: "/b"}, operation{kind: opTrash, from: "/b"}},
+		{"undoOperation() renames back", operation{kind: opRename, from: "/a", to: "/b"}, operation{kind: opRename, from: "/b", to: "/a"}},
+		{"undoOperation() restores the trashed file", operation{kind: opTrash, from: "/a", to: "/trash/a"}, operation{kind: opUntrash, from: "/trash/a", to: "/a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := undoOperation(tt.op); got != tt.want {
+				t.Errorf("undoOperation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPushOperation(t *testing.T) {
+	var history []operation
+	for i := 0; i < maxUndo+5; i++ {
+		history = pushOperation(history, operation{kind: opCreate, to: string(rune('a' + i%26))})
+	}
+	if len(history) != maxUndo {
+		t.Fatalf("pushOperation() keeps %d operations, want %d", len(history), maxUndo)
+	}
+	if got, want := history[len(history)-1].to, string(rune('a'+(maxUndo+4)%26)); got != want {
+		t.Errorf("pushOperation() keeps %q at the last, want %q", got, want)
+	}
+}